@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"Movies-Go/internal/pkg/movieimport"
+	"Movies-Go/internal/pkg/repository/postgres"
+	"Movies-Go/internal/repository/postgres/movies"
+)
+
+const importUsage = `usage: movies-api import [flags] <file>
+
+Imports movies from a CSV or NDJSON file, or from stdin when file is "-",
+and prints the import report as JSON.
+
+flags:`
+
+// runImport implements the import subcommand and returns the exit code: 1
+// when any row was rejected, 2 on bad usage.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, importUsage)
+		flags.PrintDefaults()
+	}
+
+	format := flags.String("format", "", "csv or ndjson (default: from the file extension)")
+	var opts movies.ImportOptions
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "validate the rows and roll back")
+	flags.BoolVar(&opts.Upsert, "upsert", false, "update movies whose external_id already exists")
+	flags.IntVar(&opts.BatchSize, "batch-size", 0, "commit every n rows; 0 imports all rows or none")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || opts.BatchSize < 0 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	kind := movieimport.Format(*format)
+	if kind == "" {
+		detected, ok := movieimport.FormatFor("", path)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "cannot tell the format of", path+", pass -format")
+			return 2
+		}
+		kind = detected
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer file.Close()
+		in = file
+	}
+
+	rows, err := movieimport.Parse(in, kind)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	db := postgres.Connect()
+	defer db.Close()
+
+	report, err := movies.NewRepository(db).Import(context.Background(), rows, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 1
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -2,28 +2,99 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
 	"go.uber.org/fx"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"time"
 
+	apikeys_controller "Movies-Go/internal/controller/http/v1/apikeys"
 	auth_controller "Movies-Go/internal/controller/http/v1/auth"
+	collections_controller "Movies-Go/internal/controller/http/v1/collections"
+	genres_controller "Movies-Go/internal/controller/http/v1/genres"
+	health_controller "Movies-Go/internal/controller/http/v1/health"
 	movies_controller "Movies-Go/internal/controller/http/v1/movies"
+	people_controller "Movies-Go/internal/controller/http/v1/people"
+	recommendations_controller "Movies-Go/internal/controller/http/v1/recommendations"
+	reviews_controller "Movies-Go/internal/controller/http/v1/reviews"
 	users_controller "Movies-Go/internal/controller/http/v1/users"
+	watchlists_controller "Movies-Go/internal/controller/http/v1/watchlists"
+	"Movies-Go/internal/pkg/accountmail"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/auth"
 	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/healthcheck"
+	"Movies-Go/internal/pkg/mailer"
+	"Movies-Go/internal/pkg/metrics"
+	"Movies-Go/internal/pkg/middleware"
+	"Movies-Go/internal/pkg/oidc"
+	"Movies-Go/internal/pkg/ratelimit"
+	"Movies-Go/internal/pkg/recommender"
 	"Movies-Go/internal/pkg/repository/postgres"
+	"Movies-Go/internal/pkg/tracing"
+	"Movies-Go/internal/repository/postgres/apikeys"
+	"Movies-Go/internal/repository/postgres/collections"
+	"Movies-Go/internal/repository/postgres/genres"
+	"Movies-Go/internal/repository/postgres/identities"
 	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/repository/postgres/people"
+	"Movies-Go/internal/repository/postgres/recommendations"
+	"Movies-Go/internal/repository/postgres/reviews"
+	"Movies-Go/internal/repository/postgres/sessions"
+	"Movies-Go/internal/repository/postgres/tokens"
+	"Movies-Go/internal/repository/postgres/twofactor"
 	"Movies-Go/internal/repository/postgres/users"
+	"Movies-Go/internal/repository/postgres/watchlists"
+	apikeys_router "Movies-Go/internal/router/apikeys"
 	auth_router "Movies-Go/internal/router/auth"
+	collections_router "Movies-Go/internal/router/collections"
+	docs_router "Movies-Go/internal/router/docs"
+	genres_router "Movies-Go/internal/router/genres"
+	health_router "Movies-Go/internal/router/health"
 	movies_router "Movies-Go/internal/router/movies"
+	people_router "Movies-Go/internal/router/people"
+	recommendations_router "Movies-Go/internal/router/recommendations"
+	reviews_router "Movies-Go/internal/router/reviews"
 	users_router "Movies-Go/internal/router/users"
+	watchlists_router "Movies-Go/internal/router/watchlists"
 )
 
-func ProvideDB() *bun.DB {
-	return postgres.NewPostgres()
+// ProvideDB connects to the database and closes the connection pool once
+// everything that uses it has stopped.
+func ProvideDB(lifecycle fx.Lifecycle) *bun.DB {
+	db := postgres.NewPostgres()
+	metrics.InstrumentDB(db, config.GetConf().DBName)
+	tracing.InstrumentDB(db, config.GetConf().TracingQueryText)
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Println("Closing database connections")
+			return db.Close()
+		},
+	})
+
+	return db
+}
+
+// ProvideHealthChecker sets up the checks of the readiness probe: the
+// database answers and its schema is up to date.
+func ProvideHealthChecker(db *bun.DB) (*healthcheck.Checker, error) {
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := healthcheck.NewChecker(healthcheck.DefaultTimeout)
+	checker.Add("postgres", db.PingContext)
+	checker.Add("migrations", postgres.SchemaCheck(migrator))
+
+	return checker, nil
 }
 
 func ProvideMoviesRepo(db *bun.DB) *movies.Repository {
@@ -34,20 +105,179 @@ func ProvideUsersRepo(db *bun.DB) *users.Repository {
 	return users.NewRepository(db)
 }
 
-func ProvideMoviesController(repo *movies.Repository) *movies_controller.Controller {
-	return movies_controller.NewController(repo)
+func ProvideGenresRepo(db *bun.DB) *genres.Repository {
+	return genres.NewRepository(db)
+}
+
+func ProvidePeopleRepo(db *bun.DB) *people.Repository {
+	return people.NewRepository(db)
+}
+
+func ProvideReviewsRepo(db *bun.DB) *reviews.Repository {
+	return reviews.NewRepository(db)
+}
+
+func ProvideSessionsRepo(db *bun.DB) *sessions.Repository {
+	return sessions.NewRepository(db)
+}
+
+func ProvideTokensRepo(db *bun.DB) *tokens.Repository {
+	return tokens.NewRepository(db)
+}
+
+func ProvideTwoFactorRepo(db *bun.DB) *twofactor.Repository {
+	return twofactor.NewRepository(db)
+}
+
+func ProvideIdentitiesRepo(db *bun.DB) *identities.Repository {
+	return identities.NewRepository(db)
+}
+
+func ProvideAPIKeysRepo(db *bun.DB) *apikeys.Repository {
+	return apikeys.NewRepository(db)
+}
+
+func ProvideWatchlistsRepo(db *bun.DB) *watchlists.Repository {
+	return watchlists.NewRepository(db)
+}
+
+func ProvideCollectionsRepo(db *bun.DB) *collections.Repository {
+	return collections.NewRepository(db)
+}
+
+func ProvideRecommendationsRepo(db *bun.DB) *recommendations.Repository {
+	return recommendations.NewRepository(db)
+}
+
+func ProvideMoviesController(repo *movies.Repository, watchlistsRepo *watchlists.Repository) *movies_controller.Controller {
+	return movies_controller.NewController(repo, watchlistsRepo)
+}
+
+func ProvideUsersController(repo *users.Repository, sessionsRepo *sessions.Repository, tokensRepo *tokens.Repository, mail mailer.Mailer) *users_controller.Controller {
+	return users_controller.NewController(repo, sessionsRepo, accountmail.NewSender(tokensRepo, mail))
+}
+
+func ProvideGenresController(repo *genres.Repository) *genres_controller.Controller {
+	return genres_controller.NewController(repo)
+}
+
+func ProvidePeopleController(repo *people.Repository, moviesRepo *movies.Repository) *people_controller.Controller {
+	return people_controller.NewController(repo, moviesRepo)
+}
+
+func ProvideReviewsController(repo *reviews.Repository, moviesRepo *movies.Repository, usersRepo *users.Repository) *reviews_controller.Controller {
+	return reviews_controller.NewController(repo, moviesRepo, usersRepo)
+}
+
+func ProvideWatchlistsController(repo *watchlists.Repository, moviesRepo *movies.Repository) *watchlists_controller.Controller {
+	return watchlists_controller.NewController(repo, moviesRepo)
+}
+
+func ProvideCollectionsController(repo *collections.Repository, moviesRepo *movies.Repository, usersRepo *users.Repository) *collections_controller.Controller {
+	return collections_controller.NewController(repo, moviesRepo, usersRepo)
+}
+
+func ProvideRecommendationsController(repo *recommendations.Repository, moviesRepo *movies.Repository) *recommendations_controller.Controller {
+	return recommendations_controller.NewController(repo, moviesRepo)
+}
+
+func ProvideAPIKeysController(repo *apikeys.Repository) *apikeys_controller.Controller {
+	return apikeys_controller.NewController(repo)
+}
+
+func ProvideHealthController(checker *healthcheck.Checker) *health_controller.Controller {
+	return health_controller.NewController(checker)
 }
 
-func ProvideUsersController(repo *users.Repository) *users_controller.Controller {
-	return users_controller.NewController(repo)
+func ProvideAuthController(
+	repo *users.Repository,
+	sessionsRepo *sessions.Repository,
+	tokensRepo *tokens.Repository,
+	twoFactorRepo *twofactor.Repository,
+	identitiesRepo *identities.Repository,
+	mail mailer.Mailer,
+	store ratelimit.Store,
+	providers oidc.Providers,
+) *auth_controller.Controller {
+	loginLimiter := ratelimit.NewLimiter(store, "login", config.GetConf().AccountLoginRateLimit())
+	return auth_controller.NewController(repo, sessionsRepo, tokensRepo, twoFactorRepo, identitiesRepo, mail, loginLimiter, providers)
 }
 
-func ProvideAuthController(repo *users.Repository) *auth_controller.Controller {
-	return auth_controller.NewController(repo)
+// ProvideOIDCProviders sets up the clients of the identity providers in
+// oidc_providers. They discover the providers on first use.
+func ProvideOIDCProviders() oidc.Providers {
+	conf := config.GetConf()
+
+	providers := oidc.Providers{}
+	for _, provider := range conf.OIDCProviders {
+		providers[provider.Name] = oidc.New(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  conf.OIDCRedirectURL(provider),
+			Scopes:       provider.Scopes,
+		})
+	}
+
+	return providers
+}
+
+// ProvideMailer sends the verification and password reset emails as
+// configured by mail_driver.
+func ProvideMailer() (mailer.Mailer, error) {
+	conf := config.GetConf()
+
+	return mailer.New(mailer.Options{
+		Driver:       conf.MailDriver,
+		From:         conf.MailSender(),
+		Path:         conf.MailFile,
+		SMTPHost:     conf.SMTPHost,
+		SMTPPort:     conf.SMTPPort,
+		SMTPUsername: conf.SMTPUsername,
+		SMTPPassword: conf.SMTPPassword,
+	})
+}
+
+// ProvideRateLimitStore keeps the rate limit buckets in memory, so limits
+// apply per instance. A shared Store, e.g. on Redis, can be provided
+// instead to enforce them across instances.
+func ProvideRateLimitStore() ratelimit.Store {
+	return ratelimit.NewMemoryStore()
+}
+
+// ProvideAuthLimiter throttles the routes that accept credentials per
+// client address.
+func ProvideAuthLimiter(store ratelimit.Store) *ratelimit.Limiter {
+	return ratelimit.NewLimiter(store, "auth", config.GetConf().AuthEndpointsRateLimit())
+}
+
+// RegisterSessionChecker lets access tokens be rejected as soon as their
+// session is revoked.
+func RegisterSessionChecker(repo *sessions.Repository) {
+	auth.UseSessionChecker(repo)
+}
+
+// RegisterAPIKeyValidator lets requests authenticate with API keys.
+func RegisterAPIKeyValidator(repo *apikeys.Repository) {
+	auth.UseAPIKeyValidator(repo)
 }
 
-func ProvideRouter() *gin.Engine {
+func ProvideRouter(store ratelimit.Store) (*gin.Engine, error) {
+	conf := config.GetConf()
+
 	r := gin.Default()
+	// Handlers pass the gin context to the repositories; let it carry the
+	// request's cancellation and trace.
+	r.ContextWithFallback = true
+
+	// Client addresses, which rate limits are keyed by, are only taken from
+	// X-Forwarded-For when the request comes through a trusted proxy.
+	if err := r.SetTrustedProxies(conf.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted_proxies: %w", err)
+	}
+
+	r.Use(metrics.Middleware())
+	r.Use(tracing.Middleware())
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -61,7 +291,14 @@ func ProvideRouter() *gin.Engine {
 		MaxAge: 12 * time.Hour,
 	}))
 
-	return r
+	r.Use(middleware.ErrorHandler())
+	r.Use(ratelimit.Middleware(ratelimit.NewLimiter(store, "api", conf.APIRateLimit()), ratelimit.ByIP))
+
+	r.NoRoute(func(c *gin.Context) {
+		c.Error(apperror.NotFound("No route matches %s %s", c.Request.Method, c.Request.URL.Path))
+	})
+
+	return r, nil
 }
 
 func RegisterRoutes(
@@ -69,56 +306,173 @@ func RegisterRoutes(
 	moviesController *movies_controller.Controller,
 	usersController *users_controller.Controller,
 	authController *auth_controller.Controller,
+	genresController *genres_controller.Controller,
+	peopleController *people_controller.Controller,
+	reviewsController *reviews_controller.Controller,
+	watchlistsController *watchlists_controller.Controller,
+	collectionsController *collections_controller.Controller,
+	recommendationsController *recommendations_controller.Controller,
+	healthController *health_controller.Controller,
+	apiKeysController *apikeys_controller.Controller,
+	authLimiter *ratelimit.Limiter,
 ) {
+	// Scraped by Prometheus; not part of the versioned API.
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	api := r.Group("api")
 	{
 		v1 := api.Group("v1")
 
-		v1.GET("/health", func(c *gin.Context) {
-			now := time.Now()
-			c.JSON(http.StatusOK, gin.H{
-				"status":  "healthy",
-				"time":    now.Format(time.RFC3339),
-				"version": "1.0.0",
-			})
-		})
-
+		health_router.Router(v1, healthController)
 		movies_router.Router(v1, moviesController)
 		users_router.Router(v1, usersController)
-		auth_router.Router(v1, authController)
+		auth_router.Router(v1, authController, authLimiter)
+		genres_router.Router(v1, genresController)
+		people_router.Router(v1, peopleController)
+		reviews_router.Router(v1, reviewsController)
+		watchlists_router.Router(v1, watchlistsController)
+		collections_router.Router(v1, collectionsController)
+		recommendations_router.Router(v1, recommendationsController)
+		apikeys_router.Router(v1, apiKeysController)
+		docs_router.Router(v1, docs_router.Spec())
 	}
 }
 
-// StartServer starts the HTTP server
-func StartServer(lifecycle fx.Lifecycle, r *gin.Engine) {
+// StartRecommendationsJob recomputes similar movies and personal
+// recommendations in the background while the service runs.
+func StartRecommendationsJob(lifecycle fx.Lifecycle, repo *recommendations.Repository) {
+	job := recommender.NewJob(repo, config.GetConf().RecommendationsRefreshInterval())
+
 	lifecycle.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			log.Println("Starting server on port", config.GetConf().Port)
+			job.Start()
+			return nil
+		},
+		OnStop: job.Stop,
+	})
+}
+
+// StartTracing installs the tracer provider and flushes the remaining spans
+// once everything else has stopped.
+func StartTracing(lifecycle fx.Lifecycle) error {
+	conf := config.GetConf()
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    conf.TracingExporter,
+		Endpoint:    conf.TracingEndpoint,
+		SampleRatio: conf.TracingSampling(),
+	})
+	if err != nil {
+		return err
+	}
+
+	lifecycle.Append(fx.Hook{OnStop: shutdown})
+	return nil
+}
+
+func ProvideServer(r *gin.Engine) *http.Server {
+	conf := config.GetConf()
+
+	return &http.Server{
+		Addr:              ":" + conf.Port,
+		Handler:           r,
+		ReadHeaderTimeout: conf.ServerReadTimeout(),
+		ReadTimeout:       conf.ServerReadTimeout(),
+		WriteTimeout:      conf.ServerWriteTimeout(),
+		IdleTimeout:       conf.ServerIdleTimeout(),
+	}
+}
+
+// StartServer starts the HTTP server. The port is bound before the app
+// finishes starting, so that a port in use fails the start; an error while
+// serving afterwards shuts the app down with exit code 1. On stop the
+// server stops accepting connections and waits up to shutdown_timeout for
+// in-flight requests, then closes the remaining connections.
+func StartServer(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner, server *http.Server) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return fmt.Errorf("listening on %s: %w", server.Addr, err)
+			}
+
+			log.Println("Starting server on", listener.Addr())
 			go func() {
-				if err := r.Run(":" + config.GetConf().Port); err != nil {
-					log.Fatal("Server failed to start:", err)
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Println("Server failed:", err)
+					shutdowner.Shutdown(fx.ExitCode(1))
 				}
 			}()
+
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Println("Stopping server")
+
+			ctx, cancel := context.WithTimeout(ctx, config.GetConf().ServerShutdownTimeout())
+			defer cancel()
+
+			if err := server.Shutdown(ctx); err != nil {
+				server.Close()
+				return fmt.Errorf("waiting for in-flight requests: %w", err)
+			}
+
 			return nil
 		},
 	})
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "set-role":
+			os.Exit(runSetRole(os.Args[2:]))
+		case "mock-oidc":
+			os.Exit(runMockOIDC(os.Args[2:]))
+		}
+	}
+
 	fx.New(
 		fx.Provide(
 			ProvideDB,
 			ProvideMoviesRepo,
 			ProvideUsersRepo,
+			ProvideGenresRepo,
+			ProvidePeopleRepo,
+			ProvideReviewsRepo,
+			ProvideSessionsRepo,
+			ProvideTokensRepo,
+			ProvideTwoFactorRepo,
+			ProvideIdentitiesRepo,
+			ProvideAPIKeysRepo,
+			ProvideWatchlistsRepo,
+			ProvideCollectionsRepo,
+			ProvideRecommendationsRepo,
 			ProvideMoviesController,
 			ProvideUsersController,
 			ProvideAuthController,
+			ProvideGenresController,
+			ProvidePeopleController,
+			ProvideReviewsController,
+			ProvideWatchlistsController,
+			ProvideCollectionsController,
+			ProvideRecommendationsController,
+			ProvideHealthChecker,
+			ProvideHealthController,
+			ProvideAPIKeysController,
+			ProvideRateLimitStore,
+			ProvideMailer,
+			ProvideOIDCProviders,
+			ProvideAuthLimiter,
 			ProvideRouter,
+			ProvideServer,
 		),
-		fx.Invoke(RegisterRoutes, StartServer),
+		fx.Invoke(StartTracing, RegisterSessionChecker, RegisterAPIKeyValidator, RegisterRoutes, StartRecommendationsJob, StartServer),
+		// Leave time to close the database after draining requests.
+		fx.StopTimeout(config.GetConf().ServerShutdownTimeout()+5*time.Second),
 	).Run()
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"Movies-Go/internal/pkg/openapi"
+	docs_router "Movies-Go/internal/router/docs"
+)
+
+// unversioned lists the routes served outside the API on purpose.
+var unversioned = map[string]bool{
+	"GET /metrics": true,
+}
+
+// TestOpenAPICoversRoutes fails when a route is registered without being
+// documented in the OpenAPI spec, or documented without being registered.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	RegisterRoutes(r, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	spec := docs_router.Spec()
+	registered := map[string]bool{}
+
+	for _, route := range r.Routes() {
+		if unversioned[route.Method+" "+route.Path] {
+			continue
+		}
+
+		path, ok := strings.CutPrefix(route.Path, docs_router.BasePath)
+		if !ok {
+			t.Errorf("%s %s is not under %s", route.Method, route.Path, docs_router.BasePath)
+			continue
+		}
+
+		registered[route.Method+" "+openapi.Path(path)] = true
+		if !spec.Has(route.Method, path) {
+			t.Errorf("%s %s is missing from the OpenAPI spec", route.Method, path)
+		}
+	}
+
+	for _, operation := range spec.Operations() {
+		if !registered[operation] {
+			t.Errorf("%s is documented but not registered", operation)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"Movies-Go/internal/pkg/repository/migrate"
+	"Movies-Go/internal/pkg/repository/postgres"
+)
+
+// migrationsDir is where `migrate create` writes new files. It is relative
+// to the repository root, the other subcommands use the embedded copies.
+const migrationsDir = "internal/pkg/repository/script/migrations"
+
+const migrateUsage = `usage: movies-api migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [steps]   roll back the last steps migrations (default 1)
+  status         list migrations and whether they are applied
+  create <name>  add an empty up/down migration pair`
+
+// runMigrate implements the migrate subcommand and returns the exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+
+		paths, err := migrate.Create(migrationsDir, args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "create migration:", err)
+			return 1
+		}
+		for _, path := range paths {
+			fmt.Println("created", path)
+		}
+		return 0
+	}
+
+	db := postgres.Connect()
+	defer db.Close()
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load migrations:", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, "steps must be a positive number")
+				return 2
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.AppliedAt != nil && s.Up == "":
+				state = "applied, files missing"
+			case s.Modified:
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05") + ", MODIFIED"
+			case s.AppliedAt != nil:
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"Movies-Go/internal/pkg/oidc/oidctest"
+)
+
+const mockOIDCUsage = `usage: movies-api mock-oidc [flags]
+
+Runs an OpenID Connect provider for local development that signs in
+anyone without asking: the user given by the flags, or the email address
+passed as login_hint in the authorization URL.
+
+flags:`
+
+// runMockOIDC implements the mock-oidc subcommand and returns the exit
+// code.
+func runMockOIDC(args []string) int {
+	flags := flag.NewFlagSet("mock-oidc", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, mockOIDCUsage)
+		flags.PrintDefaults()
+	}
+
+	addr := flags.String("addr", "localhost:9400", "address to listen on")
+	issuer := flags.String("issuer", "", "issuer URL (default: http://<addr>)")
+	clientID := flags.String("client-id", "movies-go", "client_id of the service")
+	clientSecret := flags.String("client-secret", "secret", "client_secret of the service, empty for a public client")
+	email := flags.String("email", "user@example.com", "email address of the user signed in")
+	name := flags.String("name", "Mock User", "name of the user signed in")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+	if *issuer == "" {
+		*issuer = "http://" + *addr
+	}
+
+	provider, err := oidctest.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "create provider:", err)
+		return 1
+	}
+	provider.User = oidctest.User{
+		Subject:       "mock-" + *email,
+		Email:         *email,
+		EmailVerified: true,
+		Name:          *name,
+	}
+
+	log.Printf("Mock OpenID Connect provider for client %q listening on %s, issuer %s", *clientID, *addr, provider.Issuer)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/repository/postgres"
+	"Movies-Go/internal/repository/postgres/sessions"
+	"Movies-Go/internal/repository/postgres/users"
+)
+
+const setRoleUsage = `usage: movies-api set-role <email> <role>
+
+Changes the role of the account with the email address to admin, editor
+or viewer. New installations have no admin: register an account, then
+promote it with this command.`
+
+// runSetRole implements the set-role subcommand and returns the exit code.
+func runSetRole(args []string) int {
+	if len(args) != 2 || !validRole(args[1]) {
+		fmt.Fprintln(os.Stderr, setRoleUsage)
+		return 2
+	}
+	email, role := args[0], args[1]
+
+	db := postgres.Connect()
+	defer db.Close()
+
+	repo := users.NewRepository(db)
+	ctx := context.Background()
+
+	user, err := repo.GetByEmail(ctx, email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		fmt.Fprintln(os.Stderr, "no account has the email address", email)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "find user:", err)
+		return 1
+	}
+
+	if err := repo.UpdateRole(ctx, user.Id, role); err != nil {
+		fmt.Fprintln(os.Stderr, "set role:", err)
+		return 1
+	}
+
+	if role != user.Role {
+		if _, err := sessions.NewRepository(db).RevokeAll(ctx, user.Id); err != nil {
+			fmt.Fprintln(os.Stderr, "log out:", err)
+			return 1
+		}
+	}
+
+	fmt.Printf("set the role of %s to %s\n", email, role)
+	return 0
+}
+
+func validRole(role string) bool {
+	for _, r := range entity.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
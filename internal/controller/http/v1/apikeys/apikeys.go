@@ -0,0 +1,132 @@
+package apikeys
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/repository/postgres/apikeys"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	repo Repository
+}
+
+func NewController(repo Repository) *Controller {
+	return &Controller{
+		repo: repo,
+	}
+}
+
+func toResponse(key *entity.APIKey) apikeys.APIKeyResponse {
+	return apikeys.APIKeyResponse{
+		ID:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// Create issues an API key for the current user. The key itself is only
+// in this response; afterwards only its prefix is shown.
+func (c *Controller) Create(ctx *gin.Context) {
+	var req apikeys.CreateAPIKeyRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		ctx.Error(apperror.InvalidField("expires_at", "must be in the future"))
+		return
+	}
+
+	secret, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	key := &entity.APIKey{
+		UserId:    ctx.GetInt("user_id"),
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    dedupe(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := c.repo.Create(ctx, key); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "API key created, copy it now as it will not be shown again",
+		"data": apikeys.CreatedAPIKeyResponse{
+			Key:    secret,
+			APIKey: toResponse(key),
+		},
+	})
+}
+
+// GetAll lists the API keys of the current user.
+func (c *Controller) GetAll(ctx *gin.Context) {
+	keys, err := c.repo.GetAll(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	results := make([]apikeys.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		results[i] = toResponse(key)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"results": results,
+			"count":   len(results),
+		},
+	})
+}
+
+// Revoke stops one of the current user's API keys from working.
+func (c *Controller) Revoke(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid API key ID"))
+		return
+	}
+
+	if err := c.repo.Revoke(ctx, ctx.GetInt("user_id"), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked successfully",
+	})
+}
+
+func dedupe(scopes []string) []string {
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,14 @@
+package apikeys
+
+import (
+	"Movies-Go/internal/entity"
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+
+	GetAll(ctx context.Context, userID int) ([]*entity.APIKey, error)
+
+	Revoke(ctx context.Context, userID, id int) error
+}
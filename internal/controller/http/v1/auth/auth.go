@@ -2,28 +2,101 @@ package auth
 
 import (
 	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/accountmail"
+	"Movies-Go/internal/pkg/apperror"
 	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/mailer"
+	"Movies-Go/internal/pkg/oidc"
+	"Movies-Go/internal/pkg/ratelimit"
+	"Movies-Go/internal/repository/postgres/sessions"
 	"Movies-Go/internal/repository/postgres/users"
 	"Movies-Go/internal/utils/password"
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// errInvalidCredentials refuses a login, whatever was wrong with it.
+var errInvalidCredentials = apperror.Unauthorized("Invalid credentials")
+
 type Repository interface {
+	GetByID(ctx context.Context, id int) (*entity.User, error)
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	Create(ctx context.Context, user *entity.User) error
+	RecordFailedLogin(ctx context.Context, id, threshold int, duration, max time.Duration) (*time.Time, error)
+	ResetFailedLogins(ctx context.Context, id int) error
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (*entity.Session, error)
+	Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*entity.Session, error)
+	Revoke(ctx context.Context, userID, sessionID int) error
+	RevokeAll(ctx context.Context, userID int) (int, error)
+}
+
+type TokenRepository interface {
+	Issue(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+}
+
+type TwoFactorRepository interface {
+	Begin(ctx context.Context, userID int, secret string) error
+	Enable(ctx context.Context, userID int, step int64, codeHashes []string) error
+	Disable(ctx context.Context, userID int) error
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+	IsRequired(ctx context.Context, role string) (bool, error)
+	RequiredRoles(ctx context.Context) ([]string, error)
+	SetRequiredRoles(ctx context.Context, roles []string) error
+}
+
+type IdentityRepository interface {
+	SignIn(ctx context.Context, provider, subject string) (*entity.User, error)
+	Link(ctx context.Context, userID int, provider, subject, email string) error
+	Provision(ctx context.Context, user *entity.User, provider, subject string) error
 }
 
 type Controller struct {
-	userRepo Repository
+	userRepo      Repository
+	sessionRepo   SessionRepository
+	tokenRepo     TokenRepository
+	twoFactorRepo TwoFactorRepository
+	identityRepo  IdentityRepository
+	mail          *accountmail.Sender
+	// loginLimiter throttles logins per account, whatever their address.
+	loginLimiter *ratelimit.Limiter
+	providers    oidc.Providers
 }
 
-func NewController(userRepo Repository) *Controller {
+func NewController(
+	userRepo Repository,
+	sessionRepo SessionRepository,
+	tokenRepo TokenRepository,
+	twoFactorRepo TwoFactorRepository,
+	identityRepo IdentityRepository,
+	mailer mailer.Mailer,
+	loginLimiter *ratelimit.Limiter,
+	providers oidc.Providers,
+) *Controller {
 	return &Controller{
-		userRepo: userRepo,
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		tokenRepo:     tokenRepo,
+		twoFactorRepo: twoFactorRepo,
+		identityRepo:  identityRepo,
+		mail:          accountmail.NewSender(tokenRepo, mailer),
+		loginLimiter:  loginLimiter,
+		providers:     providers,
 	}
 }
 
@@ -31,25 +104,19 @@ func (c *Controller) Register(ctx *gin.Context) {
 	var req users.RegisterRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+		ctx.Error(apperror.Binding(err))
 		return
 	}
 
 	existingUser, err := c.userRepo.GetByEmail(ctx, req.Email)
 	if err == nil && existingUser != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "User with this email already exists",
-		})
+		ctx.Error(apperror.Conflict("User with this email already exists"))
 		return
 	}
 
 	hashedPassword, err := password.Hash(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process password: " + err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
@@ -58,82 +125,277 @@ func (c *Controller) Register(ctx *gin.Context) {
 		Name:      req.Name,
 		Email:     req.Email,
 		Password:  hashedPassword,
+		Role:      entity.RoleViewer,
 		CreatedAt: &now,
 		UpdatedAt: &now,
 	}
 
 	if err := c.userRepo.Create(ctx, user); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create user: " + err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
-	token, err := auth.GenerateToken(user.Id, user.Email)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate token: " + err.Error(),
+	// The account exists either way; the user can ask for another email.
+	if err := c.mail.SendVerification(ctx, user); err != nil {
+		log.Printf("Sending the verification email to user %d: %v", user.Id, err)
+	}
+
+	if !config.GetConf().AllowUnverifiedLogin {
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message": "Registered successfully, follow the link sent to your email address to verify it",
+			"data":    userResponse(user),
 		})
 		return
 	}
 
-	response := users.AuthResponse{
-		Token: token,
-		User: users.UserResponse{
-			ID:        user.Id,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		},
+	response, err := c.openSession(ctx, user)
+	if err != nil {
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusCreated, response)
 }
 
+// Login checks the credentials and opens a session. Logins are throttled
+// per account, and repeated failures lock the account for a growing period
+// during which the password is not even checked. Unknown and locked
+// accounts are refused like a wrong password, in about the same time, so
+// that neither reveals which addresses are registered. Users with
+// two-factor authentication get a challenge for LoginTwoFactor instead of
+// a session.
 func (c *Controller) Login(ctx *gin.Context) {
 	var req users.LoginRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if !ratelimit.Apply(ctx, c.loginLimiter, strings.ToLower(req.Email)) {
 		return
 	}
 
 	user, err := c.userRepo.GetByEmail(ctx, req.Email)
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid credentials",
-		})
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(err)
+		return
+	}
+
+	if user == nil || user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		password.VerifyDummy(req.Password)
+		ctx.Error(errInvalidCredentials)
 		return
 	}
 
 	if !password.Verify(user.Password, req.Password) {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid credentials",
-		})
+		c.failLogin(ctx, user, errInvalidCredentials)
 		return
 	}
 
-	token, err := auth.GenerateToken(user.Id, user.Email)
+	if user.EmailVerifiedAt == nil && !config.GetConf().AllowUnverifiedLogin {
+		ctx.Error(apperror.Forbidden("Email address is not verified"))
+		return
+	}
+
+	c.finishLogin(ctx, user)
+}
+
+// finishLogin completes a login of the user after the first factor, the
+// password or an identity provider, passed. With two-factor
+// authentication, or when the role requires it, that only earns a
+// challenge for the second step.
+func (c *Controller) finishLogin(ctx *gin.Context, user *entity.User) {
+	required, err := c.twoFactorRepo.IsRequired(ctx, user.Role)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate token: " + err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
+	if user.TwoFactorEnabled() || required {
+		challenge, err := challengeResponse(user.Id, !user.TwoFactorEnabled())
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
 
-	response := users.AuthResponse{
-		Token: token,
-		User: users.UserResponse{
-			ID:        user.Id,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		},
+		ctx.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	// Failures are only forgiven once every step passed, so that the
+	// password alone cannot reset the count of failed two-factor codes.
+	if user.FailedAttempts > 0 || user.LockedUntil != nil {
+		if err := c.userRepo.ResetFailedLogins(ctx, user.Id); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	response, err := c.openSession(ctx, user)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. Each refresh token works once; replaying a used one revokes the
+// session it belongs to.
+func (c *Controller) Refresh(ctx *gin.Context) {
+	var req sessions.RefreshRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	session, err := c.sessionRepo.Rotate(ctx, auth.HashRefreshToken(req.RefreshToken), refreshHash, refreshExpiry())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The role is read again so that role changes apply on the next refresh.
+	user, err := c.userRepo.GetByID(ctx, session.UserId)
+	if errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(sessions.ErrSessionRevoked)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Sessions opened before the role required two-factor authentication
+	// end, so that the user enrolls at the next login.
+	if !user.TwoFactorEnabled() {
+		required, err := c.twoFactorRepo.IsRequired(ctx, user.Role)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if required {
+			if err := c.sessionRepo.Revoke(ctx, user.Id, session.Id); err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			ctx.Error(apperror.Unauthorized("Two-factor authentication is required for your role, log in again to set it up"))
+			return
+		}
+	}
+
+	response, err := authResponse(user, session.Id, refreshToken)
+	if err != nil {
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+// Logout revokes the session of the access token used for the request.
+func (c *Controller) Logout(ctx *gin.Context) {
+	err := c.sessionRepo.Revoke(ctx, ctx.GetInt("user_id"), ctx.GetInt("session_id"))
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAll revokes every session of the current user, on all devices.
+func (c *Controller) LogoutAll(ctx *gin.Context) {
+	revoked, err := c.sessionRepo.RevokeAll(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of all sessions successfully",
+		"data": gin.H{
+			"revoked": revoked,
+		},
+	})
+}
+
+func refreshExpiry() time.Time {
+	return time.Now().Add(config.GetConf().RefreshTokenDuration())
+}
+
+// openSession starts a session for the user and returns its tokens.
+func (c *Controller) openSession(ctx context.Context, user *entity.User) (users.AuthResponse, error) {
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return users.AuthResponse{}, err
+	}
+
+	session, err := c.sessionRepo.Create(ctx, user.Id, refreshHash, refreshExpiry())
+	if err != nil {
+		return users.AuthResponse{}, err
+	}
+
+	return authResponse(user, session.Id, refreshToken)
+}
+
+// failLogin answers a login of the user that failed with err and counts
+// the failure; once the failures reach the lockout threshold the account
+// is locked.
+func (c *Controller) failLogin(ctx *gin.Context, user *entity.User, err error) {
+	threshold, duration, max := config.GetConf().LoginLockout()
+	if threshold > 0 {
+		if _, recordErr := c.userRepo.RecordFailedLogin(ctx, user.Id, threshold, duration, max); recordErr != nil {
+			ctx.Error(recordErr)
+			return
+		}
+	}
+
+	ctx.Error(err)
+}
+
+// refuseLocked answers the second step of a login to an account locked
+// until lockedUntil. Its caller knows the password already, so unlike Login
+// it may learn about the lock.
+func refuseLocked(ctx *gin.Context, lockedUntil time.Time) {
+	wait := time.Until(lockedUntil).Round(time.Second)
+	ratelimit.Refuse(ctx, wait, "Account is locked after too many failed logins, retry in "+strconv.Itoa(int(wait.Seconds()))+" seconds")
+}
+
+func authResponse(user *entity.User, sessionID int, refreshToken string) (users.AuthResponse, error) {
+	token, err := auth.GenerateToken(user.Id, user.Email, user.Role, sessionID)
+	if err != nil {
+		return users.AuthResponse{}, err
+	}
+
+	return users.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.GetConf().AccessTokenDuration().Seconds()),
+		User:         userResponse(user),
+	}, nil
+}
+
+func userResponse(user *entity.User) users.UserResponse {
+	return users.UserResponse{
+		ID:              user.Id,
+		Name:            user.Name,
+		Email:           user.Email,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		TwoFactor:       user.TwoFactorEnabled(),
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/utils/password"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newLoginController returns a controller with one verified user,
+// ann@example.com with the password "correct horse".
+func newLoginController(t *testing.T) (*Controller, *fakeUsers) {
+	t.Helper()
+
+	hash, err := password.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifiedAt := time.Now()
+	userRepo := newFakeUsers(&entity.User{
+		Id:              1,
+		Name:            "Ann",
+		Email:           "ann@example.com",
+		Password:        hash,
+		Role:            entity.RoleViewer,
+		EmailVerifiedAt: &verifiedAt,
+	})
+
+	return &Controller{
+		userRepo:      userRepo,
+		sessionRepo:   &fakeSessions{},
+		twoFactorRepo: newFakeTwoFactor(userRepo),
+	}, userRepo
+}
+
+func login(c *Controller, email, pass string) *httptest.ResponseRecorder {
+	return post(c.Login, `{"email": "`+email+`", "password": "`+pass+`"}`)
+}
+
+// TestLoginDoesNotRevealAccounts checks that unknown and locked accounts
+// are refused like a wrong password.
+func TestLoginDoesNotRevealAccounts(t *testing.T) {
+	c, userRepo := newLoginController(t)
+
+	if w := login(c, "nobody@example.com", "correct horse"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown address: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// The lockout threshold of the test configuration is 3.
+	for i := 0; i < 3; i++ {
+		w := login(c, "ann@example.com", "wrong")
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong password %d: status = %d, want %d", i+1, w.Code, http.StatusUnauthorized)
+		}
+		if w.Header().Get("Retry-After") != "" {
+			t.Errorf("wrong password %d: Retry-After is set", i+1)
+		}
+	}
+
+	if userRepo.users[1].LockedUntil == nil {
+		t.Fatal("account not locked after 3 failures")
+	}
+
+	w := login(c, "ann@example.com", "correct horse")
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Retry-After") != "" {
+		t.Errorf("locked account: status = %d, Retry-After = %q, want %d without Retry-After",
+			w.Code, w.Header().Get("Retry-After"), http.StatusUnauthorized)
+	}
+
+	unlocked := time.Now().Add(-time.Second)
+	userRepo.users[1].LockedUntil = &unlocked
+
+	if w := login(c, "ann@example.com", "correct horse"); w.Code != http.StatusOK {
+		t.Fatalf("after the lock: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if userRepo.users[1].FailedAttempts != 0 || userRepo.users[1].LockedUntil != nil {
+		t.Error("failed logins not reset by a successful login")
+	}
+}
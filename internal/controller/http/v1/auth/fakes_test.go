@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/mailer"
+	"context"
+	"time"
+)
+
+// fakeUsers is an in-memory Repository.
+type fakeUsers struct {
+	users map[int]*entity.User
+}
+
+func newFakeUsers(users ...*entity.User) *fakeUsers {
+	f := &fakeUsers{users: map[int]*entity.User{}}
+	for _, user := range users {
+		f.users[user.Id] = user
+	}
+
+	return f
+}
+
+func (f *fakeUsers) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, apperror.NotFound("User not found")
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUsers) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+
+	return nil, apperror.NotFound("User not found")
+}
+
+func (f *fakeUsers) Create(ctx context.Context, user *entity.User) error {
+	user.Id = len(f.users) + 1
+	copied := *user
+	f.users[user.Id] = &copied
+
+	return nil
+}
+
+func (f *fakeUsers) RecordFailedLogin(ctx context.Context, id, threshold int, duration, max time.Duration) (*time.Time, error) {
+	user := f.users[id]
+	user.FailedAttempts++
+	if user.FailedAttempts < threshold {
+		return nil, nil
+	}
+
+	lockedUntil := time.Now().Add(duration)
+	user.LockedUntil = &lockedUntil
+
+	return &lockedUntil, nil
+}
+
+func (f *fakeUsers) ResetFailedLogins(ctx context.Context, id int) error {
+	user := f.users[id]
+	user.FailedAttempts = 0
+	user.LockedUntil = nil
+
+	return nil
+}
+
+// fakeIdentities is an in-memory IdentityRepository backed by users.
+type fakeIdentities struct {
+	users *fakeUsers
+	// linked maps provider and subject to a user id.
+	linked map[[2]string]int
+}
+
+func newFakeIdentities(users *fakeUsers) *fakeIdentities {
+	return &fakeIdentities{users: users, linked: map[[2]string]int{}}
+}
+
+func (f *fakeIdentities) SignIn(ctx context.Context, provider, subject string) (*entity.User, error) {
+	id, ok := f.linked[[2]string{provider, subject}]
+	if !ok {
+		return nil, apperror.NotFound("Identity not found")
+	}
+
+	return f.users.GetByID(ctx, id)
+}
+
+func (f *fakeIdentities) Link(ctx context.Context, userID int, provider, subject, email string) error {
+	f.linked[[2]string{provider, subject}] = userID
+	return nil
+}
+
+func (f *fakeIdentities) Provision(ctx context.Context, user *entity.User, provider, subject string) error {
+	if err := f.users.Create(ctx, user); err != nil {
+		return err
+	}
+
+	return f.Link(ctx, user.Id, provider, subject, user.Email)
+}
+
+// fakeTokens is a TokenRepository whose Issue returns err.
+type fakeTokens struct {
+	err error
+}
+
+func (f *fakeTokens) Issue(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	return f.err
+}
+
+func (f *fakeTokens) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	return 0, apperror.NotFound("Token not found")
+}
+
+func (f *fakeTokens) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	return 0, apperror.NotFound("Token not found")
+}
+
+// fakeMailer collects the messages sent.
+type fakeMailer struct {
+	sent chan mailer.Message
+}
+
+func (f *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	f.sent <- msg
+	return nil
+}
+
+// fakeSessions is a SessionRepository that opens sessions and nothing
+// else.
+type fakeSessions struct {
+	opened int
+}
+
+func (f *fakeSessions) Create(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (*entity.Session, error) {
+	f.opened++
+	return &entity.Session{Id: f.opened, UserId: userID}, nil
+}
+
+func (f *fakeSessions) Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*entity.Session, error) {
+	return nil, apperror.Unauthorized("Invalid refresh token")
+}
+
+func (f *fakeSessions) Revoke(ctx context.Context, userID, sessionID int) error {
+	return nil
+}
+
+func (f *fakeSessions) RevokeAll(ctx context.Context, userID int) (int, error) {
+	return 0, nil
+}
+
+// fakeTwoFactor is a TwoFactorRepository storing the state of users in
+// users, with recovery codes by hash.
+type fakeTwoFactor struct {
+	users    *fakeUsers
+	required map[string]bool
+	codes    map[string]bool
+}
+
+func newFakeTwoFactor(users *fakeUsers) *fakeTwoFactor {
+	return &fakeTwoFactor{users: users, required: map[string]bool{}, codes: map[string]bool{}}
+}
+
+func (f *fakeTwoFactor) Begin(ctx context.Context, userID int, secret string) error {
+	f.users.users[userID].TOTPSecret = &secret
+	return nil
+}
+
+func (f *fakeTwoFactor) Enable(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	now := time.Now()
+	user := f.users.users[userID]
+	user.TOTPEnabledAt = &now
+	user.TOTPLastStep = &step
+
+	return f.ReplaceRecoveryCodes(ctx, userID, codeHashes)
+}
+
+func (f *fakeTwoFactor) Disable(ctx context.Context, userID int) error {
+	user := f.users.users[userID]
+	user.TOTPSecret, user.TOTPEnabledAt, user.TOTPLastStep = nil, nil, nil
+	f.codes = map[string]bool{}
+
+	return nil
+}
+
+func (f *fakeTwoFactor) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	user := f.users.users[userID]
+	if user.TOTPLastStep != nil && *user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = &step
+
+	return true, nil
+}
+
+func (f *fakeTwoFactor) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	if !f.codes[codeHash] {
+		return false, nil
+	}
+	delete(f.codes, codeHash)
+
+	return true, nil
+}
+
+func (f *fakeTwoFactor) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	f.codes = map[string]bool{}
+	for _, hash := range codeHashes {
+		f.codes[hash] = true
+	}
+
+	return nil
+}
+
+func (f *fakeTwoFactor) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	return len(f.codes), nil
+}
+
+func (f *fakeTwoFactor) IsRequired(ctx context.Context, role string) (bool, error) {
+	return f.required[role], nil
+}
+
+func (f *fakeTwoFactor) RequiredRoles(ctx context.Context) ([]string, error) {
+	var roles []string
+	for role, required := range f.required {
+		if required {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+func (f *fakeTwoFactor) SetRequiredRoles(ctx context.Context, roles []string) error {
+	f.required = map[string]bool{}
+	for _, role := range roles {
+		f.required[role] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"Movies-Go/internal/pkg/middleware"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testConfig is the configuration the controller reads during the tests.
+const testConfig = `
+db_host: "localhost"
+db_port: "5432"
+db_name: "test"
+jwt_secret: "test-secret"
+lockout_threshold: 3
+lockout_duration: "15m"
+lockout_max_duration: "1h"
+app_url: "http://localhost:3000"
+`
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "auth-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "conf.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		log.Fatal(err)
+	}
+	os.Setenv("CONFIG_PATH", path)
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// post sends the JSON body to handler, with the error handling of the
+// server, and returns the response.
+func post(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.POST("/", handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	return w
+}
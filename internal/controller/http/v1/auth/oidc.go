@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/oidc"
+	"Movies-Go/internal/repository/postgres/identities"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errUnknownProvider = apperror.NotFound("Identity provider not found")
+	errInvalidFlow     = apperror.Unauthorized("Invalid or expired sign-in, start again")
+	// errUnverifiedAccount refuses to link an identity to an account whose
+	// address was never verified.
+	errUnverifiedAccount = apperror.Forbidden("An account with this email address exists but is not verified; verify the address or reset the password, then sign in again")
+)
+
+// OIDCProviders lists the identity providers users can sign in with, for
+// login pages to offer.
+func (c *Controller) OIDCProviders(ctx *gin.Context) {
+	configured := config.GetConf().OIDCProviders
+
+	providers := make([]identities.ProviderResponse, 0, len(configured))
+	for _, provider := range configured {
+		displayName := provider.DisplayName
+		if displayName == "" {
+			displayName = provider.Name
+		}
+
+		providers = append(providers, identities.ProviderResponse{
+			Name:        provider.Name,
+			DisplayName: displayName,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": providers,
+	})
+}
+
+// StartOIDCLogin starts a sign-in at an identity provider. The client sends
+// the browser to the authorization URL and keeps the flow token for
+// FinishOIDCLogin, which the page at the redirect URL calls.
+func (c *Controller) StartOIDCLogin(ctx *gin.Context) {
+	name := ctx.Param("provider")
+	provider, ok := c.providers[name]
+	if !ok {
+		ctx.Error(errUnknownProvider)
+		return
+	}
+
+	state, err := oidc.Random()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	nonce, err := oidc.Random()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		ctx.Error(apperror.Upstream("The identity provider could not be reached").Wrap(err))
+		return
+	}
+
+	flowToken, err := auth.GenerateFlowToken(name, state, nonce, verifier)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": identities.AuthorizationResponse{
+			AuthorizationURL: authorizationURL,
+			FlowToken:        flowToken,
+			ExpiresIn:        int(auth.FlowDuration.Seconds()),
+		},
+	})
+}
+
+// FinishOIDCLogin redeems the authorization code the provider redirected
+// back with and logs in the user of the identity in its ID token, like
+// Login does after checking the password.
+func (c *Controller) FinishOIDCLogin(ctx *gin.Context) {
+	name := ctx.Param("provider")
+	provider, ok := c.providers[name]
+	settings, configured := config.GetConf().OIDCProvider(name)
+	if !ok || !configured {
+		ctx.Error(errUnknownProvider)
+		return
+	}
+
+	var req identities.CallbackRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	flow, err := auth.ValidateFlowToken(req.FlowToken)
+	if err != nil || flow.Provider != name ||
+		subtle.ConstantTimeCompare([]byte(flow.State), []byte(req.State)) != 1 {
+		ctx.Error(errInvalidFlow)
+		return
+	}
+
+	token, err := provider.Exchange(ctx, req.Code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrInvalidGrant):
+			err = apperror.Unauthorized("The authorization code is invalid or expired, start again").Wrap(err)
+		case errors.Is(err, oidc.ErrInvalidIDToken):
+			err = apperror.Upstream("The identity provider returned an invalid ID token").Wrap(err)
+		default:
+			err = apperror.Upstream("The identity provider could not be reached").Wrap(err)
+		}
+
+		ctx.Error(err)
+		return
+	}
+
+	user, err := c.identityUser(ctx, settings, token)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.finishLogin(ctx, user)
+}
+
+// identityUser returns the user an identity signs in as. Identities seen
+// before are linked to their user already. Others are linked to the
+// account with their email address if both the provider and the account
+// verified it, or get a new account when the provider allows sign-ups.
+func (c *Controller) identityUser(ctx context.Context, settings config.OIDCProvider, token *oidc.IDToken) (*entity.User, error) {
+	user, err := c.identityRepo.SignIn(ctx, settings.Name, token.Subject)
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return user, err
+	}
+
+	if token.Email == "" || !token.EmailVerified {
+		return nil, apperror.Forbidden("The identity provider did not confirm your email address")
+	}
+
+	user, err = c.userRepo.GetByEmail(ctx, token.Email)
+	if err == nil {
+		// Anyone can register an address they do not own. Linking to such
+		// an account would let its creator keep a password to the
+		// identity's account.
+		if user.EmailVerifiedAt == nil {
+			return nil, errUnverifiedAccount
+		}
+
+		if err := c.identityRepo.Link(ctx, user.Id, settings.Name, token.Subject, token.Email); err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return nil, err
+	}
+
+	if !settings.AllowSignup || !settings.AllowsDomain(token.Email) {
+		return nil, apperror.Forbidden("There is no account for %s, ask an administrator to create one", token.Email)
+	}
+
+	// Accounts from a provider have no password, so only the provider can
+	// log them in until the user resets it.
+	now := time.Now()
+	user = &entity.User{
+		Name:            identityName(token),
+		Email:           token.Email,
+		Role:            settings.Role(),
+		EmailVerifiedAt: &now,
+	}
+
+	if err := c.identityRepo.Provision(ctx, user, settings.Name, token.Subject); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func identityName(token *oidc.IDToken) string {
+	switch {
+	case token.Name != "":
+		return token.Name
+	case token.PreferredUsername != "":
+		return token.PreferredUsername
+	default:
+		name, _, _ := strings.Cut(token.Email, "@")
+		return name
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/oidc"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdentityUser(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+	settings := config.OIDCProvider{Name: "mock", AllowSignup: true}
+
+	tests := []struct {
+		name  string
+		user  *entity.User
+		token oidc.IDToken
+		// want is the email of the user signed in, empty when refused
+		// with wantErr.
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "links a verified account",
+			user:  &entity.User{Id: 1, Email: "ann@example.com", Role: entity.RoleAdmin, Password: "hash", EmailVerifiedAt: &verifiedAt},
+			token: oidc.IDToken{Subject: "ann", Email: "ann@example.com", EmailVerified: true},
+			want:  "ann@example.com",
+		},
+		{
+			// Whoever registered the address first must not keep a
+			// password to the account of its real owner.
+			name:    "refuses an unverified account",
+			user:    &entity.User{Id: 1, Email: "bob@example.com", Role: entity.RoleAdmin, Password: "hash"},
+			token:   oidc.IDToken{Subject: "bob", Email: "bob@example.com", EmailVerified: true},
+			wantErr: apperror.ErrForbidden,
+		},
+		{
+			name:    "refuses an address the provider did not verify",
+			user:    &entity.User{Id: 1, Email: "cat@example.com", Role: entity.RoleAdmin, Password: "hash", EmailVerifiedAt: &verifiedAt},
+			token:   oidc.IDToken{Subject: "cat", Email: "cat@example.com"},
+			wantErr: apperror.ErrForbidden,
+		},
+		{
+			name:  "provisions a new account",
+			user:  &entity.User{Id: 1, Email: "admin@example.com", Role: entity.RoleAdmin, EmailVerifiedAt: &verifiedAt},
+			token: oidc.IDToken{Subject: "dan", Email: "dan@example.com", EmailVerified: true},
+			want:  "dan@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := newFakeUsers(tt.user)
+			identityRepo := newFakeIdentities(userRepo)
+			c := &Controller{userRepo: userRepo, identityRepo: identityRepo}
+
+			user, err := c.identityUser(context.Background(), settings, &tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("identityUser() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(identityRepo.linked) != 0 {
+					t.Errorf("identity linked to %v after a refusal", identityRepo.linked)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("identityUser() error = %v", err)
+			}
+			if user.Email != tt.want {
+				t.Errorf("identityUser() signed in %s, want %s", user.Email, tt.want)
+			}
+
+			// The next sign-in finds the identity.
+			again, err := c.identityUser(context.Background(), settings, &tt.token)
+			if err != nil || again.Id != user.Id {
+				t.Errorf("second identityUser() = %v, %v, want user %d", again, err, user.Id)
+			}
+		})
+	}
+}
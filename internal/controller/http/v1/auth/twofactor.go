@@ -0,0 +1,474 @@
+package auth
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/ratelimit"
+	"Movies-Go/internal/pkg/totp"
+	"Movies-Go/internal/repository/postgres/twofactor"
+	"Movies-Go/internal/utils/password"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidChallenge = apperror.Unauthorized("Invalid or expired challenge token")
+	errInvalidCode      = apperror.Unauthorized("Invalid two-factor code")
+)
+
+// TwoFactorStatus tells the current user whether two-factor authentication
+// is on and how many recovery codes are left.
+func (c *Controller) TwoFactorStatus(ctx *gin.Context) {
+	user, err := c.userRepo.GetByID(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	required, err := c.twoFactorRepo.IsRequired(ctx, user.Role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	status := twofactor.StatusResponse{
+		Enabled:  user.TwoFactorEnabled(),
+		Required: required,
+	}
+	if status.Enabled {
+		status.EnabledAt = user.TOTPEnabledAt
+		if status.RecoveryCodesCount, err = c.twoFactorRepo.CountRecoveryCodes(ctx, user.Id); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": status,
+	})
+}
+
+// SetupTwoFactor starts enrolling the current user: it returns a new secret
+// to add to an authenticator app, which ConfirmTwoFactor then checks.
+func (c *Controller) SetupTwoFactor(ctx *gin.Context) {
+	user, err := c.userRepo.GetByID(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setup, err := c.beginSetup(ctx, user)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Add the secret to your authenticator app and confirm with a code",
+		"data":    setup,
+	})
+}
+
+// ConfirmTwoFactor turns two-factor authentication on once the user proves
+// their authenticator works, and returns their recovery codes. They are
+// shown only this once.
+func (c *Controller) ConfirmTwoFactor(ctx *gin.Context) {
+	var req twofactor.CodeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	user, err := c.userRepo.GetByID(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	codes, err := c.enable(ctx, user, req.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication enabled, store the recovery codes in a safe place",
+		"data":    twofactor.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user,
+// e.g. when they ran out. It takes a code from the authenticator.
+func (c *Controller) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req twofactor.CodeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	user, err := c.userRepo.GetByID(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !user.TwoFactorEnabled() {
+		ctx.Error(apperror.Validation("Two-factor authentication is not enabled"))
+		return
+	}
+
+	ok, err := c.checkCode(ctx, user, req.Code, false)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !ok {
+		ctx.Error(errInvalidCode)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := c.twoFactorRepo.ReplaceRecoveryCodes(ctx, user.Id, hashes); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Recovery codes replaced, the previous ones no longer work",
+		"data":    twofactor.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// DisableTwoFactor turns two-factor authentication off for the current user
+// after checking their password and a code. Users whose role requires it
+// cannot turn it off.
+func (c *Controller) DisableTwoFactor(ctx *gin.Context) {
+	var req twofactor.DisableRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	user, err := c.userRepo.GetByID(ctx, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !user.TwoFactorEnabled() {
+		ctx.Error(apperror.Validation("Two-factor authentication is not enabled"))
+		return
+	}
+
+	required, err := c.twoFactorRepo.IsRequired(ctx, user.Role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if required {
+		ctx.Error(apperror.Forbidden("Two-factor authentication is required for your role"))
+		return
+	}
+
+	if !password.Verify(user.Password, req.Password) {
+		ctx.Error(errInvalidCredentials)
+		return
+	}
+
+	ok, err := c.checkCode(ctx, user, req.Code, true)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !ok {
+		ctx.Error(errInvalidCode)
+		return
+	}
+
+	if err := c.twoFactorRepo.Disable(ctx, user.Id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// LoginTwoFactorSetup starts enrolling a user whose role requires
+// two-factor authentication, during a login, with the challenge token the
+// password step returned.
+func (c *Controller) LoginTwoFactorSetup(ctx *gin.Context) {
+	var req twofactor.ChallengeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	claims, err := auth.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil || !claims.Setup {
+		ctx.Error(errInvalidChallenge)
+		return
+	}
+
+	user, err := c.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(errInvalidChallenge)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setup, err := c.beginSetup(ctx, user)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Add the secret to your authenticator app and log in with a code",
+		"data":    setup,
+	})
+}
+
+// LoginTwoFactor is the second step of a login: it checks a code from the
+// authenticator, or a recovery code, and opens the session. A user who is
+// enrolling during the login completes the enrollment with it and gets
+// their recovery codes in the response.
+func (c *Controller) LoginTwoFactor(ctx *gin.Context) {
+	var req twofactor.LoginRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	claims, err := auth.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		ctx.Error(errInvalidChallenge)
+		return
+	}
+
+	if !ratelimit.Apply(ctx, c.loginLimiter, "user:"+strconv.Itoa(claims.UserID)) {
+		return
+	}
+
+	user, err := c.userRepo.GetByID(ctx, claims.UserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(errInvalidChallenge)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		refuseLocked(ctx, *user.LockedUntil)
+		return
+	}
+
+	var codes []string
+	switch {
+	case user.TwoFactorEnabled():
+		ok, err := c.checkCode(ctx, user, req.Code, true)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if !ok {
+			c.failLogin(ctx, user, errInvalidCode)
+			return
+		}
+	case claims.Setup:
+		codes, err = c.enable(ctx, user, req.Code)
+		if errors.Is(err, errInvalidCode) {
+			c.failLogin(ctx, user, err)
+			return
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	default:
+		// Two-factor authentication was turned off since the challenge.
+		ctx.Error(errInvalidChallenge)
+		return
+	}
+
+	if user.FailedAttempts > 0 || user.LockedUntil != nil {
+		if err := c.userRepo.ResetFailedLogins(ctx, user.Id); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	response, err := c.openSession(ctx, user)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	response.User.TwoFactor = true
+	response.RecoveryCodes = codes
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTwoFactorPolicy lists the roles that require two-factor
+// authentication.
+func (c *Controller) GetTwoFactorPolicy(ctx *gin.Context) {
+	roles, err := c.twoFactorRepo.RequiredRoles(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": twofactor.PolicyResponse{RequiredRoles: roles},
+	})
+}
+
+// UpdateTwoFactorPolicy sets the roles that require two-factor
+// authentication. Members who are not enrolled have to enroll at their next
+// login, and their sessions end at the next refresh.
+func (c *Controller) UpdateTwoFactorPolicy(ctx *gin.Context) {
+	var req twofactor.PolicyRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if err := c.twoFactorRepo.SetRequiredRoles(ctx, req.RequiredRoles); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	roles, err := c.twoFactorRepo.RequiredRoles(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor policy updated successfully",
+		"data":    twofactor.PolicyResponse{RequiredRoles: roles},
+	})
+}
+
+// beginSetup stores a new secret for the user and returns it with its
+// otpauth URI.
+func (c *Controller) beginSetup(ctx context.Context, user *entity.User) (twofactor.SetupResponse, error) {
+	if user.TwoFactorEnabled() {
+		return twofactor.SetupResponse{}, twofactor.ErrAlreadyEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return twofactor.SetupResponse{}, err
+	}
+
+	if err := c.twoFactorRepo.Begin(ctx, user.Id, secret); err != nil {
+		return twofactor.SetupResponse{}, err
+	}
+
+	return twofactor.SetupResponse{
+		Secret: secret,
+		URI:    totp.URI(config.GetConf().TwoFactorIssuer(), user.Email, secret),
+	}, nil
+}
+
+// enable checks code against the secret the user is enrolling with and
+// turns two-factor authentication on. It returns the new recovery codes,
+// or errInvalidCode.
+func (c *Controller) enable(ctx context.Context, user *entity.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled() {
+		return nil, twofactor.ErrAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, apperror.Validation("Start the two-factor setup first")
+	}
+
+	step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, errInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.twoFactorRepo.Enable(ctx, user.Id, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// checkCode reports whether code is a valid TOTP code of the user, or with
+// allowRecovery an unused recovery code, and uses it up.
+func (c *Controller) checkCode(ctx context.Context, user *entity.User, code string, allowRecovery bool) (bool, error) {
+	var last int64
+	if user.TOTPLastStep != nil {
+		last = *user.TOTPLastStep
+	}
+
+	if step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), last); ok {
+		return c.twoFactorRepo.UseStep(ctx, user.Id, step)
+	}
+
+	if !allowRecovery {
+		return false, nil
+	}
+
+	return c.twoFactorRepo.UseRecoveryCode(ctx, user.Id, auth.HashOpaqueToken(totp.NormalizeRecoveryCode(code)))
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := totp.NewRecoveryCodes(totp.RecoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashOpaqueToken(code)
+	}
+
+	return codes, hashes, nil
+}
+
+// challengeResponse answers the password step of a login that needs a
+// second step.
+func challengeResponse(userID int, setup bool) (twofactor.ChallengeResponse, error) {
+	token, err := auth.GenerateChallengeToken(userID, setup)
+	if err != nil {
+		return twofactor.ChallengeResponse{}, err
+	}
+
+	return twofactor.ChallengeResponse{
+		TwoFactorRequired: true,
+		SetupRequired:     setup,
+		ChallengeToken:    token,
+		ExpiresIn:         int(auth.ChallengeDuration.Seconds()),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/pkg/totp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestLoginTwoFactorLockout checks that failed codes lock the account like
+// wrong passwords, and that the lock holds for valid codes until it ends.
+func TestLoginTwoFactorLockout(t *testing.T) {
+	c, userRepo := newLoginController(t)
+	twoFactorRepo := c.twoFactorRepo.(*fakeTwoFactor)
+	user := userRepo.users[1]
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabledAt := time.Now()
+	user.TOTPSecret = &secret
+	user.TOTPEnabledAt = &enabledAt
+	twoFactorRepo.codes[auth.HashOpaqueToken("abcd-efgh-jkmn-pqrs")] = true
+
+	challenge, err := auth.GenerateChallengeToken(user.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(code string) *httptest.ResponseRecorder {
+		return post(c.LoginTwoFactor, `{"challenge_token": "`+challenge+`", "code": "`+code+`"}`)
+	}
+	valid := func() string {
+		code, err := totp.Code(secret, totp.Step(time.Now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	// The lockout threshold of the test configuration is 3.
+	for _, code := range []string{"000000", "zzzz-zzzz-zzzz-zzzz", "123"} {
+		if w := send(code); w.Code != http.StatusUnauthorized {
+			t.Fatalf("code %q: status = %d, want %d", code, w.Code, http.StatusUnauthorized)
+		}
+	}
+	if user.FailedAttempts != 3 || user.LockedUntil == nil {
+		t.Fatalf("after 3 failed codes: %d failures, locked until %v", user.FailedAttempts, user.LockedUntil)
+	}
+
+	// The caller knows the password, so it learns about the lock.
+	w := send(valid())
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("valid code while locked: status = %d, Retry-After = %q, want %d with Retry-After",
+			w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+
+	unlocked := time.Now().Add(-time.Second)
+	user.LockedUntil = &unlocked
+
+	code := valid()
+	if w := send(code); w.Code != http.StatusOK {
+		t.Fatalf("valid code after the lock: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if user.FailedAttempts != 0 || user.LockedUntil != nil {
+		t.Error("failures not reset by a successful login")
+	}
+
+	// Codes work once; a replay counts as a failure.
+	if w := send(code); w.Code != http.StatusUnauthorized {
+		t.Errorf("replayed code: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if user.FailedAttempts != 1 {
+		t.Errorf("replayed code: %d failures, want 1", user.FailedAttempts)
+	}
+
+	// Recovery codes are accepted in any form, once.
+	if w := send("ABCD EFGH JKMN PQRS"); w.Code != http.StatusOK {
+		t.Errorf("recovery code: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if w := send("abcd-efgh-jkmn-pqrs"); w.Code != http.StatusUnauthorized {
+		t.Errorf("used recovery code: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
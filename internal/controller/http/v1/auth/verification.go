@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"Movies-Go/internal/pkg/accountmail"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/repository/postgres/tokens"
+	"Movies-Go/internal/utils/password"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail marks the email address of the user as verified with the
+// token of a verification email.
+func (c *Controller) VerifyEmail(ctx *gin.Context) {
+	var req tokens.VerifyEmailRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if _, err := c.tokenRepo.VerifyEmail(ctx, auth.HashOpaqueToken(req.Token)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Email address verified successfully",
+	})
+}
+
+// ResendVerification mails a new verification link to an account whose
+// email address is not verified yet. The response is the same whether or
+// not there is such an account, so that it does not reveal which addresses
+// are registered; the mail is sent after responding, so that neither does
+// the response time.
+func (c *Controller) ResendVerification(ctx *gin.Context) {
+	var req tokens.ResendVerificationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	accountmail.InBackground(ctx.Request.Context(), "Resending the verification email", func(ctx context.Context) error {
+		user, err := c.userRepo.GetByEmail(ctx, req.Email)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+
+		if err := c.mail.SendVerification(ctx, user); err != nil {
+			return fmt.Errorf("user %d: %w", user.Id, err)
+		}
+		return nil
+	})
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists and is not verified yet, a verification link has been sent to its email address",
+	})
+}
+
+// ForgotPassword mails a password reset link. Like ResendVerification, it
+// answers the same, and as fast, whether or not the account exists.
+func (c *Controller) ForgotPassword(ctx *gin.Context) {
+	var req tokens.ForgotPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	accountmail.InBackground(ctx.Request.Context(), "Sending the password reset email", func(ctx context.Context) error {
+		user, err := c.userRepo.GetByEmail(ctx, req.Email)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := c.mail.SendPasswordReset(ctx, user); err != nil {
+			return fmt.Errorf("user %d: %w", user.Id, err)
+		}
+		return nil
+	})
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists, a password reset link has been sent to its email address",
+	})
+}
+
+// ResetPassword sets a new password with the token of a password reset
+// email and logs the user out everywhere.
+func (c *Controller) ResetPassword(ctx *gin.Context) {
+	var req tokens.ResetPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	hashedPassword, err := password.Hash(req.Password)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if _, err := c.tokenRepo.ResetPassword(ctx, auth.HashOpaqueToken(req.Token), hashedPassword); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully, log in with the new password",
+	})
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/accountmail"
+	"Movies-Go/internal/pkg/mailer"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestForgotPasswordAnswersAlike checks that known and unknown addresses get
+// the same response, even when the reset link cannot be issued.
+func TestForgotPasswordAnswersAlike(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		issueErr error
+		// sent is whether a reset email goes out.
+		sent bool
+	}{
+		{name: "known address", email: "ann@example.com", sent: true},
+		{name: "unknown address", email: "nobody@example.com"},
+		{name: "token not issued", email: "ann@example.com", issueErr: errors.New("database is down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeMailer{sent: make(chan mailer.Message, 1)}
+			c := &Controller{
+				userRepo: newFakeUsers(&entity.User{Id: 1, Email: "ann@example.com", Name: "Ann"}),
+				mail:     accountmail.NewSender(&fakeTokens{err: tt.issueErr}, sender),
+			}
+
+			w := post(c.ForgotPassword, `{"email": "`+tt.email+`"}`)
+
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body)
+			}
+
+			select {
+			case msg := <-sender.sent:
+				if !tt.sent {
+					t.Errorf("sent %q to %s", msg.Subject, msg.To)
+				}
+				if !strings.Contains(msg.Body, "http://localhost:3000/reset-password?token=") {
+					t.Errorf("email has no reset link:\n%s", msg.Body)
+				}
+			case <-time.After(time.Second):
+				if tt.sent {
+					t.Error("no email sent")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,525 @@
+package collections
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/collections"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	repo      Repository
+	movieRepo MovieRepository
+	userRepo  UserRepository
+}
+
+func NewController(repo Repository, movieRepo MovieRepository, userRepo UserRepository) *Controller {
+	return &Controller{
+		repo:      repo,
+		movieRepo: movieRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// toResponse converts a collection for the user with id viewerID. Only the
+// owner sees the share token.
+func toResponse(collection *entity.Collection, viewerID int) collections.CollectionResponse {
+	response := collections.CollectionResponse{
+		ID:           collection.Id,
+		Name:         collection.Name,
+		Description:  collection.Description,
+		Visibility:   collection.Visibility,
+		ItemCount:    collection.ItemCount,
+		CopiedFromID: collection.CopiedFromId,
+		CreatedAt:    collection.CreatedAt,
+		UpdatedAt:    collection.UpdatedAt,
+	}
+
+	if collection.User != nil {
+		response.Owner = &collections.CollectionOwner{
+			ID:   collection.User.Id,
+			Name: collection.User.Name,
+		}
+	}
+
+	if collection.UserId == viewerID {
+		response.ShareToken = &collection.ShareToken
+	}
+
+	return response
+}
+
+func toListResponse(list []*entity.Collection, count, viewerID int) map[string]interface{} {
+	results := make([]collections.CollectionResponse, 0, len(list))
+	for _, collection := range list {
+		results = append(results, toResponse(collection, viewerID))
+	}
+
+	return map[string]interface{}{
+		"results": results,
+		"count":   count,
+	}
+}
+
+func toItemResponse(item *entity.CollectionItem) collections.ItemResponse {
+	response := collections.ItemResponse{
+		MovieID:   item.MovieId,
+		Position:  item.Position,
+		Note:      item.Note,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+
+	if item.Movie != nil {
+		response.Movie = &collections.ItemMovie{
+			ID:       item.Movie.Id,
+			Title:    item.Movie.Title,
+			Director: item.Movie.Director,
+			Year:     item.Movie.Year,
+		}
+	}
+
+	return response
+}
+
+// canView tells whether the current user may see the collection: its owner
+// always can, anybody can see public ones and unlisted ones can be seen
+// with the share token passed as ?token=.
+func canView(ctx *gin.Context, collection *entity.Collection) bool {
+	switch {
+	case collection.UserId == ctx.GetInt("user_id"):
+		return true
+	case collection.Visibility == entity.VisibilityPublic:
+		return true
+	case collection.Visibility == entity.VisibilityUnlisted:
+		token := ctx.Query("token")
+		return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(collection.ShareToken)) == 1
+	default:
+		return false
+	}
+}
+
+// visible loads the :id collection and checks that the current user may see
+// it, writing the error response itself when they may not. Collections the
+// user cannot see are reported as missing.
+func (c *Controller) visible(ctx *gin.Context) (*entity.Collection, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid collection ID"))
+		return nil, false
+	}
+
+	collection, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	if !canView(ctx, collection) {
+		ctx.Error(apperror.NotFound("Collection not found"))
+		return nil, false
+	}
+
+	return collection, true
+}
+
+// owned loads the :id collection and checks that it belongs to the current
+// user.
+func (c *Controller) owned(ctx *gin.Context) (*entity.Collection, bool) {
+	collection, ok := c.visible(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	if collection.UserId != ctx.GetInt("user_id") {
+		ctx.Error(apperror.Forbidden("Only the owner can change this collection"))
+		return nil, false
+	}
+
+	return collection, true
+}
+
+// movie checks that the movie a request body refers to exists.
+func (c *Controller) movie(ctx *gin.Context, id int) bool {
+	_, err := c.movieRepo.GetByID(ctx, id)
+	if errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(apperror.InvalidField("movie_id", "movie does not exist"))
+		return false
+	}
+	if err != nil {
+		ctx.Error(err)
+		return false
+	}
+
+	return true
+}
+
+func parseMovieID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("movie_id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid movie ID"))
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (c *Controller) list(ctx *gin.Context, filter collections.ListFilter) {
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.List(ctx, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": toListResponse(list, count, ctx.GetInt("user_id")),
+	})
+}
+
+// GetPublic lists public collections, optionally filtered by name.
+func (c *Controller) GetPublic(ctx *gin.Context) {
+	c.list(ctx, collections.ListFilter{PublicOnly: true})
+}
+
+// GetMine lists the current user's collections, whatever their visibility.
+func (c *Controller) GetMine(ctx *gin.Context) {
+	userID := ctx.GetInt("user_id")
+	c.list(ctx, collections.ListFilter{OwnerID: &userID})
+}
+
+// GetByUser lists the public collections of a user.
+func (c *Controller) GetByUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid user ID"))
+		return
+	}
+
+	if _, err := c.userRepo.GetByID(ctx, userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.list(ctx, collections.ListFilter{OwnerID: &userID, PublicOnly: true})
+}
+
+// GetByMovie lists the public collections that contain a movie.
+func (c *Controller) GetByMovie(ctx *gin.Context) {
+	movieID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid movie ID"))
+		return
+	}
+
+	if _, err := c.movieRepo.GetByID(ctx, movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.list(ctx, collections.ListFilter{MovieID: &movieID, PublicOnly: true})
+}
+
+func (c *Controller) Create(ctx *gin.Context) {
+	var req collections.CreateCollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	collection := &entity.Collection{
+		UserId:     ctx.GetInt("user_id"),
+		Name:       *req.Name,
+		Visibility: entity.VisibilityPrivate,
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+	if req.Visibility != nil {
+		collection.Visibility = *req.Visibility
+	}
+
+	if err := c.repo.Create(ctx, collection); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.respond(ctx, http.StatusCreated, "Collection created successfully", collection.Id)
+}
+
+func (c *Controller) GetByID(ctx *gin.Context) {
+	collection, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": toResponse(collection, ctx.GetInt("user_id")),
+	})
+}
+
+func (c *Controller) Update(ctx *gin.Context) {
+	collection, ok := c.owned(ctx)
+	if !ok {
+		return
+	}
+
+	var req collections.UpdateCollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if req.Name != nil {
+		collection.Name = *req.Name
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+	if req.Visibility != nil {
+		collection.Visibility = *req.Visibility
+	}
+
+	if err := c.repo.Update(ctx, collection, req.RotateShareToken); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Collection updated successfully",
+		"data":    toResponse(collection, ctx.GetInt("user_id")),
+	})
+}
+
+// Delete removes a collection. Admins may delete any collection, e.g. to
+// moderate public ones.
+func (c *Controller) Delete(ctx *gin.Context) {
+	collection, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+
+	if collection.UserId != ctx.GetInt("user_id") && ctx.GetString("role") != entity.RoleAdmin {
+		ctx.Error(apperror.Forbidden("Only the owner can delete this collection"))
+		return
+	}
+
+	if err := c.repo.Delete(ctx, collection.Id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Collection deleted successfully",
+	})
+}
+
+// Copy saves a copy of a collection the current user can see as one of
+// their own.
+func (c *Controller) Copy(ctx *gin.Context) {
+	source, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+
+	var req collections.CopyCollectionRequest
+	// The body is optional.
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	collection := &entity.Collection{
+		UserId:      ctx.GetInt("user_id"),
+		Name:        source.Name + " (copy)",
+		Description: source.Description,
+		Visibility:  entity.VisibilityPrivate,
+	}
+	if req.Name != nil {
+		collection.Name = *req.Name
+	}
+	if req.Visibility != nil {
+		collection.Visibility = *req.Visibility
+	}
+
+	if err := c.repo.Copy(ctx, source.Id, collection); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.respond(ctx, http.StatusCreated, "Collection copied successfully", collection.Id)
+}
+
+func (c *Controller) respond(ctx *gin.Context, status int, message string, id int) {
+	collection, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"message": message,
+		"data":    toResponse(collection, ctx.GetInt("user_id")),
+	})
+}
+
+func (c *Controller) GetItems(ctx *gin.Context) {
+	collection, ok := c.visible(ctx)
+	if !ok {
+		return
+	}
+
+	var filter collections.ItemFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.GetItems(ctx, collection.Id, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	results := make([]collections.ItemResponse, 0, len(list))
+	for _, item := range list {
+		results = append(results, toItemResponse(item))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"results": results,
+			"count":   count,
+		},
+	})
+}
+
+// AddItem adds a movie to a collection, at the end unless a position is
+// given.
+func (c *Controller) AddItem(ctx *gin.Context) {
+	collection, ok := c.owned(ctx)
+	if !ok {
+		return
+	}
+
+	var req collections.AddItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if !c.movie(ctx, *req.MovieID) {
+		return
+	}
+
+	item := &entity.CollectionItem{
+		CollectionId: collection.Id,
+		MovieId:      *req.MovieID,
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+	if req.Note != nil {
+		item.Note = *req.Note
+	}
+
+	if err := c.repo.AddItem(ctx, item); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.respondItem(ctx, http.StatusCreated, "Movie added to the collection", collection.Id, item.MovieId)
+}
+
+// UpdateItem moves a movie within a collection and/or changes its note.
+func (c *Controller) UpdateItem(ctx *gin.Context) {
+	collection, ok := c.owned(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, ok := parseMovieID(ctx)
+	if !ok {
+		return
+	}
+
+	var req collections.UpdateItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if err := c.repo.UpdateItem(ctx, collection.Id, movieID, req.Position, req.Note); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.respondItem(ctx, http.StatusOK, "Collection updated successfully", collection.Id, movieID)
+}
+
+func (c *Controller) RemoveItem(ctx *gin.Context) {
+	collection, ok := c.owned(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, ok := parseMovieID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.RemoveItem(ctx, collection.Id, movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Movie removed from the collection",
+	})
+}
+
+// Reorder replaces the order of a collection's movies.
+func (c *Controller) Reorder(ctx *gin.Context) {
+	collection, ok := c.owned(ctx)
+	if !ok {
+		return
+	}
+
+	var req collections.ReorderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if err := c.repo.Reorder(ctx, collection.Id, req.MovieIDs); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Collection reordered successfully",
+	})
+}
+
+func (c *Controller) respondItem(ctx *gin.Context, status int, message string, collectionID, movieID int) {
+	item, err := c.repo.GetItem(ctx, collectionID, movieID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"message": message,
+		"data":    toItemResponse(item),
+	})
+}
@@ -0,0 +1,41 @@
+package collections
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/repository/postgres/collections"
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, collection *entity.Collection) error
+
+	GetByID(ctx context.Context, id int) (*entity.Collection, error)
+
+	List(ctx context.Context, filter collections.ListFilter) ([]*entity.Collection, int, error)
+
+	Update(ctx context.Context, collection *entity.Collection, rotateToken bool) error
+
+	Delete(ctx context.Context, id int) error
+
+	GetItems(ctx context.Context, collectionID int, filter collections.ItemFilter) ([]*entity.CollectionItem, int, error)
+
+	GetItem(ctx context.Context, collectionID, movieID int) (*entity.CollectionItem, error)
+
+	AddItem(ctx context.Context, item *entity.CollectionItem) error
+
+	UpdateItem(ctx context.Context, collectionID, movieID int, position *int, note *string) error
+
+	RemoveItem(ctx context.Context, collectionID, movieID int) error
+
+	Reorder(ctx context.Context, collectionID int, movieIDs []int) error
+
+	Copy(ctx context.Context, sourceID int, collection *entity.Collection) error
+}
+
+type MovieRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.Movie, error)
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.User, error)
+}
@@ -0,0 +1,194 @@
+package genres
+
+import (
+	basic_controller "Movies-Go/internal/controller/http/v1/_basic_controller"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/genres"
+	"Movies-Go/internal/repository/postgres/movies"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type Controller struct {
+	repo Repository
+}
+
+func NewController(repo Repository) *Controller {
+	return &Controller{
+		repo: repo,
+	}
+}
+
+func toResponse(genre *entity.Genre) genres.GenreResponse {
+	return genres.GenreResponse{
+		ID:          genre.Id,
+		Name:        genre.Name,
+		Description: genre.Description,
+		CreatedAt:   genre.CreatedAt,
+		UpdatedAt:   genre.UpdatedAt,
+	}
+}
+
+func (c *Controller) Create(ctx *gin.Context) {
+	var req genres.CreateGenreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	existing, _ := c.repo.GetByName(ctx, *req.Name)
+	if existing != nil {
+		ctx.Error(apperror.Conflict("Genre with this name already exists"))
+		return
+	}
+
+	genre := &entity.Genre{
+		Name: *req.Name,
+	}
+	if req.Description != nil {
+		genre.Description = *req.Description
+	}
+
+	if err := c.repo.Create(ctx, genre); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": toResponse(genre),
+	})
+}
+
+func (c *Controller) GetAll(ctx *gin.Context) {
+	list, err := c.repo.GetAll(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := make([]genres.GenreResponse, 0, len(list))
+	for _, genre := range list {
+		response = append(response, toResponse(genre))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
+
+func (c *Controller) GetByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid genre ID"))
+		return
+	}
+
+	genre, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": toResponse(genre),
+	})
+}
+
+func (c *Controller) Update(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid genre ID"))
+		return
+	}
+
+	genre, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var req genres.UpdateGenreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if req.Name != nil && *req.Name != genre.Name {
+		existing, _ := c.repo.GetByName(ctx, *req.Name)
+		if existing != nil && existing.Id != genre.Id {
+			ctx.Error(apperror.Conflict("Genre with this name already exists"))
+			return
+		}
+		genre.Name = *req.Name
+	}
+
+	if req.Description != nil {
+		genre.Description = *req.Description
+	}
+
+	if err := c.repo.Update(ctx, genre); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Genre updated successfully",
+		"data":    toResponse(genre),
+	})
+}
+
+func (c *Controller) Delete(ctx *gin.Context) {
+	reqCtx, data, err := basic_controller.BasicDelete(ctx)
+	if err != nil {
+		return
+	}
+
+	if _, err := c.repo.GetByID(reqCtx, *data.Id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := c.repo.Delete(reqCtx, data); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Genre deleted successfully",
+	})
+}
+
+// GetMovies lists the movies tagged with the genre, paginated like GET /movies.
+func (c *Controller) GetMovies(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid genre ID"))
+		return
+	}
+
+	var filter movies.Filter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if _, err := c.repo.GetByID(ctx, id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	list, count, err := c.repo.GetMovies(ctx, id, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"results": list,
+			"count":   count,
+		},
+	})
+}
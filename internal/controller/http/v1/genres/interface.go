@@ -0,0 +1,24 @@
+package genres
+
+import (
+	"Movies-Go/internal/entity"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"Movies-Go/internal/repository/postgres/movies"
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, genre *entity.Genre) error
+
+	GetAll(ctx context.Context) ([]*entity.Genre, error)
+
+	GetByID(ctx context.Context, id int) (*entity.Genre, error)
+
+	GetByName(ctx context.Context, name string) (*entity.Genre, error)
+
+	Update(ctx context.Context, genre *entity.Genre) error
+
+	Delete(ctx context.Context, data basic_repo.Delete) error
+
+	GetMovies(ctx context.Context, genreID int, filter movies.Filter) ([]*entity.Movie, int, error)
+}
@@ -0,0 +1,83 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"Movies-Go/internal/pkg/healthcheck"
+	"Movies-Go/internal/pkg/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	checker *healthcheck.Checker
+	started time.Time
+}
+
+func NewController(checker *healthcheck.Checker) *Controller {
+	return &Controller{
+		checker: checker,
+		started: time.Now(),
+	}
+}
+
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Time    string `json:"time"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+type LiveResponse struct {
+	Status        string  `json:"status"`
+	Version       string  `json:"version"`
+	Commit        string  `json:"commit"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+type ReadyResponse struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	healthcheck.Report
+}
+
+// Health is the original status endpoint, kept for existing clients.
+func (c *Controller) Health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, HealthResponse{
+		Status:  "healthy",
+		Time:    time.Now().Format(time.RFC3339),
+		Version: version.Version,
+		Commit:  version.Commit,
+	})
+}
+
+// Live reports that the process is serving requests. It checks no
+// dependencies, so that an unavailable database does not get the service
+// restarted.
+func (c *Controller) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, LiveResponse{
+		Status:        healthcheck.StatusOK,
+		Version:       version.Version,
+		Commit:        version.Commit,
+		UptimeSeconds: time.Since(c.started).Truncate(time.Second).Seconds(),
+	})
+}
+
+// Ready runs the dependency checks and responds 503 unless all pass, so
+// that no traffic is routed to the instance meanwhile.
+func (c *Controller) Ready(ctx *gin.Context) {
+	report := c.checker.Run(ctx.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != healthcheck.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(status, ReadyResponse{
+		Version: version.Version,
+		Commit:  version.Commit,
+		Report:  report,
+	})
+}
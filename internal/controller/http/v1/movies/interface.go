@@ -2,16 +2,26 @@ package movies
 
 import (
 	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/cursor"
 	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
 	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/repository/postgres/watchlists"
 	"context"
 )
 
 type Repository interface {
 	Create(ctx context.Context, data movies.CreateMovieRequest) (entity.Movie, error)
-	GetAll(ctx context.Context, filter movies.SearchMovieRequest) ([]*entity.Movie, int, error)
+	GetAll(ctx context.Context, filter movies.SearchMovieRequest) ([]*entity.Movie, int, cursor.Page, error)
 	GetByID(ctx context.Context, id int) (*entity.Movie, error)
 	Update(ctx context.Context, data movies.UpdateMovieRequest) (entity.Movie, error)
 	Delete(ctx context.Context, data basic_repo.Delete) error
-	Search(ctx context.Context, query string, page, limit int) ([]*movies.MovieResponse, int, error)
+	Search(ctx context.Context, filter movies.SearchMovieRequest) ([]*movies.MovieResponse, int, cursor.Page, error)
+	Export(ctx context.Context, filter movies.SearchMovieRequest, fn func([]*entity.Movie) error) error
+	Import(ctx context.Context, rows []movies.ImportRow, opts movies.ImportOptions) (movies.ImportReport, error)
+}
+
+// ListRepository tells whether movies are on the current user's watchlist
+// and in their watch history.
+type ListRepository interface {
+	Statuses(ctx context.Context, userID int, movieIDs []int) (map[int]watchlists.Status, error)
 }
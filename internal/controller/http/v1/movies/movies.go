@@ -3,21 +3,33 @@ package movies
 import (
 	basic_controller "Movies-Go/internal/controller/http/v1/_basic_controller"
 	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/cursor"
+	"Movies-Go/internal/pkg/movieexport"
+	"Movies-Go/internal/pkg/movieimport"
 	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
 	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/repository/postgres/watchlists"
 	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+// maxImportSize bounds the body of an import request.
+const maxImportSize = 10 << 20
+
 type MovieRepositoryAdapter struct {
 	repo *movies.Repository
 }
 
 func (a *MovieRepositoryAdapter) Create(ctx context.Context, data movies.CreateMovieRequest) (entity.Movie, error) {
-	movie := &entity.Movie{}
+	movie := &entity.Movie{ExternalId: externalID(data.ExternalID)}
 
 	if data.Title != nil {
 		movie.Title = *data.Title
@@ -35,11 +47,7 @@ func (a *MovieRepositoryAdapter) Create(ctx context.Context, data movies.CreateM
 		movie.Plot = *data.Plot
 	}
 
-	if data.Rating != nil {
-		movie.Rating = *data.Rating
-	}
-
-	err := a.repo.Create(ctx, movie)
+	err := a.repo.Create(ctx, movie, data.GenreIDs)
 	if err != nil {
 		return entity.Movie{}, err
 	}
@@ -47,7 +55,7 @@ func (a *MovieRepositoryAdapter) Create(ctx context.Context, data movies.CreateM
 	return *movie, nil
 }
 
-func (a *MovieRepositoryAdapter) GetAll(ctx context.Context, filter movies.SearchMovieRequest) ([]*entity.Movie, int, error) {
+func (a *MovieRepositoryAdapter) GetAll(ctx context.Context, filter movies.SearchMovieRequest) ([]*entity.Movie, int, cursor.Page, error) {
 	return a.repo.GetAll(ctx, filter)
 }
 
@@ -66,6 +74,10 @@ func (a *MovieRepositoryAdapter) Update(ctx context.Context, data movies.UpdateM
 		return entity.Movie{}, err
 	}
 
+	if data.ExternalID != nil {
+		movie.ExternalId = externalID(data.ExternalID)
+	}
+
 	if data.Title != nil {
 		movie.Title = *data.Title
 	}
@@ -82,11 +94,7 @@ func (a *MovieRepositoryAdapter) Update(ctx context.Context, data movies.UpdateM
 		movie.Plot = *data.Plot
 	}
 
-	if data.Rating != nil {
-		movie.Rating = *data.Rating
-	}
-
-	err = a.repo.Update(ctx, movie)
+	err = a.repo.Update(ctx, movie, data.GenreIDs)
 	if err != nil {
 		return entity.Movie{}, err
 	}
@@ -96,64 +104,130 @@ func (a *MovieRepositoryAdapter) Update(ctx context.Context, data movies.UpdateM
 
 func (a *MovieRepositoryAdapter) Delete(ctx context.Context, data basic_repo.Delete) error {
 	if data.Id == nil {
-		return fmt.Errorf("id is required")
+		return apperror.Validation("id is required")
 	}
 	return a.repo.Delete(ctx, data)
 }
 
-func (a *MovieRepositoryAdapter) Search(ctx context.Context, query string, page, limit int) ([]*movies.MovieResponse, int, error) {
-	filter := movies.SearchMovieRequest{
-		Query: &query,
-		Page:  &page,
-		Limit: &limit,
-	}
-
-	moviesResult, count, err := a.repo.GetAll(ctx, filter)
+func (a *MovieRepositoryAdapter) Search(ctx context.Context, filter movies.SearchMovieRequest) ([]*movies.MovieResponse, int, cursor.Page, error) {
+	moviesResult, count, links, err := a.repo.GetAll(ctx, filter)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, cursor.Page{}, err
 	}
 
 	var response []*movies.MovieResponse
 	for _, movie := range moviesResult {
-		response = append(response, &movies.MovieResponse{
-			ID:        &movie.Id,
-			Title:     &movie.Title,
-			Director:  &movie.Director,
-			Year:      &movie.Year,
-			Plot:      &movie.Plot,
-			Rating:    &movie.Rating,
-			CreatedAt: movie.CreatedAt,
-			UpdatedAt: movie.UpdatedAt,
+		item := &movies.MovieResponse{
+			ID:             &movie.Id,
+			ExternalID:     movie.ExternalId,
+			Title:          &movie.Title,
+			Director:       &movie.Director,
+			Year:           &movie.Year,
+			Plot:           &movie.Plot,
+			Rating:         &movie.Rating,
+			VoteCount:      &movie.VoteCount,
+			WeightedRating: &movie.WeightedRating,
+			Genres:         genreResponses(movie.Genres),
+			CreatedAt:      movie.CreatedAt,
+			UpdatedAt:      movie.UpdatedAt,
+		}
+
+		if movie.TitleHighlight != "" {
+			item.Rank = &movie.SearchRank
+			item.Highlights = &movies.MovieHighlights{
+				Title: movie.TitleHighlight,
+				Plot:  movie.PlotHighlight,
+			}
+		}
+
+		response = append(response, item)
+	}
+
+	return response, count, links, nil
+}
+
+func (a *MovieRepositoryAdapter) Export(ctx context.Context, filter movies.SearchMovieRequest, fn func([]*entity.Movie) error) error {
+	return a.repo.Export(ctx, filter, fn)
+}
+
+func (a *MovieRepositoryAdapter) Import(ctx context.Context, rows []movies.ImportRow, opts movies.ImportOptions) (movies.ImportReport, error) {
+	return a.repo.Import(ctx, rows, opts)
+}
+
+// externalID treats an empty external_id as none, so that it can be cleared.
+func externalID(id *string) *string {
+	if id == nil || *id == "" {
+		return nil
+	}
+
+	return id
+}
+
+func genreResponses(genres []*entity.Genre) []*movies.Genre {
+	response := make([]*movies.Genre, 0, len(genres))
+	for _, genre := range genres {
+		response = append(response, &movies.Genre{
+			ID:   genre.Id,
+			Name: genre.Name,
 		})
 	}
 
-	return response, count, nil
+	return response
 }
 
 type Controller struct {
 	useCase Repository
+	lists   ListRepository
 }
 
-func NewController(repo *movies.Repository) *Controller {
+func NewController(repo *movies.Repository, lists ListRepository) *Controller {
 	adapter := &MovieRepositoryAdapter{
 		repo: repo,
 	}
 	return &Controller{
 		useCase: adapter,
+		lists:   lists,
 	}
 }
 
+// statuses looks up the watchlist and history state of the movies for the
+// current user.
+func (cl *Controller) statuses(c *gin.Context, ids []int) (map[int]watchlists.Status, error) {
+	return cl.lists.Statuses(c.Request.Context(), c.GetInt("user_id"), ids)
+}
+
+// annotate sets InWatchlist and Watched on the movies for the current user.
+func (cl *Controller) annotate(c *gin.Context, list ...*entity.Movie) error {
+	ids := make([]int, 0, len(list))
+	for _, movie := range list {
+		ids = append(ids, movie.Id)
+	}
+
+	statuses, err := cl.statuses(c, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, movie := range list {
+		status := statuses[movie.Id]
+		movie.InWatchlist = &status.InWatchlist
+		movie.Watched = &status.Watched
+	}
+
+	return nil
+}
+
 func (cl *Controller) Create(c *gin.Context) {
 	var request movies.CreateMovieRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Binding(err))
 		return
 	}
 
 	detail, err := cl.useCase.Create(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -170,11 +244,7 @@ func (cl *Controller) GetAll(c *gin.Context) {
 	if len(limitQ) > 0 {
 		queryInt, err := strconv.Atoi(limitQ[0])
 		if err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Limit must be number!",
-				"status":  false,
-			})
-
+			c.Error(apperror.InvalidField("limit", "must be a number"))
 			return
 		}
 
@@ -185,10 +255,7 @@ func (cl *Controller) GetAll(c *gin.Context) {
 	if len(pageQ) > 0 {
 		page, err := strconv.Atoi(pageQ[0])
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": "Page must be number!",
-				"status":  false,
-			})
+			c.Error(apperror.InvalidField("page", "must be a number"))
 			return
 		}
 		filter.Page = &page
@@ -199,25 +266,43 @@ func (cl *Controller) GetAll(c *gin.Context) {
 		filter.Query = &queryQ[0]
 	}
 
-	ctx := context.Background()
+	cursorQ := query["cursor"]
+	if len(cursorQ) > 0 {
+		filter.Cursor = &cursorQ[0]
+	}
 
-	list, count, err := cl.useCase.GetAll(ctx, filter)
+	if err := movies.CheckParams(query); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := c.ShouldBindQuery(&filter.MovieFilter); err != nil {
+		c.Error(apperror.Binding(err))
+		return
+	}
 
+	if err := filter.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	list, count, links, err := cl.useCase.GetAll(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": err.Error(),
-			"status":  false,
-		})
+		c.Error(err)
+		return
+	}
 
+	if err := cl.annotate(c, list...); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "ok!",
-		"status":  true,
 		"data": map[string]interface{}{
-			"results": list,
-			"count":   count,
+			"results":     list,
+			"count":       count,
+			"next_cursor": links.NextCursor,
+			"prev_cursor": links.PrevCursor,
 		},
 	})
 }
@@ -226,28 +311,22 @@ func (cl *Controller) GetByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":  "Invalid movie ID",
-			"status": false,
-		})
+		c.Error(apperror.Validation("Invalid movie ID"))
 		return
 	}
-	ctx := context.Background()
-
-	detail, err := cl.useCase.GetByID(ctx, id)
+	detail, err := cl.useCase.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": err.Error(),
-			"status":  false,
-		})
+		c.Error(err)
+		return
+	}
 
+	if err := cl.annotate(c, detail); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "ok!",
-		"status":  true,
-		"data":    detail,
+		"data": detail,
 	})
 }
 
@@ -256,42 +335,28 @@ func (cl *Controller) Update(c *gin.Context) {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":  "Invalid movie ID",
-			"status": false,
-		})
+		c.Error(apperror.Validation("Invalid movie ID"))
 		return
 	}
 
 	var data movies.UpdateMovieRequest
 	err = c.ShouldBind(&data)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": err.Error(),
-			"status":  false,
-		})
-
+		c.Error(apperror.Binding(err))
 		return
 	}
 
 	if data.Id == nil {
 		data.Id = &id
 	}
-	ctx := context.Background()
-
-	detail, err := cl.useCase.Update(ctx, data)
+	detail, err := cl.useCase.Update(c.Request.Context(), data)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": err.Error(),
-			"status":  false,
-		})
-
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "ok!",
-		"status":  true,
+		"message": "Movie updated successfully",
 		"data":    detail,
 	})
 }
@@ -304,17 +369,12 @@ func (cl *Controller) Delete(c *gin.Context) {
 
 	err = cl.useCase.Delete(ctx, data)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": err.Error(),
-			"status":  false,
-		})
-
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "ok!",
-		"status":  true,
+		"message": "Movie deleted successfully",
 	})
 }
 
@@ -322,13 +382,18 @@ func (cl *Controller) Delete(c *gin.Context) {
 func (cl *Controller) Search(c *gin.Context) {
 	var request movies.SearchMovieRequest
 	if err := c.ShouldBindQuery(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.Binding(err))
+		return
+	}
+
+	if err := movies.CheckParams(c.Request.URL.Query()); err != nil {
+		c.Error(err)
 		return
 	}
 
-	query := ""
-	if request.Query != nil {
-		query = *request.Query
+	if err := request.Validate(); err != nil {
+		c.Error(err)
+		return
 	}
 
 	page := 1
@@ -341,24 +406,192 @@ func (cl *Controller) Search(c *gin.Context) {
 		limit = *request.Limit
 	}
 
-	moviesResult, totalCount, err := cl.useCase.Search(c.Request.Context(), query, page, limit)
+	moviesResult, totalCount, links, err := cl.useCase.Search(c.Request.Context(), request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ids := make([]int, 0, len(moviesResult))
+	for _, movie := range moviesResult {
+		ids = append(ids, *movie.ID)
+	}
+
+	statuses, err := cl.statuses(c, ids)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	for _, movie := range moviesResult {
+		status := statuses[*movie.ID]
+		movie.InWatchlist = &status.InWatchlist
+		movie.Watched = &status.Watched
+	}
+
 	totalPages := (totalCount + limit - 1) / limit
 	if totalPages < 1 {
 		totalPages = 1
 	}
 
-	response := gin.H{
-		"data":        moviesResult,
-		"total":       totalCount,
-		"page":        page,
-		"limit":       limit,
-		"total_pages": totalPages,
+	c.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"results":     moviesResult,
+			"count":       totalCount,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": totalPages,
+			"next_cursor": links.NextCursor,
+			"prev_cursor": links.PrevCursor,
+		},
+	})
+}
+
+// Import creates or updates movies from an uploaded CSV or NDJSON file, sent
+// either as the request body or as the "file" field of a multipart form. It
+// responds with a report listing the rows that were rejected. Without a
+// batch_size nothing is written unless every row is valid.
+func (cl *Controller) Import(c *gin.Context) {
+	var request movies.ImportRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.Error(apperror.Binding(err))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var body io.Reader = c.Request.Body
+	contentType, filename := c.ContentType(), ""
+	if contentType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.Error(apperror.InvalidField("file", "must be an uploaded CSV or NDJSON file"))
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer file.Close()
+
+		body = file
+		contentType, filename = header.Header.Get("Content-Type"), header.Filename
+	}
+
+	format := movieimport.Format(request.Format)
+	if format == "" {
+		detected, ok := movieimport.FormatFor(contentType, filename)
+		if !ok {
+			c.Error(apperror.InvalidField("format", "could not be detected from the upload, pass format=csv or format=ndjson"))
+			return
+		}
+		format = detected
 	}
 
-	c.JSON(http.StatusOK, response)
+	rows, err := movieimport.Parse(body, format)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report, err := cl.useCase.Import(c.Request.Context(), rows, request.ImportOptions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !report.DryRun && !report.Committed {
+		c.Error(apperror.Validation("%d of %d rows were rejected, nothing was imported", report.Failed, report.Total).
+			With("report", report))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
+
+// Export streams the movies matching the GET /movies filters as a CSV,
+// NDJSON or xlsx download. Errors after the first rows were sent cannot be
+// reported to the client any more; they are logged and the file is cut
+// short.
+func (cl *Controller) Export(c *gin.Context) {
+	query := c.Request.URL.Query()
+	if err := movies.CheckExportParams(query); err != nil {
+		c.Error(err)
+		return
+	}
+
+	format := movieexport.FormatCSV
+	if value := query.Get("format"); value != "" {
+		format = movieexport.Format(value)
+	}
+	if format != movieexport.FormatCSV && format != movieexport.FormatNDJSON && format != movieexport.FormatXLSX {
+		c.Error(apperror.InvalidField("format", "must be one of: csv, ndjson, xlsx").With("allowed", movieexport.Formats))
+		return
+	}
+
+	var filter movies.SearchMovieRequest
+	if queryQ := query["query"]; len(queryQ) > 0 {
+		filter.Query = &queryQ[0]
+	}
+
+	if err := c.ShouldBindQuery(&filter.MovieFilter); err != nil {
+		c.Error(apperror.Binding(err))
+		return
+	}
+
+	if err := filter.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The headers are sent with the first batch, so that errors before it
+	// still get a problem response.
+	var out movieexport.Writer
+	start := func() error {
+		// Large exports take longer than the server's write timeout.
+		http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+		filename := fmt.Sprintf("movies-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+
+		var err error
+		out, err = movieexport.NewWriter(c.Writer, format)
+		return err
+	}
+
+	err := cl.useCase.Export(c.Request.Context(), filter, func(batch []*entity.Movie) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := out.Write(batch); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+
+		return nil
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err == nil {
+		err = out.Close()
+	}
+
+	if err != nil {
+		if c.Writer.Written() {
+			log.Printf("%s %s: export interrupted: %v", c.Request.Method, c.Request.URL.Path, err)
+			return
+		}
+		c.Error(err)
+	}
 }
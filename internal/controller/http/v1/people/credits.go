@@ -0,0 +1,184 @@
+package people
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/people"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func toCreditResponse(credit *entity.MovieCredit) people.CreditResponse {
+	response := people.CreditResponse{
+		ID:            credit.Id,
+		MovieID:       credit.MovieId,
+		Role:          credit.Role,
+		CharacterName: credit.CharacterName,
+		BillingOrder:  credit.BillingOrder,
+	}
+
+	if credit.Person != nil {
+		response.Person = people.CreditPerson{
+			ID:   credit.Person.Id,
+			Name: credit.Person.Name,
+		}
+	}
+
+	return response
+}
+
+// movieID parses the :id parameter and checks that the movie exists,
+// writing the error response itself when it does not.
+func (c *Controller) movieID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid movie ID"))
+		return 0, false
+	}
+
+	if _, err := c.movieRepo.GetByID(ctx, id); err != nil {
+		ctx.Error(err)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (c *Controller) credit(ctx *gin.Context) (*entity.MovieCredit, bool) {
+	movieID, ok := c.movieID(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	creditID, err := strconv.Atoi(ctx.Param("credit_id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid credit ID"))
+		return nil, false
+	}
+
+	credit, err := c.repo.GetCreditByID(ctx, movieID, creditID)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	return credit, true
+}
+
+func (c *Controller) GetCredits(ctx *gin.Context) {
+	movieID, ok := c.movieID(ctx)
+	if !ok {
+		return
+	}
+
+	credits, err := c.repo.GetCredits(ctx, movieID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := make([]people.CreditResponse, 0, len(credits))
+	for _, credit := range credits {
+		response = append(response, toCreditResponse(credit))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
+
+func (c *Controller) CreateCredit(ctx *gin.Context) {
+	movieID, ok := c.movieID(ctx)
+	if !ok {
+		return
+	}
+
+	var req people.CreateCreditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	person, err := c.repo.GetByID(ctx, *req.PersonID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(apperror.InvalidField("person_id", "refers to a person that does not exist"))
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	credit := &entity.MovieCredit{
+		MovieId:  movieID,
+		PersonId: person.Id,
+		Person:   person,
+		Role:     *req.Role,
+	}
+	if req.CharacterName != nil {
+		credit.CharacterName = *req.CharacterName
+	}
+	if req.BillingOrder != nil {
+		credit.BillingOrder = *req.BillingOrder
+	}
+
+	if err := c.repo.CreateCredit(ctx, credit); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": toCreditResponse(credit),
+	})
+}
+
+func (c *Controller) UpdateCredit(ctx *gin.Context) {
+	credit, ok := c.credit(ctx)
+	if !ok {
+		return
+	}
+
+	var req people.UpdateCreditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if req.Role != nil {
+		credit.Role = *req.Role
+	}
+	if req.CharacterName != nil {
+		credit.CharacterName = *req.CharacterName
+	}
+	if req.BillingOrder != nil {
+		credit.BillingOrder = *req.BillingOrder
+	}
+
+	if err := c.repo.UpdateCredit(ctx, credit); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Credit updated successfully",
+		"data":    toCreditResponse(credit),
+	})
+}
+
+func (c *Controller) DeleteCredit(ctx *gin.Context) {
+	credit, ok := c.credit(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.DeleteCredit(ctx, credit); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Credit deleted successfully",
+	})
+}
@@ -0,0 +1,36 @@
+package people
+
+import (
+	"Movies-Go/internal/entity"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"Movies-Go/internal/repository/postgres/people"
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, person *entity.Person) error
+
+	GetAll(ctx context.Context, filter people.Filter) ([]*entity.Person, int, error)
+
+	GetByID(ctx context.Context, id int) (*entity.Person, error)
+
+	Update(ctx context.Context, person *entity.Person) error
+
+	Delete(ctx context.Context, data basic_repo.Delete) error
+
+	GetFilmography(ctx context.Context, personID int) ([]*entity.MovieCredit, error)
+
+	GetCredits(ctx context.Context, movieID int) ([]*entity.MovieCredit, error)
+
+	GetCreditByID(ctx context.Context, movieID, creditID int) (*entity.MovieCredit, error)
+
+	CreateCredit(ctx context.Context, credit *entity.MovieCredit) error
+
+	UpdateCredit(ctx context.Context, credit *entity.MovieCredit) error
+
+	DeleteCredit(ctx context.Context, credit *entity.MovieCredit) error
+}
+
+type MovieRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.Movie, error)
+}
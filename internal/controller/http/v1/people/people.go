@@ -0,0 +1,203 @@
+package people
+
+import (
+	basic_controller "Movies-Go/internal/controller/http/v1/_basic_controller"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/people"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type Controller struct {
+	repo      Repository
+	movieRepo MovieRepository
+}
+
+func NewController(repo Repository, movieRepo MovieRepository) *Controller {
+	return &Controller{
+		repo:      repo,
+		movieRepo: movieRepo,
+	}
+}
+
+func toResponse(person *entity.Person) people.PersonResponse {
+	return people.PersonResponse{
+		ID:        person.Id,
+		Name:      person.Name,
+		Bio:       person.Bio,
+		CreatedAt: person.CreatedAt,
+		UpdatedAt: person.UpdatedAt,
+	}
+}
+
+func (c *Controller) Create(ctx *gin.Context) {
+	var req people.CreatePersonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	person := &entity.Person{
+		Name: *req.Name,
+	}
+	if req.Bio != nil {
+		person.Bio = *req.Bio
+	}
+
+	if err := c.repo.Create(ctx, person); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": toResponse(person),
+	})
+}
+
+func (c *Controller) GetAll(ctx *gin.Context) {
+	var filter people.Filter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.GetAll(ctx, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := make([]people.PersonResponse, 0, len(list))
+	for _, person := range list {
+		response = append(response, toResponse(person))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"results": response,
+			"count":   count,
+		},
+	})
+}
+
+func (c *Controller) GetByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid person ID"))
+		return
+	}
+
+	person, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": toResponse(person),
+	})
+}
+
+func (c *Controller) Update(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid person ID"))
+		return
+	}
+
+	person, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var req people.UpdatePersonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if req.Name != nil {
+		person.Name = *req.Name
+	}
+
+	if req.Bio != nil {
+		person.Bio = *req.Bio
+	}
+
+	if err := c.repo.Update(ctx, person); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Person updated successfully",
+		"data":    toResponse(person),
+	})
+}
+
+func (c *Controller) Delete(ctx *gin.Context) {
+	reqCtx, data, err := basic_controller.BasicDelete(ctx)
+	if err != nil {
+		return
+	}
+
+	if _, err := c.repo.GetByID(reqCtx, *data.Id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := c.repo.Delete(reqCtx, data); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Person deleted successfully",
+	})
+}
+
+// Filmography lists every movie the person is credited on, newest first.
+func (c *Controller) Filmography(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid person ID"))
+		return
+	}
+
+	person, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	credits, err := c.repo.GetFilmography(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := people.FilmographyResponse{
+		Person:  toResponse(person),
+		Credits: make([]*people.FilmographyEntry, 0, len(credits)),
+	}
+	for _, credit := range credits {
+		response.Credits = append(response.Credits, &people.FilmographyEntry{
+			CreditID:      credit.Id,
+			Role:          credit.Role,
+			CharacterName: credit.CharacterName,
+			BillingOrder:  credit.BillingOrder,
+			Movie: people.FilmographyMovie{
+				ID:    credit.Movie.Id,
+				Title: credit.Movie.Title,
+				Year:  credit.Movie.Year,
+			},
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
@@ -0,0 +1,18 @@
+package recommendations
+
+import (
+	"Movies-Go/internal/entity"
+	"context"
+)
+
+type Repository interface {
+	Similar(ctx context.Context, movieID, limit int) ([]*entity.MovieSimilarity, error)
+
+	ForUser(ctx context.Context, userID, limit int) ([]*entity.UserRecommendation, error)
+
+	Popular(ctx context.Context, userID, limit int) ([]*entity.Movie, error)
+}
+
+type MovieRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.Movie, error)
+}
@@ -0,0 +1,142 @@
+package recommendations
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/recommendations"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Controller struct {
+	repo      Repository
+	movieRepo MovieRepository
+}
+
+func NewController(repo Repository, movieRepo MovieRepository) *Controller {
+	return &Controller{
+		repo:      repo,
+		movieRepo: movieRepo,
+	}
+}
+
+func toMovie(movie *entity.Movie) recommendations.RecommendedMovie {
+	genres := make([]string, 0, len(movie.Genres))
+	for _, genre := range movie.Genres {
+		genres = append(genres, genre.Name)
+	}
+
+	return recommendations.RecommendedMovie{
+		ID:             movie.Id,
+		Title:          movie.Title,
+		Director:       movie.Director,
+		Year:           movie.Year,
+		Rating:         movie.Rating,
+		WeightedRating: movie.WeightedRating,
+		Genres:         genres,
+	}
+}
+
+// Similar lists the movies most like the :id movie.
+func (c *Controller) Similar(ctx *gin.Context) {
+	movieID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid movie ID"))
+		return
+	}
+
+	if _, err := c.movieRepo.GetByID(ctx, movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var filter recommendations.Filter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, err := c.repo.Similar(ctx, movieID, *filter.Limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := recommendations.SimilarListResponse{
+		Results: make([]recommendations.SimilarMovieResponse, 0, len(list)),
+	}
+	for _, similarity := range list {
+		response.Results = append(response.Results, recommendations.SimilarMovieResponse{
+			Movie: toMovie(similarity.SimilarMovie),
+			Score: similarity.Score,
+			Scores: recommendations.SimilarityScores{
+				Genres:  similarity.GenreScore,
+				People:  similarity.PeopleScore,
+				Plot:    similarity.PlotScore,
+				Ratings: similarity.RatingScore,
+			},
+		})
+		response.ComputedAt = similarity.ComputedAt
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
+
+// ForCurrentUser lists personal recommendations for the current user. Users
+// the last refresh found nothing for, e.g. new accounts, get the best rated
+// movies they have not seen instead.
+func (c *Controller) ForCurrentUser(ctx *gin.Context) {
+	var filter recommendations.UserFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	userID := ctx.GetInt("user_id")
+
+	list, err := c.repo.ForUser(ctx, userID, *filter.Limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := recommendations.RecommendationListResponse{
+		Results: make([]recommendations.RecommendationResponse, 0, len(list)),
+		Source:  recommendations.SourcePersonalized,
+	}
+	for _, recommendation := range list {
+		response.Results = append(response.Results, recommendations.RecommendationResponse{
+			Movie: toMovie(recommendation.Movie),
+			Score: recommendation.Score,
+			Scores: &recommendations.RecommendationScores{
+				Collaborative: recommendation.CollaborativeScore,
+				Content:       recommendation.ContentScore,
+			},
+		})
+		response.ComputedAt = recommendation.ComputedAt
+	}
+
+	if len(list) == 0 {
+		popular, err := c.repo.Popular(ctx, userID, *filter.Limit)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		response.Source = recommendations.SourcePopular
+		for _, movie := range popular {
+			response.Results = append(response.Results, recommendations.RecommendationResponse{
+				Movie: toMovie(movie),
+				Score: movie.WeightedRating,
+			})
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
@@ -0,0 +1,27 @@
+package reviews
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/repository/postgres/movies"
+	"context"
+)
+
+type Repository interface {
+	Upsert(ctx context.Context, review *entity.Review) error
+
+	Delete(ctx context.Context, userID, movieID int) error
+
+	GetByUserAndMovie(ctx context.Context, userID, movieID int) (*entity.Review, error)
+
+	GetByMovie(ctx context.Context, movieID int, filter movies.Filter) ([]*entity.Review, int, error)
+
+	GetByUser(ctx context.Context, userID int, filter movies.Filter) ([]*entity.Review, int, error)
+}
+
+type MovieRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.Movie, error)
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.User, error)
+}
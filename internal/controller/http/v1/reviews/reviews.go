@@ -0,0 +1,190 @@
+package reviews
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/repository/postgres/reviews"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type Controller struct {
+	repo      Repository
+	movieRepo MovieRepository
+	userRepo  UserRepository
+}
+
+func NewController(repo Repository, movieRepo MovieRepository, userRepo UserRepository) *Controller {
+	return &Controller{
+		repo:      repo,
+		movieRepo: movieRepo,
+		userRepo:  userRepo,
+	}
+}
+
+func toResponse(review *entity.Review) reviews.ReviewResponse {
+	response := reviews.ReviewResponse{
+		ID:        review.Id,
+		MovieID:   review.MovieId,
+		Score:     review.Score,
+		Body:      review.Body,
+		CreatedAt: review.CreatedAt,
+		UpdatedAt: review.UpdatedAt,
+	}
+
+	if review.User != nil {
+		response.User = &reviews.ReviewUser{
+			ID:   review.User.Id,
+			Name: review.User.Name,
+		}
+	}
+
+	if review.Movie != nil {
+		response.Movie = &reviews.ReviewMovie{
+			ID:    review.Movie.Id,
+			Title: review.Movie.Title,
+			Year:  review.Movie.Year,
+		}
+	}
+
+	return response
+}
+
+func toListResponse(list []*entity.Review, count int) map[string]interface{} {
+	results := make([]reviews.ReviewResponse, 0, len(list))
+	for _, review := range list {
+		results = append(results, toResponse(review))
+	}
+
+	return map[string]interface{}{
+		"results": results,
+		"count":   count,
+	}
+}
+
+// movieID parses the :id parameter and checks that the movie exists,
+// writing the error response itself when it does not.
+func (c *Controller) movieID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid movie ID"))
+		return 0, false
+	}
+
+	if _, err := c.movieRepo.GetByID(ctx, id); err != nil {
+		ctx.Error(err)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (c *Controller) GetByMovie(ctx *gin.Context) {
+	movieID, ok := c.movieID(ctx)
+	if !ok {
+		return
+	}
+
+	var filter movies.Filter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.GetByMovie(ctx, movieID, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": toListResponse(list, count),
+	})
+}
+
+func (c *Controller) GetByUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid user ID"))
+		return
+	}
+
+	if _, err := c.userRepo.GetByID(ctx, userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var filter movies.Filter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.GetByUser(ctx, userID, filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": toListResponse(list, count),
+	})
+}
+
+// Upsert creates or replaces the current user's review of the movie.
+func (c *Controller) Upsert(ctx *gin.Context) {
+	movieID, ok := c.movieID(ctx)
+	if !ok {
+		return
+	}
+
+	var req reviews.UpsertReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	review := &entity.Review{
+		UserId:  ctx.GetInt("user_id"),
+		MovieId: movieID,
+		Score:   *req.Score,
+	}
+	if req.Body != nil {
+		review.Body = *req.Body
+	}
+
+	if err := c.repo.Upsert(ctx, review); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	saved, err := c.repo.GetByUserAndMovie(ctx, review.UserId, movieID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Review saved successfully",
+		"data":    toResponse(saved),
+	})
+}
+
+// Delete removes the current user's review of the movie.
+func (c *Controller) Delete(ctx *gin.Context) {
+	movieID, ok := c.movieID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.Delete(ctx, ctx.GetInt("user_id"), movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Review deleted successfully",
+	})
+}
@@ -2,7 +2,8 @@ package users
 
 import (
 	"Movies-Go/internal/entity"
-	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/pkg/cursor"
+	"Movies-Go/internal/repository/postgres/users"
 	"context"
 )
 
@@ -13,11 +14,19 @@ type Repository interface {
 
 	Create(ctx context.Context, user *entity.User) error
 
-	GetAll(ctx context.Context, filter movies.Filter) ([]*entity.User, error)
+	GetAll(ctx context.Context, filter users.Filter) ([]*entity.User, cursor.Page, error)
 
 	GetByID(ctx context.Context, id int) (*entity.User, error)
 
 	Update(ctx context.Context, user *entity.User) error
 
 	Delete(ctx context.Context, id int) error
+
+	UpdateRole(ctx context.Context, id int, role string) error
+}
+
+type SessionRepository interface {
+	RevokeAll(ctx context.Context, userID int) (int, error)
+
+	RevokeOthers(ctx context.Context, userID, sessionID int) (int, error)
 }
@@ -1,43 +1,51 @@
 package users
 
 import (
-	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/accountmail"
+	"Movies-Go/internal/pkg/apperror"
 	"Movies-Go/internal/repository/postgres/users"
 	"Movies-Go/internal/utils/password"
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
 )
 
 type Controller struct {
-	repo Repository
+	repo        Repository
+	sessionRepo SessionRepository
+	mail        *accountmail.Sender
 }
 
-func NewController(repo Repository) *Controller {
+func NewController(repo Repository, sessionRepo SessionRepository, mail *accountmail.Sender) *Controller {
 	return &Controller{
-		repo: repo,
+		repo:        repo,
+		sessionRepo: sessionRepo,
+		mail:        mail,
 	}
 }
 
 func (c *Controller) GetAll(ctx *gin.Context) {
-	var filter movies.Filter
+	var filter users.Filter
 	if err := ctx.ShouldBindQuery(&filter); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid filter parameters: " + err.Error(),
-		})
+		ctx.Error(apperror.Binding(err))
 		return
 	}
 
-	users, err := c.repo.GetAll(ctx, filter)
+	list, links, err := c.repo.GetAll(ctx, filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve users: " + err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": users,
+		"data": map[string]interface{}{
+			"results":     list,
+			"next_cursor": links.NextCursor,
+			"prev_cursor": links.PrevCursor,
+		},
 	})
 }
 
@@ -45,17 +53,13 @@ func (c *Controller) GetByID(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		ctx.Error(apperror.Validation("Invalid user ID"))
 		return
 	}
 
 	user, err := c.repo.GetByID(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-		})
+		ctx.Error(err)
 		return
 	}
 
@@ -68,25 +72,24 @@ func (c *Controller) Update(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		ctx.Error(apperror.Validation("Invalid user ID"))
+		return
+	}
+
+	if ctx.GetString("role") != entity.RoleAdmin && ctx.GetInt("user_id") != id {
+		ctx.Error(apperror.Forbidden("Unauthorized access: insufficient permissions"))
 		return
 	}
 
 	existingUser, err := c.repo.GetByID(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-		})
+		ctx.Error(err)
 		return
 	}
 
 	var req users.UpdateUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+		ctx.Error(apperror.Binding(err))
 		return
 	}
 
@@ -94,31 +97,58 @@ func (c *Controller) Update(ctx *gin.Context) {
 		existingUser.Name = req.Name
 	}
 
-	if req.Email != "" && req.Email != existingUser.Email {
+	emailChanged := req.Email != "" && req.Email != existingUser.Email
+	if emailChanged {
 		user, _ := c.repo.GetByEmail(ctx, req.Email)
 		if user != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Email already in use",
-			})
+			ctx.Error(apperror.Conflict("Email already in use"))
 			return
 		}
 		existingUser.Email = req.Email
+		// The new address has to be verified again.
+		existingUser.EmailVerifiedAt = nil
 	}
 
 	if req.Password != "" {
 		hashedPassword, err := password.Hash(req.Password)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to process password: " + err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 		existingUser.Password = hashedPassword
 	}
 
 	if err := c.repo.Update(ctx, existingUser); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update user: " + err.Error(),
+		ctx.Error(err)
+		return
+	}
+
+	// A new password logs out every other session, in case the old one
+	// leaked. Users changing their own password stay logged in.
+	if req.Password != "" {
+		if ctx.GetInt("user_id") == id {
+			_, err = c.sessionRepo.RevokeOthers(ctx, id, ctx.GetInt("session_id"))
+		} else {
+			_, err = c.sessionRepo.RevokeAll(ctx, id)
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	if emailChanged {
+		user := *existingUser
+		accountmail.InBackground(ctx.Request.Context(), "Sending the verification email", func(ctx context.Context) error {
+			if err := c.mail.SendVerification(ctx, &user); err != nil {
+				return fmt.Errorf("user %d: %w", user.Id, err)
+			}
+			return nil
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": "User updated successfully, follow the link sent to the new email address to verify it",
+			"data":    existingUser,
 		})
 		return
 	}
@@ -133,24 +163,23 @@ func (c *Controller) Delete(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		ctx.Error(apperror.Validation("Invalid user ID"))
 		return
 	}
 
 	currentUserID, exists := ctx.Get("user_id")
 	if exists && currentUserID.(int) == id {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Cannot delete your own account",
-		})
+		ctx.Error(apperror.Validation("Cannot delete your own account"))
 		return
 	}
 
 	if err := c.repo.Delete(ctx, id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete user: " + err.Error(),
-		})
+		ctx.Error(err)
+		return
+	}
+
+	if _, err := c.sessionRepo.RevokeAll(ctx, id); err != nil {
+		ctx.Error(err)
 		return
 	}
 
@@ -158,3 +187,46 @@ func (c *Controller) Delete(ctx *gin.Context) {
 		"message": "User deleted successfully",
 	})
 }
+
+// UpdateRole changes a user's role and logs them out, so that tokens
+// carrying the old role stop working. The last remaining admin cannot be
+// demoted, so the installation always keeps someone able to manage roles.
+func (c *Controller) UpdateRole(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid user ID"))
+		return
+	}
+
+	var req users.UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	user, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := c.repo.UpdateRole(ctx, id, req.Role); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if req.Role != user.Role {
+		if _, err := c.sessionRepo.RevokeAll(ctx, id); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	user.Role = req.Role
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Role updated successfully",
+		"data":    user,
+	})
+}
@@ -0,0 +1,32 @@
+package watchlists
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/repository/postgres/watchlists"
+	"context"
+	"time"
+)
+
+type Repository interface {
+	GetWatchlist(ctx context.Context, userID int, filter watchlists.WatchlistFilter) ([]*entity.WatchlistItem, int, error)
+
+	GetWatchlistItem(ctx context.Context, userID, movieID int) (*entity.WatchlistItem, error)
+
+	AddToWatchlist(ctx context.Context, item *entity.WatchlistItem) error
+
+	UpdateWatchlistItem(ctx context.Context, userID, movieID int, position *int, note *string) (*entity.WatchlistItem, error)
+
+	RemoveFromWatchlist(ctx context.Context, userID, movieID int) error
+
+	GetHistory(ctx context.Context, userID int, filter watchlists.HistoryFilter) ([]*entity.WatchHistoryEntry, int, error)
+
+	MarkWatched(ctx context.Context, userID, movieID int, watchedAt time.Time) (*entity.WatchHistoryEntry, error)
+
+	UpdateHistoryEntry(ctx context.Context, userID, movieID int, watchedAt *time.Time, rewatchCount *int) (*entity.WatchHistoryEntry, error)
+
+	RemoveFromHistory(ctx context.Context, userID, movieID int) error
+}
+
+type MovieRepository interface {
+	GetByID(ctx context.Context, id int) (*entity.Movie, error)
+}
@@ -0,0 +1,335 @@
+package watchlists
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/watchlists"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+type Controller struct {
+	repo      Repository
+	movieRepo MovieRepository
+}
+
+func NewController(repo Repository, movieRepo MovieRepository) *Controller {
+	return &Controller{
+		repo:      repo,
+		movieRepo: movieRepo,
+	}
+}
+
+func movieSummary(movie *entity.Movie) *watchlists.MovieSummary {
+	if movie == nil {
+		return nil
+	}
+
+	return &watchlists.MovieSummary{
+		ID:             movie.Id,
+		Title:          movie.Title,
+		Director:       movie.Director,
+		Year:           movie.Year,
+		Rating:         movie.Rating,
+		WeightedRating: movie.WeightedRating,
+	}
+}
+
+func toItemResponse(item *entity.WatchlistItem) watchlists.WatchlistItemResponse {
+	return watchlists.WatchlistItemResponse{
+		MovieID:   item.MovieId,
+		Position:  item.Position,
+		Note:      item.Note,
+		Watched:   item.Watched,
+		Movie:     movieSummary(item.Movie),
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+func toEntryResponse(entry *entity.WatchHistoryEntry) watchlists.HistoryEntryResponse {
+	return watchlists.HistoryEntryResponse{
+		MovieID:      entry.MovieId,
+		WatchedAt:    entry.WatchedAt.Format(dateLayout),
+		RewatchCount: entry.RewatchCount,
+		Movie:        movieSummary(entry.Movie),
+		CreatedAt:    entry.CreatedAt,
+		UpdatedAt:    entry.UpdatedAt,
+	}
+}
+
+// movie looks up the movie a request body refers to. An unknown id is a
+// problem with the request rather than a missing resource.
+func (c *Controller) movie(ctx *gin.Context, id int) (*entity.Movie, bool) {
+	movie, err := c.movieRepo.GetByID(ctx, id)
+	if errors.Is(err, apperror.ErrNotFound) {
+		ctx.Error(apperror.InvalidField("movie_id", "movie does not exist"))
+		return nil, false
+	}
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	return movie, true
+}
+
+// movieID parses the :movie_id parameter.
+func parseMovieID(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("movie_id"))
+	if err != nil {
+		ctx.Error(apperror.Validation("Invalid movie ID"))
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (c *Controller) GetWatchlist(ctx *gin.Context) {
+	var filter watchlists.WatchlistFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.GetWatchlist(ctx, ctx.GetInt("user_id"), filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	results := make([]watchlists.WatchlistItemResponse, 0, len(list))
+	for _, item := range list {
+		results = append(results, toItemResponse(item))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"results": results,
+			"count":   count,
+		},
+	})
+}
+
+// AddToWatchlist puts a movie on the current user's watchlist, at the end
+// unless a position is given.
+func (c *Controller) AddToWatchlist(ctx *gin.Context) {
+	var req watchlists.AddWatchlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if _, ok := c.movie(ctx, *req.MovieID); !ok {
+		return
+	}
+
+	item := &entity.WatchlistItem{
+		UserId:  ctx.GetInt("user_id"),
+		MovieId: *req.MovieID,
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+	if req.Note != nil {
+		item.Note = *req.Note
+	}
+
+	if err := c.repo.AddToWatchlist(ctx, item); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.respondItem(ctx, http.StatusCreated, "Movie added to the watchlist", item.MovieId)
+}
+
+// UpdateWatchlistItem moves a movie within the current user's watchlist
+// and/or changes its note.
+func (c *Controller) UpdateWatchlistItem(ctx *gin.Context) {
+	movieID, ok := parseMovieID(ctx)
+	if !ok {
+		return
+	}
+
+	var req watchlists.UpdateWatchlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	if _, err := c.repo.UpdateWatchlistItem(ctx, ctx.GetInt("user_id"), movieID, req.Position, req.Note); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	c.respondItem(ctx, http.StatusOK, "Watchlist updated successfully", movieID)
+}
+
+func (c *Controller) RemoveFromWatchlist(ctx *gin.Context) {
+	movieID, ok := parseMovieID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.RemoveFromWatchlist(ctx, ctx.GetInt("user_id"), movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Movie removed from the watchlist",
+	})
+}
+
+func (c *Controller) respondItem(ctx *gin.Context, status int, message string, movieID int) {
+	item, err := c.repo.GetWatchlistItem(ctx, ctx.GetInt("user_id"), movieID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"message": message,
+		"data":    toItemResponse(item),
+	})
+}
+
+func (c *Controller) GetHistory(ctx *gin.Context) {
+	var filter watchlists.HistoryFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	list, count, err := c.repo.GetHistory(ctx, ctx.GetInt("user_id"), filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	results := make([]watchlists.HistoryEntryResponse, 0, len(list))
+	for _, entry := range list {
+		results = append(results, toEntryResponse(entry))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": map[string]interface{}{
+			"results": results,
+			"count":   count,
+		},
+	})
+}
+
+// MarkWatched records that the current user watched a movie, today unless
+// watched_at is given. Marking a movie that is already in the history
+// counts as a rewatch.
+func (c *Controller) MarkWatched(ctx *gin.Context) {
+	var req watchlists.AddHistoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	watchedAt, ok := parseDate(ctx, req.WatchedAt)
+	if !ok {
+		return
+	}
+	if watchedAt == nil {
+		today, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+		watchedAt = &today
+	}
+
+	movie, ok := c.movie(ctx, *req.MovieID)
+	if !ok {
+		return
+	}
+
+	entry, err := c.repo.MarkWatched(ctx, ctx.GetInt("user_id"), movie.Id, *watchedAt)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	entry.Movie = movie
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Movie marked as watched",
+		"data":    toEntryResponse(entry),
+	})
+}
+
+// UpdateHistoryEntry corrects the watched date or rewatch count of a movie
+// in the current user's history.
+func (c *Controller) UpdateHistoryEntry(ctx *gin.Context) {
+	movieID, ok := parseMovieID(ctx)
+	if !ok {
+		return
+	}
+
+	var req watchlists.UpdateHistoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.Binding(err))
+		return
+	}
+
+	watchedAt, ok := parseDate(ctx, req.WatchedAt)
+	if !ok {
+		return
+	}
+
+	entry, err := c.repo.UpdateHistoryEntry(ctx, ctx.GetInt("user_id"), movieID, watchedAt, req.RewatchCount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if movie, err := c.movieRepo.GetByID(ctx, movieID); err == nil {
+		entry.Movie = movie
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "History updated successfully",
+		"data":    toEntryResponse(entry),
+	})
+}
+
+func (c *Controller) RemoveFromHistory(ctx *gin.Context) {
+	movieID, ok := parseMovieID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.RemoveFromHistory(ctx, ctx.GetInt("user_id"), movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Movie removed from the history",
+	})
+}
+
+// parseDate parses an optional YYYY-MM-DD request value. Dates in the
+// future are rejected.
+func parseDate(ctx *gin.Context, value *string) (*time.Time, bool) {
+	if value == nil {
+		return nil, true
+	}
+
+	date, err := time.Parse(dateLayout, *value)
+	if err != nil {
+		ctx.Error(apperror.InvalidField("watched_at", "must be a date in the YYYY-MM-DD format"))
+		return nil, false
+	}
+	if date.After(time.Now()) {
+		ctx.Error(apperror.InvalidField("watched_at", "must not be in the future"))
+		return nil, false
+	}
+
+	return &date, true
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	// ScopeRead allows GET requests, ScopeWrite every other method.
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// APIKey lets scripts act as its user without a password. Prefix
+// identifies the key in listings and lookups; only the SHA-256 hash of the
+// whole key is stored.
+type APIKey struct {
+	bun.BaseModel `bun:"table:api_keys"`
+
+	Id         int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId     int        `json:"user_id" bun:"user_id,notnull"`
+	User       *User      `json:"-" bun:"rel:belongs-to,join:user_id=id"`
+	Name       string     `json:"name" bun:"name,notnull"`
+	Prefix     string     `json:"prefix" bun:"prefix,notnull,unique"`
+	KeyHash    string     `json:"-" bun:"key_hash,notnull"`
+	Scopes     []string   `json:"scopes" bun:"scopes,array"`
+	ExpiresAt  *time.Time `json:"expires_at" bun:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" bun:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bun:"revoked_at"`
+	CreatedAt  *time.Time `json:"created_at" bun:"created_at"`
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+// Visibilities lists every collection visibility. Private collections are
+// only visible to their owner, unlisted ones to anyone with the share link
+// and public ones to everybody.
+var Visibilities = []string{VisibilityPrivate, VisibilityUnlisted, VisibilityPublic}
+
+// Collection is a named, ordered list of movies curated by a user.
+type Collection struct {
+	bun.BaseModel `bun:"table:collections"`
+
+	Id          int    `json:"id" bun:"id,pk,autoincrement"`
+	UserId      int    `json:"user_id" bun:"user_id,notnull"`
+	User        *User  `json:"user,omitempty" bun:"rel:belongs-to,join:user_id=id"`
+	Name        string `json:"name" bun:"name,notnull"`
+	Description string `json:"description,omitempty" bun:"description"`
+	Visibility  string `json:"visibility" bun:"visibility,notnull,default:'private'"`
+	// ShareToken grants access to an unlisted collection.
+	ShareToken   string     `json:"-" bun:"share_token,notnull,unique"`
+	CopiedFromId *int       `json:"copied_from_id,omitempty" bun:"copied_from_id"`
+	CreatedAt    *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at" bun:"updated_at"`
+
+	ItemCount int `json:"item_count" bun:"item_count,scanonly"`
+}
+
+// CollectionItem is a movie in a collection. Position orders the
+// collection, starting at 1.
+type CollectionItem struct {
+	bun.BaseModel `bun:"table:collection_items"`
+
+	Id           int        `json:"id" bun:"id,pk,autoincrement"`
+	CollectionId int        `json:"collection_id" bun:"collection_id,notnull"`
+	MovieId      int        `json:"movie_id" bun:"movie_id,notnull"`
+	Movie        *Movie     `json:"movie,omitempty" bun:"rel:belongs-to,join:movie_id=id"`
+	Position     int        `json:"position" bun:"position,notnull"`
+	Note         string     `json:"note,omitempty" bun:"note"`
+	CreatedAt    *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at" bun:"updated_at"`
+}
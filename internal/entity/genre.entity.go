@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type Genre struct {
+	bun.BaseModel `bun:"table:genres"`
+
+	basicEntity
+	Id          int        `json:"id" bun:"id,pk,autoincrement"`
+	Name        string     `json:"name" bun:"name,notnull"`
+	Description string     `json:"description,omitempty" bun:"description"`
+	CreatedAt   *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at" bun:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" bun:"deleted_at"`
+}
+
+// MovieGenre is the join model behind the Movie.Genres many-to-many relation.
+type MovieGenre struct {
+	bun.BaseModel `bun:"table:movie_genres"`
+
+	MovieId int    `json:"movie_id" bun:"movie_id,pk"`
+	Movie   *Movie `json:"-" bun:"rel:belongs-to,join:movie_id=id"`
+	GenreId int    `json:"genre_id" bun:"genre_id,pk"`
+	Genre   *Genre `json:"-" bun:"rel:belongs-to,join:genre_id=id"`
+}
@@ -9,13 +9,27 @@ type Movie struct {
 	bun.BaseModel `bun:"table:movies"`
 
 	basicEntity
-	Id        int        `json:"id" bun:"id,pk,autoincrement"`
-	Title     string     `json:"title" bun:"title,notnull"`
-	Director  string     `json:"director" bun:"director,notnull"`
-	Year      int        `json:"year" bun:"year,notnull"`
-	Plot      string     `json:"plot" bun:"plot"`
-	Rating    float64    `json:"rating" bun:"rating"`
-	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at" bun:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty" bun:"deleted_at"`
+	Id             int            `json:"id" bun:"id,pk,autoincrement"`
+	ExternalId     *string        `json:"external_id,omitempty" bun:"external_id"`
+	Title          string         `json:"title" bun:"title,notnull"`
+	Director       string         `json:"director" bun:"director,notnull"`
+	Year           int            `json:"year" bun:"year,notnull"`
+	Plot           string         `json:"plot" bun:"plot"`
+	Rating         float64        `json:"rating" bun:"rating"`
+	VoteCount      int            `json:"vote_count" bun:"vote_count,notnull,default:0"`
+	WeightedRating float64        `json:"weighted_rating" bun:"weighted_rating,notnull,default:0"`
+	Genres         []*Genre       `json:"genres" bun:"m2m:movie_genres,join:Movie=Genre"`
+	Credits        []*MovieCredit `json:"credits,omitempty" bun:"rel:has-many,join:id=movie_id"`
+	CreatedAt      *time.Time     `json:"created_at" bun:"created_at"`
+	UpdatedAt      *time.Time     `json:"updated_at" bun:"updated_at"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty" bun:"deleted_at"`
+
+	// Populated only by full-text search queries.
+	SearchRank     float64 `json:"-" bun:"search_rank,scanonly"`
+	TitleHighlight string  `json:"-" bun:"title_highlight,scanonly"`
+	PlotHighlight  string  `json:"-" bun:"plot_highlight,scanonly"`
+
+	// Set for the requesting user by the movie endpoints.
+	InWatchlist *bool `json:"in_watchlist,omitempty" bun:"-"`
+	Watched     *bool `json:"watched,omitempty" bun:"-"`
 }
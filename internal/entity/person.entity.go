@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	CreditRoleDirector        = "director"
+	CreditRoleActor           = "actor"
+	CreditRoleWriter          = "writer"
+	CreditRoleProducer        = "producer"
+	CreditRoleComposer        = "composer"
+	CreditRoleCinematographer = "cinematographer"
+	CreditRoleEditor          = "editor"
+)
+
+type Person struct {
+	bun.BaseModel `bun:"table:people"`
+
+	basicEntity
+	Id        int        `json:"id" bun:"id,pk,autoincrement"`
+	Name      string     `json:"name" bun:"name,notnull"`
+	Bio       string     `json:"bio,omitempty" bun:"bio"`
+	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" bun:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bun:"deleted_at"`
+}
+
+// MovieCredit records that a person worked on a movie in a given role.
+type MovieCredit struct {
+	bun.BaseModel `bun:"table:movie_credits"`
+
+	Id            int        `json:"id" bun:"id,pk,autoincrement"`
+	MovieId       int        `json:"movie_id" bun:"movie_id,notnull"`
+	Movie         *Movie     `json:"movie,omitempty" bun:"rel:belongs-to,join:movie_id=id"`
+	PersonId      int        `json:"person_id" bun:"person_id,notnull"`
+	Person        *Person    `json:"person,omitempty" bun:"rel:belongs-to,join:person_id=id"`
+	Role          string     `json:"role" bun:"role,notnull"`
+	CharacterName string     `json:"character_name,omitempty" bun:"character_name"`
+	BillingOrder  int        `json:"billing_order" bun:"billing_order,notnull,default:0"`
+	CreatedAt     *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at" bun:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// MovieSimilarity is a precomputed "more like this" pair. Score combines
+// the component scores, each between 0 and 1 except RatingScore, which is
+// a correlation between -1 and 1.
+type MovieSimilarity struct {
+	bun.BaseModel `bun:"table:movie_similarities"`
+
+	MovieId        int        `json:"movie_id" bun:"movie_id,pk"`
+	SimilarMovieId int        `json:"similar_movie_id" bun:"similar_movie_id,pk"`
+	SimilarMovie   *Movie     `json:"similar_movie,omitempty" bun:"rel:belongs-to,join:similar_movie_id=id"`
+	Score          float64    `json:"score" bun:"score,notnull"`
+	GenreScore     float64    `json:"genre_score" bun:"genre_score,notnull"`
+	PeopleScore    float64    `json:"people_score" bun:"people_score,notnull"`
+	PlotScore      float64    `json:"plot_score" bun:"plot_score,notnull"`
+	RatingScore    float64    `json:"rating_score" bun:"rating_score,notnull"`
+	ComputedAt     *time.Time `json:"computed_at" bun:"computed_at"`
+}
+
+// UserRecommendation is a precomputed suggestion of a movie the user has not
+// reviewed, watched or put on their watchlist yet.
+type UserRecommendation struct {
+	bun.BaseModel `bun:"table:user_recommendations"`
+
+	UserId             int        `json:"user_id" bun:"user_id,pk"`
+	MovieId            int        `json:"movie_id" bun:"movie_id,pk"`
+	Movie              *Movie     `json:"movie,omitempty" bun:"rel:belongs-to,join:movie_id=id"`
+	Score              float64    `json:"score" bun:"score,notnull"`
+	CollaborativeScore float64    `json:"collaborative_score" bun:"collaborative_score,notnull"`
+	ContentScore       float64    `json:"content_score" bun:"content_score,notnull"`
+	ComputedAt         *time.Time `json:"computed_at" bun:"computed_at"`
+}
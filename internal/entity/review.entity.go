@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// Review is a user's score (0-10) and optional text for a movie. A user has
+// at most one review per movie.
+type Review struct {
+	bun.BaseModel `bun:"table:reviews"`
+
+	Id        int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId    int        `json:"user_id" bun:"user_id,notnull"`
+	User      *User      `json:"user,omitempty" bun:"rel:belongs-to,join:user_id=id"`
+	MovieId   int        `json:"movie_id" bun:"movie_id,notnull"`
+	Movie     *Movie     `json:"movie,omitempty" bun:"rel:belongs-to,join:movie_id=id"`
+	Score     float64    `json:"score" bun:"score,notnull"`
+	Body      string     `json:"body,omitempty" bun:"body"`
+	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" bun:"updated_at"`
+}
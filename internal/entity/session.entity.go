@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// Session is one login of a user. Access tokens carry the session id so
+// that revoking the session invalidates them before they expire.
+type Session struct {
+	bun.BaseModel `bun:"table:sessions"`
+
+	Id         int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId     int        `json:"user_id" bun:"user_id,notnull"`
+	ExpiresAt  time.Time  `json:"expires_at" bun:"expires_at,notnull"`
+	LastUsedAt *time.Time `json:"last_used_at" bun:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bun:"revoked_at"`
+	CreatedAt  *time.Time `json:"created_at" bun:"created_at"`
+}
+
+// RefreshToken is a single-use token that renews a session. Only the
+// SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	bun.BaseModel `bun:"table:refresh_tokens"`
+
+	Id        int        `json:"id" bun:"id,pk,autoincrement"`
+	SessionId int        `json:"session_id" bun:"session_id,notnull"`
+	Session   *Session   `json:"-" bun:"rel:belongs-to,join:session_id=id"`
+	TokenHash string     `json:"-" bun:"token_hash,notnull,unique"`
+	ExpiresAt time.Time  `json:"expires_at" bun:"expires_at,notnull"`
+	UsedAt    *time.Time `json:"used_at,omitempty" bun:"used_at"`
+	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
+}
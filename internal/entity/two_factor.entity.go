@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// RecoveryCode replaces a TOTP code once when the user has lost their
+// authenticator. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	bun.BaseModel `bun:"table:recovery_codes"`
+
+	Id        int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId    int        `json:"user_id" bun:"user_id,notnull"`
+	CodeHash  string     `json:"-" bun:"code_hash,notnull"`
+	UsedAt    *time.Time `json:"used_at,omitempty" bun:"used_at"`
+	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
+}
+
+// RolePolicy holds the security requirements for the members of a role.
+type RolePolicy struct {
+	bun.BaseModel `bun:"table:role_policies"`
+
+	Role             string     `json:"role" bun:"role,pk"`
+	RequireTwoFactor bool       `json:"require_two_factor" bun:"require_two_factor,notnull"`
+	UpdatedAt        *time.Time `json:"updated_at" bun:"updated_at"`
+}
@@ -5,6 +5,15 @@ import (
 	"time"
 )
 
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Roles lists every assignable user role.
+var Roles = []string{RoleAdmin, RoleEditor, RoleViewer}
+
 type User struct {
 	bun.BaseModel `bun:"table:users"`
 
@@ -13,7 +22,29 @@ type User struct {
 	Name      string     `json:"name" bun:"name,notnull"`
 	Email     string     `json:"email" bun:"email,notnull,unique"`
 	Password  string     `json:"-" bun:"password,notnull"`
+	Role      string     `json:"role" bun:"role,notnull,default:'viewer'"`
 	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at" bun:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" bun:"deleted_at"`
+
+	// FailedAttempts counts consecutive failed logins; at the lockout
+	// threshold logins are refused until LockedUntil.
+	FailedAttempts int        `json:"-" bun:"failed_attempts,notnull"`
+	LockedUntil    *time.Time `json:"-" bun:"locked_until"`
+
+	// EmailVerifiedAt is when the user proved they own Email, nil until
+	// then.
+	EmailVerifiedAt *time.Time `json:"email_verified_at" bun:"email_verified_at"`
+
+	// TOTPSecret is set once the user starts enrolling in two-factor
+	// authentication, which is on from TOTPEnabledAt. TOTPLastStep is the
+	// time step of the last accepted code.
+	TOTPSecret    *string    `json:"-" bun:"totp_secret"`
+	TOTPEnabledAt *time.Time `json:"two_factor_enabled_at,omitempty" bun:"totp_enabled_at"`
+	TOTPLastStep  *int64     `json:"-" bun:"totp_last_step"`
+}
+
+// TwoFactorEnabled reports whether logins of the user need a second factor.
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
 }
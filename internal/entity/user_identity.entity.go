@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// UserIdentity links a user to their account at an OpenID Connect identity
+// provider, which identifies it by Subject. Email is the address the
+// provider reported when the identity was linked.
+type UserIdentity struct {
+	bun.BaseModel `bun:"table:user_identities"`
+
+	Id          int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId      int        `json:"user_id" bun:"user_id,notnull"`
+	User        *User      `json:"-" bun:"rel:belongs-to,join:user_id=id"`
+	Provider    string     `json:"provider" bun:"provider,notnull"`
+	Subject     string     `json:"-" bun:"subject,notnull"`
+	Email       *string    `json:"email" bun:"email"`
+	LastLoginAt *time.Time `json:"last_login_at" bun:"last_login_at"`
+	CreatedAt   *time.Time `json:"created_at" bun:"created_at"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+// UserToken is a single-use token mailed to a user for Purpose. Only the
+// SHA-256 hash of the token is stored.
+type UserToken struct {
+	bun.BaseModel `bun:"table:user_tokens"`
+
+	Id        int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId    int        `json:"user_id" bun:"user_id,notnull"`
+	Purpose   string     `json:"purpose" bun:"purpose,notnull"`
+	TokenHash string     `json:"-" bun:"token_hash,notnull,unique"`
+	ExpiresAt time.Time  `json:"expires_at" bun:"expires_at,notnull"`
+	UsedAt    *time.Time `json:"used_at,omitempty" bun:"used_at"`
+	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
+}
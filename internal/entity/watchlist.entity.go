@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// WatchlistItem is a movie a user wants to watch. Position orders the
+// user's list, starting at 1.
+type WatchlistItem struct {
+	bun.BaseModel `bun:"table:watchlist_items"`
+
+	Id        int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId    int        `json:"user_id" bun:"user_id,notnull"`
+	MovieId   int        `json:"movie_id" bun:"movie_id,notnull"`
+	Movie     *Movie     `json:"movie,omitempty" bun:"rel:belongs-to,join:movie_id=id"`
+	Position  int        `json:"position" bun:"position,notnull"`
+	Note      string     `json:"note,omitempty" bun:"note"`
+	CreatedAt *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" bun:"updated_at"`
+
+	// Watched is set when listing: whether the movie is in the user's history.
+	Watched bool `json:"-" bun:"watched,scanonly"`
+}
+
+// WatchHistoryEntry records that a user has seen a movie: when they last
+// watched it and how many times they watched it again after the first time.
+type WatchHistoryEntry struct {
+	bun.BaseModel `bun:"table:watch_history"`
+
+	Id           int        `json:"id" bun:"id,pk,autoincrement"`
+	UserId       int        `json:"user_id" bun:"user_id,notnull"`
+	MovieId      int        `json:"movie_id" bun:"movie_id,notnull"`
+	Movie        *Movie     `json:"movie,omitempty" bun:"rel:belongs-to,join:movie_id=id"`
+	WatchedAt    time.Time  `json:"watched_at" bun:"watched_at,notnull"`
+	RewatchCount int        `json:"rewatch_count" bun:"rewatch_count,notnull,default:0"`
+	CreatedAt    *time.Time `json:"created_at" bun:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at" bun:"updated_at"`
+}
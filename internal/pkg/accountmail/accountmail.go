@@ -0,0 +1,115 @@
+// Package accountmail sends the links of the account flows: verifying an
+// email address and resetting a password.
+package accountmail
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/auth"
+	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/mailer"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// backgroundTimeout bounds the work a request leaves to InBackground.
+const backgroundTimeout = 30 * time.Second
+
+type TokenRepository interface {
+	Issue(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error
+}
+
+// Sender issues the tokens of the links and mails them.
+type Sender struct {
+	tokenRepo TokenRepository
+	mailer    mailer.Mailer
+}
+
+func NewSender(tokenRepo TokenRepository, mailer mailer.Mailer) *Sender {
+	return &Sender{
+		tokenRepo: tokenRepo,
+		mailer:    mailer,
+	}
+}
+
+// SendVerification mails the user a link that verifies their email address.
+func (s *Sender) SendVerification(ctx context.Context, user *entity.User) error {
+	ttl := config.GetConf().EmailVerificationDuration()
+	link, err := s.issueLink(ctx, user, entity.TokenPurposeVerifyEmail, ttl, "/verify-email")
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"To verify the email address of your account, open this link within %s:\n\n%s\n\n"+
+			"If you did not sign up, ignore this email.\n",
+			user.Name, describe(ttl), link),
+	})
+}
+
+// SendPasswordReset mails the user a link to choose a new password.
+func (s *Sender) SendPasswordReset(ctx context.Context, user *entity.User) error {
+	ttl := config.GetConf().PasswordResetDuration()
+	link, err := s.issueLink(ctx, user, entity.TokenPurposeResetPassword, ttl, "/reset-password")
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Someone asked to reset the password of your account. To choose a new password, open this link within %s:\n\n%s\n\n"+
+			"If it was not you, ignore this email; your password stays the same.\n",
+			user.Name, describe(ttl), link),
+	})
+}
+
+// InBackground runs fn once the request is answered, bounded by
+// backgroundTimeout, and logs its error as what.
+func InBackground(ctx context.Context, what string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundTimeout)
+
+	go func() {
+		defer cancel()
+
+		if err := fn(ctx); err != nil {
+			log.Printf("%s: %v", what, err)
+		}
+	}()
+}
+
+// issueLink stores a new token of the user for purpose and returns the
+// link to the page of the web app at path that submits it.
+func (s *Sender) issueLink(ctx context.Context, user *entity.User, purpose string, ttl time.Duration, path string) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.tokenRepo.Issue(ctx, user.Id, purpose, hash, time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+
+	return config.GetConf().AppBaseURL() + path + "?token=" + url.QueryEscape(token), nil
+}
+
+// describe writes d in words for emails, e.g. "24 hours".
+func describe(d time.Duration) string {
+	unit, size := "minute", time.Minute
+	if d%time.Hour == 0 {
+		unit, size = "hour", time.Hour
+	}
+
+	n := int(d / size)
+	if n == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
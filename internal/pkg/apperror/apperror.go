@@ -0,0 +1,117 @@
+// Package apperror defines the errors the application reports to clients.
+// Repositories and controllers return them, and the error middleware turns
+// them into RFC 7807 problem responses.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors. Use errors.Is(err, apperror.ErrNotFound) to test for one.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	// ErrTooManyRequests reports a rate limit or a locked account.
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrUpstream reports that a service the request depends on, e.g. an
+	// identity provider, failed or could not be reached.
+	ErrUpstream = errors.New("upstream failure")
+)
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error that is safe to show to the client. Message becomes the
+// problem detail, the wrapped cause is only logged.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	// Extra holds additional members of the problem response.
+	Extra map[string]interface{}
+	cause error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Wrap records the underlying error without exposing it to the client.
+func (e *Error) Wrap(cause error) *Error {
+	e.cause = cause
+	return e
+}
+
+// With adds a member to the problem response, e.g. the allowed values of a
+// parameter.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extra == nil {
+		e.Extra = map[string]interface{}{}
+	}
+	e.Extra[key] = value
+
+	return e
+}
+
+func newError(kind error, format string, args []interface{}) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return newError(ErrNotFound, format, args)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return newError(ErrConflict, format, args)
+}
+
+func Validation(format string, args ...interface{}) *Error {
+	return newError(ErrValidation, format, args)
+}
+
+func Unauthorized(format string, args ...interface{}) *Error {
+	return newError(ErrUnauthorized, format, args)
+}
+
+func Forbidden(format string, args ...interface{}) *Error {
+	return newError(ErrForbidden, format, args)
+}
+
+func TooManyRequests(format string, args ...interface{}) *Error {
+	return newError(ErrTooManyRequests, format, args)
+}
+
+func Upstream(format string, args ...interface{}) *Error {
+	return newError(ErrUpstream, format, args)
+}
+
+// InvalidField reports a single rejected field.
+func InvalidField(field, message string) *Error {
+	return &Error{
+		Kind:    ErrValidation,
+		Message: "Invalid " + field,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
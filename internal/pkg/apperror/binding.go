@@ -0,0 +1,120 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Binding converts an error returned by gin's ShouldBind* methods into a
+// validation error listing every rejected field.
+func Binding(err error) *Error {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Message: fieldMessage(fe),
+			})
+		}
+
+		e := Validation("The request contains invalid fields").Wrap(err)
+		e.Fields = fields
+		return e
+	case errors.As(err, &typeError):
+		e := Validation("The request contains invalid fields").Wrap(err)
+		e.Fields = []FieldError{{
+			Field:   typeError.Field,
+			Message: "must be of type " + jsonType(typeError.Type),
+		}}
+		return e
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		return Validation("The request body is not valid JSON").Wrap(err)
+	case errors.Is(err, io.EOF):
+		return Validation("The request body is empty").Wrap(err)
+	default:
+		// Query and form values that do not parse, e.g. page=abc.
+		return Validation("Invalid request data: %s", err.Error()).Wrap(err)
+	}
+}
+
+// FieldName names struct fields in validation errors after their json or
+// form tag, so that clients see the names they sent. It is registered with
+// the validator through RegisterTagNameFunc.
+func FieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min", "gte":
+		return limit(fe, "at least")
+	case "max", "lte":
+		return limit(fe, "at most")
+	case "gt":
+		return limit(fe, "more than")
+	case "lt":
+		return limit(fe, "less than")
+	case "len":
+		return limit(fe, "exactly")
+	case "url":
+		return "must be a valid URL"
+	default:
+		return "failed the '" + fe.Tag() + "' check"
+	}
+}
+
+// limit phrases a size constraint: a length for strings, a number of items
+// for collections and a value otherwise.
+func limit(fe validator.FieldError, bound string) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return "must be " + bound + " " + fe.Param() + " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "must contain " + bound + " " + fe.Param() + " items"
+	default:
+		return "must be " + bound + " " + fe.Param()
+	}
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "string"
+	}
+}
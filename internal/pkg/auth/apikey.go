@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, so that leaked keys are easy to spot,
+// e.g. by secret scanners.
+const APIKeyPrefix = "mgk_"
+
+// ErrInvalidAPIKey is returned for API keys that do not exist, were revoked
+// or have expired.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyIdentity is who a valid API key acts as.
+type APIKeyIdentity struct {
+	KeyID  int
+	UserID int
+	Email  string
+	Role   string
+	Scopes []string
+}
+
+// APIKeyValidator looks up the key with prefix and checks keyHash against
+// it, returning ErrInvalidAPIKey when it does not match or cannot be used.
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, prefix, keyHash string) (*APIKeyIdentity, error)
+}
+
+var apiKeyValidator APIKeyValidator
+
+// UseAPIKeyValidator makes ValidateAPIKey accept the keys known to
+// validator. Without one every API key is rejected.
+func UseAPIKeyValidator(validator APIKeyValidator) {
+	apiKeyValidator = validator
+}
+
+// NewAPIKey returns a random API key, formatted as mgk_<prefix>_<secret>,
+// together with its prefix and the hash that is stored in place of it.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(buf)
+
+	secret, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + prefix + "_" + secret
+
+	return key, prefix, HashOpaqueToken(key), nil
+}
+
+// ValidateAPIKey returns who key acts as.
+func ValidateAPIKey(ctx context.Context, key string) (*APIKeyIdentity, error) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok || apiKeyValidator == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return apiKeyValidator.ValidateAPIKey(ctx, prefix, HashOpaqueToken(key))
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"Movies-Go/internal/pkg/config"
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -12,20 +13,41 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token expired")
+	ErrRevokedToken = errors.New("token revoked")
 )
 
+// SessionChecker reports whether the session an access token was issued for
+// is still active.
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID int) (bool, error)
+}
+
+var sessionChecker SessionChecker
+
+// UseSessionChecker makes ValidateToken reject access tokens whose session
+// has been revoked or has expired.
+func UseSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
 type JWTClaims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
+	// SessionID ties the access token to the session its refresh token
+	// renews, so logging out invalidates it immediately.
+	SessionID int `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+func GenerateToken(userID int, email, role string, sessionID int) (string, error) {
+	expirationTime := time.Now().Add(config.GetConf().AccessTokenDuration())
 
 	claims := &JWTClaims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -45,7 +67,7 @@ func GenerateToken(userID int, email string) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateToken(tokenString string) (*JWTClaims, error) {
+func ValidateToken(ctx context.Context, tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -66,7 +88,80 @@ func ValidateToken(tokenString string) (*JWTClaims, error) {
 	}
 
 	claims, ok := token.Claims.(*JWTClaims)
-	if !ok {
+	if !ok || claims.SessionID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	if sessionChecker != nil {
+		active, err := sessionChecker.IsSessionActive(ctx, claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrRevokedToken
+		}
+	}
+
+	return claims, nil
+}
+
+// ChallengeDuration is how long the second step of a login may take.
+const ChallengeDuration = 5 * time.Minute
+
+// challengeAudience keeps challenge tokens and access tokens apart. Access
+// tokens are also told apart by their session id, which challenges lack.
+const challengeAudience = "movies-go-2fa"
+
+// ChallengeClaims identify a user who passed the password step of a login
+// and still has to pass the two-factor step.
+type ChallengeClaims struct {
+	UserID int `json:"user_id"`
+	// Setup is set when the user has to enroll in two-factor
+	// authentication before logging in.
+	Setup bool `json:"setup,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateChallengeToken returns a token for the two-factor step of a
+// login of the user, valid for ChallengeDuration.
+func GenerateChallengeToken(userID int, setup bool) (string, error) {
+	now := time.Now()
+
+	claims := &ChallengeClaims{
+		UserID: userID,
+		Setup:  setup,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "movies-go-api",
+			Subject:   fmt.Sprintf("%d", userID),
+			Audience:  jwt.ClaimStrings{challengeAudience},
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.GetConf().JWTSecret))
+}
+
+// ValidateChallengeToken checks a token from GenerateChallengeToken.
+func ValidateChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(config.GetConf().JWTSecret), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || !token.Valid || !claims.VerifyAudience(challengeAudience, true) || claims.UserID == 0 {
 		return nil, ErrInvalidToken
 	}
 
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"Movies-Go/internal/pkg/config"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// FlowDuration is how long a sign-in at an identity provider may take.
+const FlowDuration = 10 * time.Minute
+
+// flowAudience keeps flow tokens apart from access and challenge tokens.
+const flowAudience = "movies-go-oidc"
+
+// FlowClaims carry what the service needs to finish a sign-in at an
+// identity provider. The client keeps the flow token while the user signs
+// in and hands it back with the authorization code, so that a code can only
+// be redeemed by the client that started the sign-in.
+type FlowClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	// Verifier is the PKCE code verifier. It is never sent to the
+	// provider before the code is redeemed.
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// GenerateFlowToken returns a token for a sign-in at provider, valid for
+// FlowDuration.
+func GenerateFlowToken(provider, state, nonce, verifier string) (string, error) {
+	now := time.Now()
+
+	claims := &FlowClaims{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(FlowDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "movies-go-api",
+			Audience:  jwt.ClaimStrings{flowAudience},
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.GetConf().JWTSecret))
+}
+
+// ValidateFlowToken checks a token from GenerateFlowToken.
+func ValidateFlowToken(tokenString string) (*FlowClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &FlowClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(config.GetConf().JWTSecret), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*FlowClaims)
+	if !ok || !token.Valid || !claims.VerifyAudience(flowAudience, true) || claims.Provider == "" || claims.Verifier == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewRefreshToken returns a random opaque refresh token together with the
+// hash that is stored in place of it.
+func NewRefreshToken() (string, string, error) {
+	return NewOpaqueToken()
+}
+
+// HashRefreshToken returns the hex-encoded SHA-256 of a refresh token.
+func HashRefreshToken(token string) string {
+	return HashOpaqueToken(token)
+}
+
+// NewOpaqueToken returns a random URL-safe token together with the hash
+// that is stored in place of it, for tokens that are looked up rather than
+// verified like a JWT.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex-encoded SHA-256 of an opaque token.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,7 +3,11 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 	"sync"
+	"time"
+
+	"Movies-Go/internal/pkg/ratelimit"
 
 	"gopkg.in/yaml.v2"
 )
@@ -16,6 +20,103 @@ type Config struct {
 	DBPassword string `yaml:"db_password"`
 	Port       string `yaml:"port"`
 	JWTSecret  string `yaml:"jwt_secret"`
+
+	// Token lifetimes as Go durations, e.g. "15m" or "720h".
+	AccessTokenTTL  string `yaml:"access_token_ttl"`
+	RefreshTokenTTL string `yaml:"refresh_token_ttl"`
+
+	// RecommendationsInterval is how often similar movies and personal
+	// recommendations are recomputed, e.g. "1h".
+	RecommendationsInterval string `yaml:"recommendations_interval"`
+
+	// HTTP server timeouts as Go durations. ShutdownTimeout bounds how long
+	// in-flight requests may take to finish when the service stops.
+	ReadTimeout     string `yaml:"read_timeout"`
+	WriteTimeout    string `yaml:"write_timeout"`
+	IdleTimeout     string `yaml:"idle_timeout"`
+	ShutdownTimeout string `yaml:"shutdown_timeout"`
+
+	// TracingExporter is where OpenTelemetry spans go: "otlp" sends them
+	// over OTLP/HTTP to TracingEndpoint, "stdout" prints them, and "" or
+	// "none" disables tracing.
+	TracingExporter string `yaml:"tracing_exporter"`
+	// TracingEndpoint is the OTLP/HTTP collector URL, e.g.
+	// "http://localhost:4318". The OTEL_EXPORTER_OTLP_* variables apply when
+	// it is empty.
+	TracingEndpoint string `yaml:"tracing_endpoint"`
+	// TracingSampleRatio is the share of new traces recorded, from 0 to 1.
+	// Requests that are part of a sampled trace are always recorded.
+	TracingSampleRatio *float64 `yaml:"tracing_sample_ratio"`
+	// TracingQueryText adds the SQL of queries to their spans. The SQL
+	// contains the query arguments, so it is off by default.
+	TracingQueryText bool `yaml:"tracing_query_text"`
+
+	// Rate limits as "requests/period", e.g. "300/1m", or "off". RateLimit
+	// applies per client address to the whole API, AuthRateLimit per client
+	// address to the login, registration and refresh endpoints, and
+	// LoginRateLimit per account to logins.
+	RateLimit      string `yaml:"rate_limit"`
+	AuthRateLimit  string `yaml:"auth_rate_limit"`
+	LoginRateLimit string `yaml:"login_rate_limit"`
+
+	// LockoutThreshold consecutive failed logins lock an account for
+	// LockoutDuration, doubling with each further failure up to
+	// LockoutMaxDuration.
+	LockoutThreshold   int    `yaml:"lockout_threshold"`
+	LockoutDuration    string `yaml:"lockout_duration"`
+	LockoutMaxDuration string `yaml:"lockout_max_duration"`
+
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies
+	// whose X-Forwarded-For header gives the client address. Without any,
+	// the address of the connection is used.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	// AppURL is the address of the web app that links in emails point to,
+	// e.g. "https://movies.example.com".
+	AppURL string `yaml:"app_url"`
+	// Lifetimes of the tokens mailed to users, as Go durations.
+	EmailVerificationTTL string `yaml:"email_verification_ttl"`
+	PasswordResetTTL     string `yaml:"password_reset_ttl"`
+	// AllowUnverifiedLogin lets users log in before verifying their email
+	// address, and logs them in right after registering.
+	AllowUnverifiedLogin bool `yaml:"allow_unverified_login"`
+
+	// MailDriver is how emails are sent: "smtp", "file" to append them to
+	// MailFile, or "log" (the default) to print them.
+	MailDriver   string `yaml:"mail_driver"`
+	MailFrom     string `yaml:"mail_from"`
+	MailFile     string `yaml:"mail_file"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+
+	// TOTPIssuer names the service in authenticator apps.
+	TOTPIssuer string `yaml:"totp_issuer"`
+
+	// OIDCProviders are the OpenID Connect identity providers users can
+	// sign in with.
+	OIDCProviders []OIDCProvider `yaml:"oidc_providers"`
+}
+
+// OIDCProvider is the registration of the service with an OpenID Connect
+// identity provider.
+type OIDCProvider struct {
+	// Name identifies the provider in URLs, e.g. /auth/oidc/<name>/login.
+	Name         string   `yaml:"name"`
+	DisplayName  string   `yaml:"display_name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+
+	// AllowSignup creates accounts for users of the provider who have
+	// none, optionally only for email addresses in AllowedDomains, with
+	// DefaultRole.
+	AllowSignup    bool     `yaml:"allow_signup"`
+	AllowedDomains []string `yaml:"allowed_domains"`
+	DefaultRole    string   `yaml:"default_role"`
 }
 
 var (
@@ -50,8 +151,291 @@ func GetConf() *Config {
 			log.Fatalf("Missing required JWT secret configuration")
 		}
 
+		if _, err := time.ParseDuration(conf.AccessTokenTTL); conf.AccessTokenTTL != "" && err != nil {
+			log.Fatalf("Invalid access_token_ttl: %v", err)
+		}
+
+		if _, err := time.ParseDuration(conf.RefreshTokenTTL); conf.RefreshTokenTTL != "" && err != nil {
+			log.Fatalf("Invalid refresh_token_ttl: %v", err)
+		}
+
+		if _, err := time.ParseDuration(conf.RecommendationsInterval); conf.RecommendationsInterval != "" && err != nil {
+			log.Fatalf("Invalid recommendations_interval: %v", err)
+		}
+
+		for name, value := range map[string]string{
+			"read_timeout":           conf.ReadTimeout,
+			"write_timeout":          conf.WriteTimeout,
+			"idle_timeout":           conf.IdleTimeout,
+			"shutdown_timeout":       conf.ShutdownTimeout,
+			"lockout_duration":       conf.LockoutDuration,
+			"lockout_max_duration":   conf.LockoutMaxDuration,
+			"email_verification_ttl": conf.EmailVerificationTTL,
+			"password_reset_ttl":     conf.PasswordResetTTL,
+		} {
+			if _, err := time.ParseDuration(value); value != "" && err != nil {
+				log.Fatalf("Invalid %s: %v", name, err)
+			}
+		}
+
+		switch conf.TracingExporter {
+		case "", "none", "stdout", "otlp":
+		default:
+			log.Fatalf("Invalid tracing_exporter %q: must be otlp, stdout or none", conf.TracingExporter)
+		}
+
+		if ratio := conf.TracingSampleRatio; ratio != nil && (*ratio < 0 || *ratio > 1) {
+			log.Fatalf("Invalid tracing_sample_ratio %v: must be between 0 and 1", *ratio)
+		}
+
+		for name, value := range map[string]string{
+			"rate_limit":       conf.RateLimit,
+			"auth_rate_limit":  conf.AuthRateLimit,
+			"login_rate_limit": conf.LoginRateLimit,
+		} {
+			if _, err := ratelimit.ParseLimit(value); value != "" && err != nil {
+				log.Fatalf("Invalid %s: %v", name, err)
+			}
+		}
+
+		if conf.LockoutThreshold < 0 {
+			log.Fatalf("Invalid lockout_threshold: must not be negative")
+		}
+
+		names := map[string]bool{}
+		for i, provider := range conf.OIDCProviders {
+			switch {
+			case !validProviderName(provider.Name):
+				log.Fatalf("Invalid oidc_providers[%d].name %q: must be lowercase letters, digits and dashes", i, provider.Name)
+			case names[provider.Name]:
+				log.Fatalf("Invalid oidc_providers: %q is configured twice", provider.Name)
+			case provider.Issuer == "" || provider.ClientID == "":
+				log.Fatalf("Invalid oidc_providers %q: issuer and client_id are required", provider.Name)
+			case provider.DefaultRole != "" && provider.DefaultRole != "admin" && provider.DefaultRole != "editor" && provider.DefaultRole != "viewer":
+				log.Fatalf("Invalid oidc_providers %q: default_role must be admin, editor or viewer", provider.Name)
+			}
+			names[provider.Name] = true
+		}
+
 		log.Printf("Configuration loaded successfully from %s", configPath)
 	})
 
 	return conf
 }
+
+// AccessTokenDuration is how long an access token stays valid, 15 minutes
+// unless configured.
+func (c *Config) AccessTokenDuration() time.Duration {
+	return durationOr(c.AccessTokenTTL, 15*time.Minute)
+}
+
+// RefreshTokenDuration is how long a session can be renewed without logging
+// in again, 30 days unless configured.
+func (c *Config) RefreshTokenDuration() time.Duration {
+	return durationOr(c.RefreshTokenTTL, 30*24*time.Hour)
+}
+
+// RecommendationsRefreshInterval is how often recommendations are
+// recomputed, hourly unless configured.
+func (c *Config) RecommendationsRefreshInterval() time.Duration {
+	return durationOr(c.RecommendationsInterval, time.Hour)
+}
+
+// ServerReadTimeout bounds reading a request, body included, 15 seconds
+// unless configured.
+func (c *Config) ServerReadTimeout() time.Duration {
+	return durationOr(c.ReadTimeout, 15*time.Second)
+}
+
+// ServerWriteTimeout bounds writing a response, 30 seconds unless
+// configured. Movie exports are streamed and not subject to it.
+func (c *Config) ServerWriteTimeout() time.Duration {
+	return durationOr(c.WriteTimeout, 30*time.Second)
+}
+
+// ServerIdleTimeout is how long a keep-alive connection waits for the next
+// request, 2 minutes unless configured.
+func (c *Config) ServerIdleTimeout() time.Duration {
+	return durationOr(c.IdleTimeout, 2*time.Minute)
+}
+
+// ServerShutdownTimeout is how long the service waits for in-flight
+// requests when it stops, 20 seconds unless configured.
+func (c *Config) ServerShutdownTimeout() time.Duration {
+	return durationOr(c.ShutdownTimeout, 20*time.Second)
+}
+
+// TracingEnabled reports whether spans are exported.
+func (c *Config) TracingEnabled() bool {
+	return c.TracingExporter != "" && c.TracingExporter != "none"
+}
+
+// TracingSampling is the share of new traces recorded, all of them unless
+// configured.
+func (c *Config) TracingSampling() float64 {
+	if c.TracingSampleRatio == nil {
+		return 1
+	}
+
+	return *c.TracingSampleRatio
+}
+
+// APIRateLimit is the limit per client address on the whole API, 300
+// requests a minute unless configured.
+func (c *Config) APIRateLimit() ratelimit.Limit {
+	return limitOr(c.RateLimit, ratelimit.Limit{Requests: 300, Period: time.Minute})
+}
+
+// AuthEndpointsRateLimit is the limit per client address on logins,
+// registrations and refreshes, 20 a minute unless configured.
+func (c *Config) AuthEndpointsRateLimit() ratelimit.Limit {
+	return limitOr(c.AuthRateLimit, ratelimit.Limit{Requests: 20, Period: time.Minute})
+}
+
+// AccountLoginRateLimit is the limit on logins per account, 10 a minute
+// unless configured.
+func (c *Config) AccountLoginRateLimit() ratelimit.Limit {
+	return limitOr(c.LoginRateLimit, ratelimit.Limit{Requests: 10, Period: time.Minute})
+}
+
+// LoginLockout is the lockout policy for failed logins: 5 failures lock an
+// account for 15 minutes, up to a day, unless configured. A threshold of 0
+// disables lockouts.
+func (c *Config) LoginLockout() (threshold int, duration, max time.Duration) {
+	threshold = c.LockoutThreshold
+	if threshold == 0 && c.LockoutDuration == "" {
+		threshold = 5
+	}
+
+	return threshold, durationOr(c.LockoutDuration, 15*time.Minute), durationOr(c.LockoutMaxDuration, 24*time.Hour)
+}
+
+// EmailVerificationDuration is how long an email verification link works,
+// a day unless configured.
+func (c *Config) EmailVerificationDuration() time.Duration {
+	return durationOr(c.EmailVerificationTTL, 24*time.Hour)
+}
+
+// PasswordResetDuration is how long a password reset link works, an hour
+// unless configured.
+func (c *Config) PasswordResetDuration() time.Duration {
+	return durationOr(c.PasswordResetTTL, time.Hour)
+}
+
+// MailSender is the sender address of emails, no-reply@localhost unless
+// configured.
+func (c *Config) MailSender() string {
+	if c.MailFrom == "" {
+		return "Movies-Go <no-reply@localhost>"
+	}
+
+	return c.MailFrom
+}
+
+// AppBaseURL is the address links in emails point to, without a trailing
+// slash, http://localhost:<port> unless configured.
+func (c *Config) AppBaseURL() string {
+	if c.AppURL == "" {
+		return "http://localhost:" + c.Port
+	}
+
+	return strings.TrimRight(c.AppURL, "/")
+}
+
+// TwoFactorIssuer is the name authenticator apps show for accounts of the
+// service, Movies-Go unless configured.
+func (c *Config) TwoFactorIssuer() string {
+	if c.TOTPIssuer == "" {
+		return "Movies-Go"
+	}
+
+	return c.TOTPIssuer
+}
+
+// OIDCProvider returns the identity provider called name.
+func (c *Config) OIDCProvider(name string) (OIDCProvider, bool) {
+	for _, provider := range c.OIDCProviders {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+
+	return OIDCProvider{}, false
+}
+
+// OIDCRedirectURL is where the provider sends users back to, the web app
+// page /oidc/<name>/callback under app_url unless configured.
+func (c *Config) OIDCRedirectURL(provider OIDCProvider) string {
+	if provider.RedirectURL == "" {
+		return c.AppBaseURL() + "/oidc/" + provider.Name + "/callback"
+	}
+
+	return provider.RedirectURL
+}
+
+// Role is the role of accounts created at sign-up through the provider,
+// viewer unless configured.
+func (p OIDCProvider) Role() string {
+	if p.DefaultRole == "" {
+		return "viewer"
+	}
+
+	return p.DefaultRole
+}
+
+// AllowsDomain reports whether accounts may be created for email, which
+// is any address unless AllowedDomains is set.
+func (p OIDCProvider) AllowsDomain(email string) bool {
+	if len(p.AllowedDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	for _, domain := range p.AllowedDomains {
+		if strings.EqualFold(email[at+1:], strings.TrimPrefix(domain, "@")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validProviderName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func limitOr(value string, fallback ratelimit.Limit) ratelimit.Limit {
+	if value == "" {
+		return fallback
+	}
+
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		return fallback
+	}
+
+	return limit
+}
+
+func durationOr(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+
+	return d
+}
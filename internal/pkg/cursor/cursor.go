@@ -0,0 +1,100 @@
+package cursor
+
+import (
+	"Movies-Go/internal/pkg/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a row in a sorted result set. It is handed to clients as an
+// opaque, signed token so they cannot forge positions.
+type Cursor struct {
+	// Sort is the normalized sort order the cursor was issued for.
+	Sort string `json:"s"`
+	// Values holds the sort key values of the boundary row, in sort order.
+	Values []json.RawMessage `json:"v"`
+	// Backward selects the rows before the boundary row instead of after it.
+	Backward bool `json:"b,omitempty"`
+}
+
+// Page carries the tokens for the pages next to the one being returned.
+type Page struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// New builds a cursor positioned on a row whose sort key values are values.
+func New(sort string, values []interface{}, backward bool) (Cursor, error) {
+	c := Cursor{Sort: sort, Backward: backward}
+
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return Cursor{}, err
+		}
+		c.Values = append(c.Values, raw)
+	}
+
+	return c, nil
+}
+
+// Scan decodes the boundary values into dest, which must hold one pointer
+// per sort key.
+func (c Cursor) Scan(dest ...interface{}) error {
+	if len(dest) != len(c.Values) {
+		return ErrInvalidCursor
+	}
+
+	for i, value := range c.Values {
+		if err := json.Unmarshal(value, dest[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+
+	return nil
+}
+
+// Encode serializes and signs the cursor.
+func Encode(c Cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + sign(encoded), nil
+}
+
+// Decode verifies the token's signature and returns the cursor it carries.
+func Decode(token string) (Cursor, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func sign(encoded string) string {
+	mac := hmac.New(sha256.New, []byte("cursor:"+config.GetConf().JWTSecret))
+	mac.Write([]byte(encoded))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package cursor
+
+import "strings"
+
+// Key is one column of a keyset sort order.
+type Key struct {
+	Column string
+	Desc   bool
+}
+
+// Spec renders keys in the "-rating,id" form stored in cursors.
+func Spec(names []string, keys []Key) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = names[i]
+		if key.Desc {
+			parts[i] = "-" + parts[i]
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// OrderBy returns the ORDER BY expression for keys. Walking backward flips
+// every direction; the caller reverses the fetched rows afterwards.
+func OrderBy(keys []Key, backward bool) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		direction := "ASC"
+		if key.Desc != backward {
+			direction = "DESC"
+		}
+		parts[i] = key.Column + " " + direction
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Where returns a condition selecting the rows strictly after (or, walking
+// backward, before) the row whose key values are values. Mixed sort
+// directions are expanded to
+//
+//	(k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+//
+// with the comparison flipped for descending keys.
+func Where(keys []Key, values []interface{}, backward bool) (string, []interface{}) {
+	var groups []string
+	var args []interface{}
+
+	for i, key := range keys {
+		var conditions []string
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if key.Desc != backward {
+			op = "<"
+		}
+		conditions = append(conditions, key.Column+" "+op+" ?")
+		args = append(args, values[i])
+
+		groups = append(groups, "("+strings.Join(conditions, " AND ")+")")
+	}
+
+	return "(" + strings.Join(groups, " OR ") + ")", args
+}
@@ -0,0 +1,90 @@
+package cursor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	keys := []Key{{Column: "m.rating", Desc: true}, {Column: "m.title"}, {Column: "m.id"}}
+
+	if got := Spec([]string{"rating", "title", "id"}, keys); got != "-rating,title,id" {
+		t.Errorf("Spec() = %q, want -rating,title,id", got)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	mixed := []Key{{Column: "rating", Desc: true}, {Column: "title"}, {Column: "id"}}
+	values := []interface{}{8.5, "Heat", 7}
+
+	tests := []struct {
+		name      string
+		keys      []Key
+		values    []interface{}
+		backward  bool
+		wantOrder string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "single key forward",
+			keys:      []Key{{Column: "id"}},
+			values:    []interface{}{7},
+			wantOrder: "id ASC",
+			wantWhere: "((id > ?))",
+			wantArgs:  []interface{}{7},
+		},
+		{
+			name:      "single key backward",
+			keys:      []Key{{Column: "id"}},
+			values:    []interface{}{7},
+			backward:  true,
+			wantOrder: "id DESC",
+			wantWhere: "((id < ?))",
+			wantArgs:  []interface{}{7},
+		},
+		{
+			name:      "descending key forward",
+			keys:      []Key{{Column: "created_at", Desc: true}},
+			values:    []interface{}{"2024-01-01"},
+			wantOrder: "created_at DESC",
+			wantWhere: "((created_at < ?))",
+			wantArgs:  []interface{}{"2024-01-01"},
+		},
+		{
+			name:      "mixed keys forward",
+			keys:      mixed,
+			values:    values,
+			wantOrder: "rating DESC, title ASC, id ASC",
+			wantWhere: "((rating < ?) OR (rating = ? AND title > ?) OR (rating = ? AND title = ? AND id > ?))",
+			wantArgs:  []interface{}{8.5, 8.5, "Heat", 8.5, "Heat", 7},
+		},
+		{
+			// Backward walks the same order in reverse, from the boundary
+			// row towards the start; the caller reverses the rows.
+			name:      "mixed keys backward",
+			keys:      mixed,
+			values:    values,
+			backward:  true,
+			wantOrder: "rating ASC, title DESC, id DESC",
+			wantWhere: "((rating > ?) OR (rating = ? AND title < ?) OR (rating = ? AND title = ? AND id < ?))",
+			wantArgs:  []interface{}{8.5, 8.5, "Heat", 8.5, "Heat", 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrderBy(tt.keys, tt.backward); got != tt.wantOrder {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.wantOrder)
+			}
+
+			where, args := Where(tt.keys, tt.values, tt.backward)
+			if where != tt.wantWhere {
+				t.Errorf("Where() = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Where() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Package dbquery names bun queries for metrics and traces.
+package dbquery
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun"
+)
+
+// NoTable is the table of queries that have none, e.g. raw SQL.
+const NoTable = "none"
+
+// Describe returns the operation and main table of a query. Raw queries,
+// which bun reports as SELECT without a table, are named after the first
+// keyword of their SQL, e.g. UPDATE or SAVEPOINT.
+func Describe(event *bun.QueryEvent) (operation, table string) {
+	if event.IQuery != nil {
+		if _, raw := event.IQuery.(*bun.RawQuery); !raw {
+			table = strings.Trim(event.IQuery.GetTableName(), `"`)
+			operation = event.IQuery.Operation()
+		}
+	}
+
+	if operation == "" {
+		operation = firstKeyword(event.Query)
+	}
+	if table == "" {
+		table = NoTable
+	}
+
+	return operation, table
+}
+
+func firstKeyword(query string) string {
+	query = strings.TrimLeftFunc(query, unicode.IsSpace)
+	end := strings.IndexFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end >= 0 {
+		query = query[:end]
+	}
+	if query == "" || len(query) > 16 {
+		return "OTHER"
+	}
+
+	return strings.ToUpper(query)
+}
@@ -0,0 +1,109 @@
+// Package healthcheck runs the dependency checks behind the readiness probe
+// and reports the outcome and latency of each.
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// DefaultTimeout bounds each check unless the Checker is given another.
+const DefaultTimeout = 2 * time.Second
+
+// Check returns an error when the dependency is not usable.
+type Check func(ctx context.Context) error
+
+// Note is returned by a check that passed but has something worth
+// reporting; it ends up in the Detail of the result.
+type Note string
+
+func (n Note) Error() string {
+	return string(n)
+}
+
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+type Report struct {
+	// Status is StatusOK when every check passed.
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  []Check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Run runs every check concurrently, each with the checker's timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check, c.timeout)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(results))}
+	for i, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusFailed
+		}
+		report.Checks[c.names[i]] = result
+	}
+
+	return report
+}
+
+func run(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := Result{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	var note Note
+	switch {
+	case errors.As(err, &note):
+		result.Detail = string(note)
+	case err != nil:
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileMailer appends messages to a file, readable as an mbox, or prints
+// them to the log when it has no path. Nothing is delivered.
+type FileMailer struct {
+	from string
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(from, path string) *FileMailer {
+	return &FileMailer{
+		from: from,
+		path: path,
+	}
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+
+	if m.path == "" {
+		log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	data := format(m.from, msg, now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	// The mbox separator line, so that mail clients can open the file.
+	if _, err := f.WriteString("From movies-api " + now.UTC().Format(time.ANSIC) + "\r\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,89 @@
+// Package mailer sends the emails of the account flows. SMTPMailer delivers
+// them through a mail server; FileMailer appends them to a file, or the
+// log, so that the flows can be followed locally without one.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Options struct {
+	Driver string
+	// From is the sender address, e.g. "Movies <no-reply@example.com>".
+	From string
+	// Path is the file FileMailer appends to.
+	Path string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// New returns the mailer of opts.Driver, DriverLog when empty.
+func New(opts Options) (Mailer, error) {
+	if _, err := mail.ParseAddress(opts.From); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", opts.From, err)
+	}
+
+	switch opts.Driver {
+	case "", DriverLog:
+		return NewFileMailer(opts.From, ""), nil
+	case DriverFile:
+		if opts.Path == "" {
+			return nil, fmt.Errorf("the %s mail driver needs a path", DriverFile)
+		}
+		return NewFileMailer(opts.From, opts.Path), nil
+	case DriverSMTP:
+		if opts.SMTPHost == "" {
+			return nil, fmt.Errorf("the %s mail driver needs a host", DriverSMTP)
+		}
+		return NewSMTPMailer(opts.From, opts.SMTPHost, opts.SMTPPort, opts.SMTPUsername, opts.SMTPPassword), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", opts.Driver)
+	}
+}
+
+// format renders msg as an RFC 5322 message with a UTF-8 body, sent as is
+// so that links stay readable in files.
+func format(from string, msg Message, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
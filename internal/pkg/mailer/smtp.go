@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// smtpTimeout bounds a delivery when the context has no deadline.
+const smtpTimeout = 10 * time.Second
+
+// SMTPMailer delivers messages through an SMTP server, upgrading the
+// connection with STARTTLS when the server offers it. Credentials are only
+// sent over TLS or to localhost.
+type SMTPMailer struct {
+	from string
+	host string
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer returns a mailer for the server at host:port, port 587
+// when empty. Without a username no authentication is attempted.
+func NewSMTPMailer(from, host, port, username, password string) *SMTPMailer {
+	if port == "" {
+		port = "587"
+	}
+
+	m := &SMTPMailer{
+		from: from,
+		host: host,
+		addr: net.JoinHostPort(host, port),
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return err
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	data := format(m.from, msg, time.Now())
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(recipient.Address); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
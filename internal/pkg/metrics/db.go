@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"Movies-Go/internal/pkg/dbquery"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/uptrace/bun"
+)
+
+var (
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table"})
+
+	dbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Failed database queries by operation and table. Queries finding no rows are not errors.",
+	}, []string{"operation", "table"})
+)
+
+func init() {
+	Registry.MustRegister(dbQueryDuration, dbQueryErrors)
+}
+
+// InstrumentDB records the duration and errors of every query run through
+// db, and exports the statistics of its connection pool.
+func InstrumentDB(db *bun.DB, name string) {
+	db.AddQueryHook(queryHook{})
+	Registry.MustRegister(collectors.NewDBStatsCollector(db.DB, name))
+}
+
+type queryHook struct{}
+
+func (queryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (queryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	operation, table := dbquery.Describe(event)
+
+	dbQueryDuration.WithLabelValues(operation, table).Observe(time.Since(event.StartTime).Seconds())
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		dbQueryErrors.WithLabelValues(operation, table).Inc()
+	}
+}
@@ -1,69 +1,136 @@
 package middleware
 
 import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
 	"Movies-Go/internal/pkg/auth"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 )
 
+// AuthMiddleware authenticates the request with an access token sent as
+// "Authorization: Bearer <token>", or with an API key sent as
+// "Authorization: ApiKey <key>" or "X-API-Key: <key>". Either way the gin
+// context gets the user_id, email and role of the user. API keys also set
+// api_key_id and are limited to the methods their scopes allow.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
+		apiKey := c.GetHeader("X-API-Key")
+		if authHeader == "" && apiKey == "" {
+			c.Error(apperror.Unauthorized("Authorization header is required"))
 			c.Abort()
 			return
 		}
 
+		if apiKey != "" {
+			authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header format must be Bearer {token}",
-			})
+		switch {
+		case len(parts) == 2 && parts[0] == "Bearer":
+			authenticateToken(c, parts[1])
+		case len(parts) == 2 && parts[0] == "ApiKey":
+			authenticateAPIKey(c, parts[1])
+		default:
+			c.Error(apperror.Unauthorized("Authorization header format must be Bearer {token} or ApiKey {key}"))
 			c.Abort()
-			return
 		}
+	}
+}
 
-		tokenString := parts[1]
+func authenticateToken(c *gin.Context, tokenString string) {
+	claims, err := auth.ValidateToken(c.Request.Context(), tokenString)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrExpiredToken):
+			err = apperror.Unauthorized("Token has expired")
+		case errors.Is(err, auth.ErrRevokedToken):
+			err = apperror.Unauthorized("Token has been revoked")
+		case errors.Is(err, auth.ErrInvalidToken), isTokenError(err):
+			err = apperror.Unauthorized("Invalid token").Wrap(err)
+		}
 
-		claims, err := auth.ValidateToken(tokenString)
-		if err != nil {
-			var statusCode int
-			var errorMessage string
+		c.Error(err)
+		c.Abort()
+		return
+	}
 
-			if err == auth.ErrExpiredToken {
-				statusCode = http.StatusUnauthorized
-				errorMessage = "Token has expired"
-			} else {
-				statusCode = http.StatusUnauthorized
-				errorMessage = "Invalid token"
-			}
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("session_id", claims.SessionID)
+
+	c.Next()
+}
+
+func authenticateAPIKey(c *gin.Context, key string) {
+	identity, err := auth.ValidateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			err = apperror.Unauthorized("Invalid, expired or revoked API key")
+		}
+
+		c.Error(err)
+		c.Abort()
+		return
+	}
 
-			c.JSON(statusCode, gin.H{
-				"error": errorMessage,
-			})
+	scope := entity.ScopeWrite
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		scope = entity.ScopeRead
+	}
+	if !hasScope(identity.Scopes, scope) {
+		c.Error(apperror.Forbidden("API key lacks the %s scope", scope))
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", identity.UserID)
+	c.Set("email", identity.Email)
+	c.Set("role", identity.Role)
+	c.Set("api_key_id", identity.KeyID)
+
+	c.Next()
+}
+
+// SessionOnly refuses requests authenticated with an API key, for routes
+// that manage credentials, so that a leaked key cannot be used to mint
+// others or to take over the account. It goes after AuthMiddleware.
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); ok {
+			c.Error(apperror.Forbidden("This route cannot be used with an API key, log in instead"))
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-
 		c.Next()
 	}
 }
 
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
 func RoleMiddleware(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User role not found",
-			})
+			c.Error(apperror.Unauthorized("User role not found"))
 			c.Abort()
 			return
 		}
@@ -77,9 +144,7 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		}
 
 		if !hasRole {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Unauthorized access: insufficient permissions",
-			})
+			c.Error(apperror.Forbidden("Unauthorized access: insufficient permissions"))
 			c.Abort()
 			return
 		}
@@ -87,3 +152,10 @@ func RoleMiddleware(roles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// isTokenError reports whether err comes from parsing or verifying the JWT
+// rather than from looking up its session.
+func isTokenError(err error) bool {
+	var validationErr *jwt.ValidationError
+	return errors.As(err, &validationErr)
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"Movies-Go/internal/pkg/apperror"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses.
+const ProblemContentType = "application/problem+json"
+
+// ErrorHandler turns the last error a handler attached with c.Error into an
+// RFC 7807 problem response. Errors from the apperror package keep their
+// message and status, anything else is logged and reported as a 500
+// without details so that database errors never reach the client.
+func ErrorHandler() gin.HandlerFunc {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(apperror.FieldName)
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status, problem := Problem(c, err)
+		if status >= http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+
+		body, err := json.Marshal(problem)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Data(status, ProblemContentType, body)
+	}
+}
+
+// Problem builds the problem document for err.
+func Problem(c *gin.Context, err error) (int, gin.H) {
+	status := http.StatusInternalServerError
+	detail := "An unexpected error occurred"
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		status = statusOf(appErr.Kind)
+		detail = appErr.Message
+	} else if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusNotFound
+		detail = "The requested resource was not found"
+	}
+
+	problem := gin.H{
+		"type":     "about:blank",
+		"title":    http.StatusText(status),
+		"status":   status,
+		"detail":   detail,
+		"instance": c.Request.URL.Path,
+	}
+
+	if appErr != nil {
+		if len(appErr.Fields) > 0 {
+			problem["errors"] = appErr.Fields
+		}
+		for key, value := range appErr.Extra {
+			problem[key] = value
+		}
+	}
+
+	return status, problem
+}
+
+func statusOf(kind error) int {
+	switch kind {
+	case apperror.ErrNotFound:
+		return http.StatusNotFound
+	case apperror.ErrConflict:
+		return http.StatusConflict
+	case apperror.ErrValidation:
+		return http.StatusBadRequest
+	case apperror.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case apperror.ErrForbidden:
+		return http.StatusForbidden
+	case apperror.ErrTooManyRequests:
+		return http.StatusTooManyRequests
+	case apperror.ErrUpstream:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,238 @@
+// Package movieexport writes movies as CSV, NDJSON or Excel workbooks for
+// GET /movies/export.
+package movieexport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/xlsx"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+// bom is the UTF-8 byte order mark CSV files start with.
+const bom = "\ufeff"
+
+// Formats lists the supported export formats.
+var Formats = []string{string(FormatCSV), string(FormatNDJSON), string(FormatXLSX)}
+
+// Columns are the fields of an exported movie, in order. The CSV and
+// workbook header rows use these names; genres are separated by "|".
+var Columns = []string{
+	"id", "external_id", "title", "director", "year", "plot", "genres", "genre_ids",
+	"rating", "vote_count", "weighted_rating", "created_at", "updated_at",
+}
+
+// ContentType returns the media type of files in format f.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return xlsx.ContentType
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Writer writes batches of movies. Close must be called after the last
+// batch to complete the file.
+type Writer interface {
+	Write(movies []*entity.Movie) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format f that writes to w.
+func NewWriter(w io.Writer, f Format) (Writer, error) {
+	switch f {
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatXLSX:
+		sheet, err := xlsx.NewWriter(w, "Movies")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxWriter{sheet: sheet}, sheet.WriteHeader(Columns)
+	default:
+		// The byte order mark makes Excel read the file as UTF-8.
+		if _, err := io.WriteString(w, bom); err != nil {
+			return nil, err
+		}
+		writer := csv.NewWriter(w)
+		return &csvWriter{writer: writer}, writer.Write(Columns)
+	}
+}
+
+// record is an exported movie.
+type record struct {
+	ID             int        `json:"id"`
+	ExternalID     *string    `json:"external_id"`
+	Title          string     `json:"title"`
+	Director       string     `json:"director"`
+	Year           int        `json:"year"`
+	Plot           string     `json:"plot"`
+	Genres         []string   `json:"genres"`
+	GenreIDs       []int      `json:"genre_ids"`
+	Rating         float64    `json:"rating"`
+	VoteCount      int        `json:"vote_count"`
+	WeightedRating float64    `json:"weighted_rating"`
+	CreatedAt      *time.Time `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+}
+
+func newRecord(movie *entity.Movie) record {
+	r := record{
+		ID:             movie.Id,
+		ExternalID:     movie.ExternalId,
+		Title:          movie.Title,
+		Director:       movie.Director,
+		Year:           movie.Year,
+		Plot:           movie.Plot,
+		Genres:         make([]string, 0, len(movie.Genres)),
+		GenreIDs:       make([]int, 0, len(movie.Genres)),
+		Rating:         movie.Rating,
+		VoteCount:      movie.VoteCount,
+		WeightedRating: movie.WeightedRating,
+		CreatedAt:      movie.CreatedAt,
+		UpdatedAt:      movie.UpdatedAt,
+	}
+
+	for _, genre := range movie.Genres {
+		r.Genres = append(r.Genres, genre.Name)
+		r.GenreIDs = append(r.GenreIDs, genre.Id)
+	}
+
+	return r
+}
+
+func (r record) genreIDs() string {
+	ids := make([]string, 0, len(r.GenreIDs))
+	for _, id := range r.GenreIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	return strings.Join(ids, "|")
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(movies []*entity.Movie) error {
+	for _, movie := range movies {
+		r := newRecord(movie)
+
+		externalID := ""
+		if r.ExternalID != nil {
+			externalID = *r.ExternalID
+		}
+
+		w.writer.Write([]string{
+			strconv.Itoa(r.ID),
+			text(externalID),
+			text(r.Title),
+			text(r.Director),
+			strconv.Itoa(r.Year),
+			text(r.Plot),
+			text(strings.Join(r.Genres, "|")),
+			r.genreIDs(),
+			strconv.FormatFloat(r.Rating, 'f', -1, 64),
+			strconv.Itoa(r.VoteCount),
+			strconv.FormatFloat(r.WeightedRating, 'f', -1, 64),
+			timestamp(r.CreatedAt),
+			timestamp(r.UpdatedAt),
+		})
+	}
+
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// text returns a text cell that spreadsheets do not take for a formula:
+// text starting with a character that would start one is prefixed with an
+// apostrophe, which Excel and LibreOffice hide.
+func text(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(movies []*entity.Movie) error {
+	for _, movie := range movies {
+		if err := w.encoder.Encode(newRecord(movie)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+type xlsxWriter struct {
+	sheet *xlsx.Writer
+}
+
+func (w *xlsxWriter) Write(movies []*entity.Movie) error {
+	for _, movie := range movies {
+		r := newRecord(movie)
+
+		err := w.sheet.WriteRow([]interface{}{
+			r.ID,
+			r.ExternalID,
+			r.Title,
+			r.Director,
+			r.Year,
+			r.Plot,
+			strings.Join(r.Genres, "|"),
+			r.genreIDs(),
+			r.Rating,
+			r.VoteCount,
+			r.WeightedRating,
+			r.CreatedAt,
+			r.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.sheet.Flush()
+}
+
+func (w *xlsxWriter) Close() error {
+	return w.sheet.Close()
+}
@@ -0,0 +1,93 @@
+package movieexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Movies-Go/internal/entity"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Heat", "Heat"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1", "'+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=b", "a=b"},
+		{"'Salem's Lot", "'Salem's Lot"},
+	}
+
+	for _, tt := range tests {
+		if got := text(tt.in); got != tt.want {
+			t.Errorf("text(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("CEST", 2*60*60))
+	externalID := "=cmd|' /C calc'!A0"
+	movies := []*entity.Movie{
+		{
+			Id:             1,
+			ExternalId:     &externalID,
+			Title:          "@Heat",
+			Director:       "Michael Mann",
+			Year:           1995,
+			Plot:           "A thief, a cop,\nand \"one last job\".",
+			Genres:         []*entity.Genre{{Id: 3, Name: "Crime"}, {Id: 5, Name: "Drama"}},
+			Rating:         8.3,
+			VoteCount:      12,
+			WeightedRating: 7.25,
+			CreatedAt:      &created,
+		},
+		{Id: 2, Title: "-1", Year: 2000},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(movies); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, ok := strings.CutPrefix(buf.String(), bom)
+	if !ok {
+		t.Fatalf("output does not start with a byte order mark: %q", buf.String())
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		Columns,
+		{"1", "'=cmd|' /C calc'!A0", "'@Heat", "Michael Mann", "1995", "A thief, a cop,\nand \"one last job\".",
+			"Crime|Drama", "3|5", "8.3", "12", "7.25", "2024-05-06T05:08:09Z", ""},
+		{"2", "", "'-1", "", "2000", "", "", "", "0", "0", "0", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package movieimport reads movies for bulk import from CSV and NDJSON files
+// and validates them with the same rules as POST /movies.
+package movieimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/repository/postgres/movies"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// maxLineSize bounds a single NDJSON line.
+const maxLineSize = 1 << 20
+
+// Columns lists the CSV columns an import file may have. The header row must
+// name title and year; genre_ids and genres hold lists separated by ",", "|"
+// or ";".
+var Columns = []string{"external_id", "title", "director", "year", "plot", "genre_ids", "genres"}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(apperror.FieldName)
+	return v
+}
+
+// FormatFor picks the format of an upload from its content type, falling
+// back to the file name extension.
+func FormatFor(contentType, filename string) (Format, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/json-lines":
+		return FormatNDJSON, true
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, true
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, true
+	}
+
+	return "", false
+}
+
+// Parse reads every row of r. Rows that do not parse or validate carry their
+// problems in Errors; an error is returned only when the file as a whole is
+// unusable, e.g. a CSV header with unknown columns.
+func Parse(r io.Reader, format Format) ([]movies.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatNDJSON:
+		return parseNDJSON(r)
+	default:
+		return nil, apperror.InvalidField("format", "must be one of: csv, ndjson")
+	}
+}
+
+func parseCSV(r io.Reader) ([]movies.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, apperror.Validation("The file is empty")
+	}
+	if err != nil {
+		return nil, apperror.Validation("The file is not valid CSV: %s", err.Error()).Wrap(err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known(name) {
+			return nil, apperror.Validation("Unknown column %q", name).With("allowed", Columns)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, apperror.Validation("Duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+
+	for _, name := range []string{"title", "year"} {
+		if _, ok := columns[name]; !ok {
+			return nil, apperror.Validation("Missing column %q", name)
+		}
+	}
+
+	var rows []movies.ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var row movies.ImportRow
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount):
+			row.Line = parseErr.StartLine
+			row.Errors = []apperror.FieldError{{Message: "expected " + strconv.Itoa(len(header)) + " columns"}}
+		case err != nil:
+			return nil, apperror.Validation("The file is not valid CSV: %s", err.Error()).Wrap(err)
+		default:
+			line, _ := reader.FieldPos(0)
+			row = csvRow(line, record, columns)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func known(column string) bool {
+	for _, name := range Columns {
+		if name == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+func csvRow(line int, record []string, columns map[string]int) movies.ImportRow {
+	row := movies.ImportRow{Line: line}
+
+	cell := func(name string) *string {
+		i, ok := columns[name]
+		if !ok {
+			return nil
+		}
+		if value := strings.TrimSpace(record[i]); value != "" {
+			return &value
+		}
+		return nil
+	}
+
+	row.ExternalID = cell("external_id")
+	row.Title = cell("title")
+	row.Director = cell("director")
+	row.Plot = cell("plot")
+
+	if year := cell("year"); year != nil {
+		value, err := strconv.Atoi(*year)
+		if err != nil {
+			row.Errors = append(row.Errors, apperror.FieldError{Field: "year", Message: "must be of type number"})
+		} else {
+			row.Year = &value
+		}
+	}
+
+	if ids := cell("genre_ids"); ids != nil {
+		for _, item := range splitList(*ids) {
+			id, err := strconv.Atoi(item)
+			if err != nil {
+				row.Errors = append(row.Errors, apperror.FieldError{Field: "genre_ids", Message: "must contain numbers"})
+				break
+			}
+			row.GenreIDs = append(row.GenreIDs, id)
+		}
+	}
+
+	if names := cell("genres"); names != nil {
+		row.Genres = splitList(*names)
+	}
+
+	// A cell that did not parse is left empty, so skip the "is required"
+	// error validation adds for it.
+	for _, problem := range check(&row) {
+		if !rejected(row.Errors, problem.Field) {
+			row.Errors = append(row.Errors, problem)
+		}
+	}
+
+	return row
+}
+
+func rejected(errs []apperror.FieldError, field string) bool {
+	for _, fe := range errs {
+		if fe.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(value string) []string {
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '|' || r == ';'
+	})
+
+	var items []string
+	for _, part := range parts {
+		if item := strings.TrimSpace(part); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// ndjsonRow is a line of an NDJSON file: a CreateMovieRequest that may also
+// name its genres.
+type ndjsonRow struct {
+	movies.CreateMovieRequest
+	Genres []string `json:"genres"`
+}
+
+func parseNDJSON(r io.Reader) ([]movies.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var rows []movies.ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := movies.ImportRow{Line: line}
+
+		var item ndjsonRow
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&item); err != nil {
+			row.Errors = problems(err)
+		} else {
+			row.CreateMovieRequest = item.CreateMovieRequest
+			row.Genres = item.Genres
+			row.Errors = check(&row)
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, apperror.Validation("The file could not be read: %s", err.Error()).Wrap(err)
+	}
+
+	return rows, nil
+}
+
+// check validates the row with the binding rules of CreateMovieRequest.
+func check(row *movies.ImportRow) []apperror.FieldError {
+	if row.ExternalID != nil && *row.ExternalID == "" {
+		row.ExternalID = nil
+	}
+
+	if err := validate.Struct(row.CreateMovieRequest); err != nil {
+		return problems(err)
+	}
+
+	return nil
+}
+
+func problems(err error) []apperror.FieldError {
+	e := apperror.Binding(err)
+	if len(e.Fields) > 0 {
+		return e.Fields
+	}
+
+	// Unknown fields and malformed JSON are not tied to a known field.
+	var syntaxError *json.SyntaxError
+	message := e.Message
+	switch {
+	case strings.HasPrefix(err.Error(), "json: unknown field"):
+		message = strings.TrimPrefix(err.Error(), "json: ")
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		message = "the line is not valid JSON"
+	}
+
+	return []apperror.FieldError{{Message: message}}
+}
@@ -0,0 +1,195 @@
+package movieimport
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Movies-Go/internal/pkg/apperror"
+)
+
+// fieldErrors builds the errors of a row from field and message pairs.
+func fieldErrors(pairs ...string) []apperror.FieldError {
+	var errs []apperror.FieldError
+	for i := 0; i < len(pairs); i += 2 {
+		errs = append(errs, apperror.FieldError{Field: pairs[i], Message: pairs[i+1]})
+	}
+
+	return errs
+}
+
+// wantRow is the line and errors of a parsed row.
+type wantRow struct {
+	line   int
+	errors []apperror.FieldError
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		in     string
+		want   []wantRow
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			in:     "external_id,title,director,year,plot,genre_ids,genres\nm-1,Heat,Michael Mann,1995,\"A thief,\nand a cop\",1|2,Crime;Drama\n",
+			want:   []wantRow{{line: 2}},
+		},
+		{
+			name:   "csv byte order mark and header case",
+			format: FormatCSV,
+			in:     "\xef\xbb\xbf Title , YEAR\nHeat,1995\n",
+			want:   []wantRow{{line: 2}},
+		},
+		{
+			name:   "csv field count",
+			format: FormatCSV,
+			in:     "title,year\nHeat,1995,extra\nAlien\nBrazil,1985\n",
+			want: []wantRow{
+				{line: 2, errors: fieldErrors("", "expected 2 columns")},
+				{line: 3, errors: fieldErrors("", "expected 2 columns")},
+				{line: 4},
+			},
+		},
+		{
+			// Cells that do not parse are reported once, not also as
+			// missing.
+			name:   "csv unparsable cells",
+			format: FormatCSV,
+			in:     "title,year,genre_ids\nHeat,soon,1|x\n",
+			want: []wantRow{
+				{line: 2, errors: fieldErrors("year", "must be of type number", "genre_ids", "must contain numbers")},
+			},
+		},
+		{
+			name:   "csv validation",
+			format: FormatCSV,
+			in:     "title,year,genre_ids\n ,1700,0\n",
+			want: []wantRow{
+				{line: 2, errors: fieldErrors("title", "is required", "year", "must be at least 1800", "genre_ids[0]", "must be at least 1")},
+			},
+		},
+		{
+			name:   "ndjson",
+			format: FormatNDJSON,
+			in:     "{\"external_id\":\"m-1\",\"title\":\"Heat\",\"year\":1995,\"genre_ids\":[1],\"genres\":[\"Crime\"]}\n\n  \n{\"title\":\"Alien\",\"year\":1979}\n",
+			want:   []wantRow{{line: 1}, {line: 4}},
+		},
+		{
+			name:   "ndjson unknown field",
+			format: FormatNDJSON,
+			in:     "{\"title\":\"Heat\",\"year\":1995,\"rating\":5}\n",
+			want:   []wantRow{{line: 1, errors: fieldErrors("", `unknown field "rating"`)}},
+		},
+		{
+			name:   "ndjson invalid lines",
+			format: FormatNDJSON,
+			in:     "{not json\n{\"title\":\"Heat\",\"year\":\"soon\"}\n{\"year\":1700}\n{\"title\":\"Heat\"",
+			want: []wantRow{
+				{line: 1, errors: fieldErrors("", "the line is not valid JSON")},
+				{line: 2, errors: fieldErrors("year", "must be of type number")},
+				{line: 3, errors: fieldErrors("title", "is required", "year", "must be at least 1800")},
+				{line: 4, errors: fieldErrors("", "the line is not valid JSON")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Parse(strings.NewReader(tt.in), tt.format)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if len(rows) != len(tt.want) {
+				t.Fatalf("Parse() returned %d rows, want %d: %+v", len(rows), len(tt.want), rows)
+			}
+			for i, want := range tt.want {
+				if rows[i].Line != want.line {
+					t.Errorf("row %d: line %d, want %d", i, rows[i].Line, want.line)
+				}
+				if !reflect.DeepEqual(rows[i].Errors, want.errors) {
+					t.Errorf("row %d: errors %+v, want %+v", i, rows[i].Errors, want.errors)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSVValues(t *testing.T) {
+	in := "external_id,title,director,year,plot,genre_ids,genres\n ,Heat, ,1995,\"A thief,\nand a cop\",\"1, 2\",Crime| Drama ;\n"
+
+	rows, err := Parse(strings.NewReader(in), FormatCSV)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Parse() = %+v, %v", rows, err)
+	}
+	row := rows[0]
+
+	if row.ExternalID != nil || row.Director != nil {
+		t.Errorf("blank cells parsed as %v, %v, want nil", row.ExternalID, row.Director)
+	}
+	if row.Title == nil || *row.Title != "Heat" || row.Year == nil || *row.Year != 1995 {
+		t.Errorf("title and year = %v, %v, want Heat, 1995", row.Title, row.Year)
+	}
+	if row.Plot == nil || *row.Plot != "A thief,\nand a cop" {
+		t.Errorf("plot = %v, want the quoted cell", row.Plot)
+	}
+	if !reflect.DeepEqual(row.GenreIDs, []int{1, 2}) || !reflect.DeepEqual(row.Genres, []string{"Crime", "Drama"}) {
+		t.Errorf("genres = %v, %v, want [1 2], [Crime Drama]", row.GenreIDs, row.Genres)
+	}
+}
+
+func TestParseRejectsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		in      string
+		message string
+	}{
+		{name: "empty", format: FormatCSV, in: "", message: "The file is empty"},
+		{name: "unknown column", format: FormatCSV, in: "title,year,rating\n", message: `Unknown column "rating"`},
+		{name: "duplicate column", format: FormatCSV, in: "title,year,Title\n", message: `Duplicate column "title"`},
+		{name: "missing title", format: FormatCSV, in: "year\n1995\n", message: `Missing column "title"`},
+		{name: "missing year", format: FormatCSV, in: "title\nHeat\n", message: `Missing column "year"`},
+		{name: "invalid header", format: FormatCSV, in: "\"title,year\n", message: "The file is not valid CSV"},
+		{name: "invalid row", format: FormatCSV, in: "title,year\n\"Heat,1995\n", message: "The file is not valid CSV"},
+		{name: "line too long", format: FormatNDJSON, in: strings.Repeat("x", maxLineSize+1), message: "The file could not be read"},
+		{name: "unknown format", format: "xml", in: "<movies/>", message: "Invalid format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Parse(strings.NewReader(tt.in), tt.format)
+			if !errors.Is(err, apperror.ErrValidation) {
+				t.Fatalf("Parse() = %+v, %v, want a validation error", rows, err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.message) {
+				t.Errorf("Parse() error = %q, want %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	tests := []struct {
+		contentType, filename string
+		want                  Format
+		ok                    bool
+	}{
+		{"text/csv; charset=utf-8", "movies.txt", FormatCSV, true},
+		{"application/x-ndjson", "", FormatNDJSON, true},
+		{"application/octet-stream", "Movies.CSV", FormatCSV, true},
+		{"", "movies.jsonl", FormatNDJSON, true},
+		{"", "movies.json", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := FormatFor(tt.contentType, tt.filename)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FormatFor(%q, %q) = %q, %v, want %q, %v", tt.contentType, tt.filename, got, ok, tt.want, tt.ok)
+		}
+	}
+}
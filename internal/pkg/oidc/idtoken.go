@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrInvalidIDToken is returned for ID tokens that fail verification.
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// signingMethods are the algorithms ID tokens may be signed with. Tokens
+// signed with a shared secret or not at all are refused.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// IDToken holds the verified claims of an ID token that identify the user.
+type IDToken struct {
+	Issuer  string
+	Subject string
+	// Email is only proven to belong to the user when EmailVerified is
+	// set.
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     flag   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// flag is a boolean claim that some providers send as a string.
+type flag bool
+
+func (f *flag) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*f = flag(v)
+	case string:
+		*f = flag(strings.EqualFold(v, "true"))
+	default:
+		*f = false
+	}
+
+	return nil
+}
+
+// Verify checks the signature of an ID token against the provider's keys
+// and validates its claims as OpenID Connect Core section 3.1.3.7 demands.
+// nonce must be the one the authentication request was sent with.
+func (p *Provider) Verify(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	claims := &idTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods))
+	_, err = parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	switch {
+	case claims.Issuer != metadata.Issuer:
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidIDToken, claims.Issuer)
+	case !claims.VerifyAudience(p.config.ClientID, true):
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID:
+		return nil, fmt.Errorf("%w: authorized party is %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	case claims.ExpiresAt == nil || claims.IssuedAt == nil:
+		return nil, fmt.Errorf("%w: exp and iat are required", ErrInvalidIDToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: sub is required", ErrInvalidIDToken)
+	case nonce == "" || claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	}
+
+	return &IDToken{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
@@ -0,0 +1,252 @@
+// Package oidc signs users in through OpenID Connect identity providers
+// with the authorization code flow and PKCE. Providers are configured by
+// their issuer only; the endpoints and signing keys are discovered.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpTimeout bounds every request to a provider.
+const httpTimeout = 10 * time.Second
+
+// DefaultScopes are requested when a provider is configured without scopes.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Config describes the registration of the service with a provider.
+type Config struct {
+	// Issuer is the issuer identifier of the provider, the URL its
+	// discovery document is found under.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends the browser back to with
+	// the authorization code.
+	RedirectURL string
+	Scopes      []string
+}
+
+// Metadata is the part of the provider's discovery document the client
+// uses.
+type Metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// Error is an error response of the token endpoint, RFC 6749 section 5.2.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+
+	return e.Code
+}
+
+// ErrInvalidGrant is returned when the provider rejects an authorization
+// code, e.g. because it expired, was used before or the PKCE verifier does
+// not match.
+var ErrInvalidGrant = errors.New("invalid authorization code")
+
+// Provider is a client of one identity provider. The discovery document is
+// fetched on first use, so that the service starts while a provider is
+// down.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *Metadata
+	keys     *keySet
+}
+
+// Providers holds the configured providers by name.
+type Providers map[string]*Provider
+
+// New returns a client for the provider described by config.
+func New(config Config) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Metadata returns the discovery document of the provider.
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := getJSON(ctx, p.client, p.config.Issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", p.config.Issuer, err)
+	}
+
+	// OpenID Connect Discovery section 4.3: the document must be about
+	// the issuer it was fetched from.
+	if strings.TrimRight(metadata.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("discover %s: document is for issuer %q", p.config.Issuer, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("discover %s: document lacks an endpoint", p.config.Issuer)
+	}
+	if len(metadata.CodeChallengeMethods) > 0 && !contains(metadata.CodeChallengeMethods, "S256") {
+		return nil, fmt.Errorf("discover %s: provider does not support PKCE with S256", p.config.Issuer)
+	}
+
+	p.metadata = &metadata
+	p.keys = newKeySet(metadata.JWKSURI, p.client)
+
+	return p.metadata, nil
+}
+
+// AuthCodeURL returns the address to send the browser to for signing in.
+// state and nonce are echoed back in the redirect and the ID token; the
+// challenge is derived from the PKCE verifier given to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// tokenResponse is the successful response of the token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// Exchange redeems an authorization code with the PKCE verifier it was
+// requested with and returns the verified ID token. nonce must be the one
+// given to AuthCodeURL.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	// Confidential clients authenticate with client_secret_basic, public
+	// ones only name themselves.
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exchange code with %s: %w", p.config.Issuer, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code with %s: %w", p.config.Issuer, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr Error
+		if json.Unmarshal(body, &tokenErr) != nil || tokenErr.Code == "" {
+			return nil, fmt.Errorf("exchange code with %s: %s", p.config.Issuer, resp.Status)
+		}
+		if tokenErr.Code == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGrant, &tokenErr)
+		}
+		return nil, fmt.Errorf("exchange code with %s: %w", p.config.Issuer, &tokenErr)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("exchange code with %s: %w", p.config.Issuer, err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("exchange code with %s: response has no id_token, is the openid scope requested?", p.config.Issuer)
+	}
+
+	return p.Verify(ctx, token.IDToken, nonce)
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
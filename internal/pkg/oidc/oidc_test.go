@@ -0,0 +1,196 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"Movies-Go/internal/pkg/oidc"
+	"Movies-Go/internal/pkg/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TestOIDCLogin runs the authorization code flow against the mock provider
+// and checks that codes, verifiers and nonces cannot be swapped.
+func TestOIDCLogin(t *testing.T) {
+	mock, server := oidctest.NewServer("movies-go", "secret")
+	defer server.Close()
+
+	provider := oidc.New(oidc.Config{
+		Issuer:       mock.Issuer,
+		ClientID:     "movies-go",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/oidc/mock/callback",
+	})
+	ctx := context.Background()
+
+	// authorize follows the sign-in up to the redirect back and returns
+	// the code.
+	authorize := func(nonce, verifier, hint string) string {
+		t.Helper()
+
+		authorizationURL, err := provider.AuthCodeURL(ctx, "state", nonce, oidc.Challenge(verifier))
+		if err != nil {
+			t.Fatalf("AuthCodeURL: %v", err)
+		}
+		if hint != "" {
+			authorizationURL += "&login_hint=" + url.QueryEscape(hint)
+		}
+
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		resp, err := client.Get(authorizationURL)
+		if err != nil {
+			t.Fatalf("authorize: %v", err)
+		}
+		resp.Body.Close()
+
+		location, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil || resp.StatusCode != http.StatusFound {
+			t.Fatalf("authorize: got %s to %q", resp.Status, resp.Header.Get("Location"))
+		}
+		if location.Query().Get("state") != "state" {
+			t.Fatalf("authorize: state not returned in %s", location)
+		}
+
+		return location.Query().Get("code")
+	}
+
+	code := authorize("nonce", "verifier-0123456789-0123456789-0123456789", "staff@example.com")
+	token, err := provider.Exchange(ctx, code, "verifier-0123456789-0123456789-0123456789", "nonce")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if token.Email != "staff@example.com" || !token.EmailVerified || token.Subject == "" {
+		t.Errorf("Exchange: got %+v", token)
+	}
+
+	if _, err := provider.Exchange(ctx, code, "verifier-0123456789-0123456789-0123456789", "nonce"); !errors.Is(err, oidc.ErrInvalidGrant) {
+		t.Errorf("redeeming a code twice: got %v, want ErrInvalidGrant", err)
+	}
+
+	code = authorize("nonce", "verifier-0123456789-0123456789-0123456789", "")
+	if _, err := provider.Exchange(ctx, code, "another-verifier-0123456789-0123456789", "nonce"); !errors.Is(err, oidc.ErrInvalidGrant) {
+		t.Errorf("wrong PKCE verifier: got %v, want ErrInvalidGrant", err)
+	}
+
+	code = authorize("nonce", "verifier-0123456789-0123456789-0123456789", "")
+	if _, err := provider.Exchange(ctx, code, "verifier-0123456789-0123456789-0123456789", "another-nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("wrong nonce: got %v, want ErrInvalidIDToken", err)
+	}
+
+	// A token signed by another provider with the same issuer and key id
+	// must not verify.
+	impostor, err := oidctest.New(mock.Issuer, "movies-go", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := impostor.IDToken(mock.User, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Verify(ctx, forged, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("forged ID token: got %v, want ErrInvalidIDToken", err)
+	}
+}
+
+// TestVerifyRejects checks that ID tokens are refused unless signed by the
+// provider with an asymmetric key and issued by it for this client.
+func TestVerifyRejects(t *testing.T) {
+	mock, server := oidctest.NewServer("movies-go", "secret")
+	defer server.Close()
+
+	provider := oidc.New(oidc.Config{
+		Issuer:       mock.Issuer,
+		ClientID:     "movies-go",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/oidc/mock/callback",
+	})
+	ctx := context.Background()
+
+	// signed returns a token of the mock provider with the claims changed
+	// by change.
+	signed := func(change func(claims jwt.MapClaims)) func() (string, error) {
+		return func() (string, error) {
+			claims := mock.Claims(mock.User, "nonce")
+			change(claims)
+			return mock.Sign(claims)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token func() (string, error)
+	}{
+		{
+			name: "alg none",
+			token: func() (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, mock.Claims(mock.User, "nonce"))
+				token.Header["kid"] = "oidctest"
+				return token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			},
+		},
+		{
+			// Signed with the client secret, which the client knows as
+			// well as the provider.
+			name: "HS256",
+			token: func() (string, error) {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, mock.Claims(mock.User, "nonce"))
+				token.Header["kid"] = "oidctest"
+				return token.SignedString([]byte("secret"))
+			},
+		},
+		{name: "wrong issuer", token: signed(func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" })},
+		{name: "issuer with a trailing slash", token: signed(func(claims jwt.MapClaims) { claims["iss"] = mock.Issuer + "/" })},
+		{name: "wrong audience", token: signed(func(claims jwt.MapClaims) { claims["aud"] = "another-client" })},
+		{name: "no audience", token: signed(func(claims jwt.MapClaims) { delete(claims, "aud") })},
+		{
+			name:  "several audiences without azp",
+			token: signed(func(claims jwt.MapClaims) { claims["aud"] = []string{"movies-go", "another-client"} }),
+		},
+		{
+			name: "several audiences for another party",
+			token: signed(func(claims jwt.MapClaims) {
+				claims["aud"] = []string{"movies-go", "another-client"}
+				claims["azp"] = "another-client"
+			}),
+		},
+		{name: "expired", token: signed(func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() })},
+		{name: "no expiry", token: signed(func(claims jwt.MapClaims) { delete(claims, "exp") })},
+		{name: "no issue time", token: signed(func(claims jwt.MapClaims) { delete(claims, "iat") })},
+		{name: "no subject", token: signed(func(claims jwt.MapClaims) { delete(claims, "sub") })},
+		{name: "wrong nonce", token: signed(func(claims jwt.MapClaims) { claims["nonce"] = "another-nonce" })},
+		{name: "no nonce", token: signed(func(claims jwt.MapClaims) { delete(claims, "nonce") })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := tt.token()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if token, err := provider.Verify(ctx, raw, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("Verify() = %+v, %v, want ErrInvalidIDToken", token, err)
+			}
+		})
+	}
+
+	// Several audiences are fine when the token names this client as the
+	// authorized party.
+	raw, err := signed(func(claims jwt.MapClaims) {
+		claims["aud"] = []string{"movies-go", "another-client"}
+		claims["azp"] = "movies-go"
+	})()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Verify(ctx, raw, "nonce"); err != nil {
+		t.Errorf("Verify() of a token for several audiences: %v", err)
+	}
+}
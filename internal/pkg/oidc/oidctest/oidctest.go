@@ -0,0 +1,314 @@
+// Package oidctest is an OpenID Connect provider for tests and local
+// development. It has no login form: every authentication request is
+// granted at once, for User or for the address given as login_hint. It
+// implements discovery, the authorization code flow with mandatory PKCE
+// and RS256-signed ID tokens.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"Movies-Go/internal/pkg/oidc"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// codeDuration is how long an authorization code can be redeemed.
+	codeDuration = time.Minute
+	// tokenDuration is how long issued ID tokens are valid.
+	tokenDuration = 5 * time.Minute
+	keyID         = "oidctest"
+)
+
+// User is who the provider signs in.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is the mock identity provider. It serves its endpoints under
+// the path of Issuer.
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// User is signed in unless the request names someone else.
+	User User
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+// grant is an issued authorization code.
+type grant struct {
+	user        User
+	redirectURI string
+	challenge   string
+	nonce       string
+	expiresAt   time.Time
+}
+
+// New returns a provider for the client clientID, a public client when
+// clientSecret is empty.
+func New(issuer, clientID, clientSecret string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		Issuer:       strings.TrimRight(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		User: User{
+			Subject:       "mock-user@example.com",
+			Email:         "user@example.com",
+			EmailVerified: true,
+			Name:          "Mock User",
+		},
+		key:   key,
+		codes: map[string]grant{},
+	}, nil
+}
+
+// NewServer starts a provider on a local port, like httptest.NewServer.
+// Its issuer is the URL of the server.
+func NewServer(clientID, clientSecret string) (*Provider, *httptest.Server) {
+	server := httptest.NewUnstartedServer(nil)
+
+	provider, err := New("http://"+server.Listener.Addr().String(), clientID, clientSecret)
+	if err != nil {
+		panic("oidctest: " + err.Error())
+	}
+
+	server.Config.Handler = provider
+	server.Start()
+
+	return provider, server
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	base := ""
+	if issuer, err := url.Parse(p.Issuer); err == nil {
+		base = issuer.Path
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, base) {
+	case "/.well-known/openid-configuration":
+		p.discovery(w)
+	case "/authorize":
+		p.authorize(w, r)
+	case "/token":
+		p.token(w, r)
+	case "/jwks":
+		p.jwks(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *Provider) discovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+// authorize grants the request and redirects back with a code. Requests
+// that cannot be redirected safely get a plain 400.
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if query.Get("client_id") != p.ClientID || err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "unknown client_id or invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	redirect := func(params url.Values) {
+		params.Set("state", query.Get("state"))
+		target := *redirectURI
+		target.RawQuery = params.Encode()
+		http.Redirect(w, r, target.String(), http.StatusFound)
+	}
+
+	switch {
+	case query.Get("response_type") != "code":
+		redirect(url.Values{"error": {"unsupported_response_type"}})
+		return
+	case !strings.Contains(" "+query.Get("scope")+" ", " openid "):
+		redirect(url.Values{"error": {"invalid_scope"}})
+		return
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		redirect(url.Values{"error": {"invalid_request"}, "error_description": {"PKCE with S256 is required"}})
+		return
+	}
+
+	user := p.User
+	if hint := query.Get("login_hint"); hint != "" {
+		name, _, _ := strings.Cut(hint, "@")
+		user = User{Subject: "mock-" + hint, Email: hint, EmailVerified: true, Name: name}
+	}
+
+	code, err := oidc.Random()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.mu.Lock()
+	p.codes[code] = grant{
+		user:        user,
+		redirectURI: redirectURI.String(),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		expiresAt:   time.Now().Add(codeDuration),
+	}
+	p.mu.Unlock()
+
+	redirect(url.Values{"code": {code}})
+}
+
+// token redeems an authorization code for an ID token.
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.ClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.ClientSecret)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// Codes work once, whether or not the rest of the request is valid.
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	g, found := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	verifier := r.PostForm.Get("code_verifier")
+	if !found || time.Now().After(g.expiresAt) ||
+		r.PostForm.Get("redirect_uri") != g.redirectURI ||
+		verifier == "" || oidc.Challenge(verifier) != g.challenge {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	idToken, err := p.IDToken(g.user, g.nonce)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	accessToken, err := oidc.Random()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenDuration.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// IDToken returns an ID token for user, signed with the provider's key.
+func (p *Provider) IDToken(user User, nonce string) (string, error) {
+	return p.Sign(p.Claims(user, nonce))
+}
+
+// Claims returns the claims of an ID token for user. Tests change them and
+// Sign the result to craft tokens the regular flow would not issue.
+func (p *Provider) Claims(user User, nonce string) jwt.MapClaims {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            user.Subject,
+		"aud":            p.ClientID,
+		"exp":            now.Add(tokenDuration).Unix(),
+		"iat":            now.Unix(),
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	return claims
+}
+
+// Sign signs claims with the provider's key.
+func (p *Provider) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+
+	return token.SignedString(p.key)
+}
+
+func (p *Provider) jwks(w http.ResponseWriter) {
+	public := p.key.PublicKey
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,338 @@
+// Package openapi builds an OpenAPI 3 document from route descriptions,
+// deriving the request and response schemas from the Go types the handlers
+// bind and return.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Movies-Go/internal/pkg/middleware"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+// bearerScheme is the name of the JWT security scheme, apiKeyScheme the
+// one of personal API keys.
+const (
+	bearerScheme = "bearerAuth"
+	apiKeyScheme = "apiKeyAuth"
+)
+
+type Document struct {
+	OpenAPI    string                  `json:"openapi"`
+	Info       Info                    `json:"info"`
+	Servers    []Server                `json:"servers,omitempty"`
+	Tags       []Tag                   `json:"tags,omitempty"`
+	Paths      map[string]PathItem     `json:"paths"`
+	Components Components              `json:"components"`
+	names      map[reflect.Type]string `json:"-"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Route describes an endpoint. Query and Body are zero values of the types
+// the handler binds; their form, json and binding tags become the
+// parameters and the request schema.
+type Route struct {
+	Method      string
+	Path        string // in gin syntax, e.g. /movies/:id
+	Tag         string
+	Summary     string
+	Description string
+
+	// Public routes need no access token. Roles restricts authenticated
+	// routes to users with one of the roles. SessionOnly routes refuse API
+	// keys.
+	Public      bool
+	Roles       []string
+	SessionOnly bool
+
+	Query interface{}
+	Body  interface{}
+	// Content replaces the JSON request body, keyed by media type.
+	Content map[string]*Schema
+
+	// Status is the success status, 200 by default.
+	Status int
+	// Response is the success response body as built by Data, List, Message
+	// or a custom schema. Files replaces it with non-JSON media types.
+	Response *Schema
+	Files    map[string]*Schema
+	// Responses documents other JSON responses than problems, by status.
+	Responses map[int]*Schema
+}
+
+// New returns an empty document for an API served under serverURL.
+func New(info Info, serverURL string) *Document {
+	info.Description = strings.TrimSpace(info.Description)
+
+	d := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Servers: []Server{{URL: serverURL}},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerScheme: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "Access token from /auth/login, /auth/register or /auth/refresh.",
+				},
+				apiKeyScheme: {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        "X-API-Key",
+					Description: "Personal API key from /users/me/api-keys, also accepted as \"Authorization: ApiKey <key>\". The read scope allows GET requests, the write scope the others.",
+				},
+			},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	d.Components.Schemas["Problem"] = problemSchema()
+	d.Components.Responses = map[string]Response{
+		"Problem": {
+			Description: "The request failed; see the problem details.",
+			Content:     map[string]MediaType{middleware.ProblemContentType: {Schema: Ref("Problem")}},
+		},
+	}
+
+	return d
+}
+
+func problemSchema() *Schema {
+	return &Schema{
+		Type:        "object",
+		Description: "An RFC 7807 problem. Validation problems list the rejected fields in errors.",
+		Properties: map[string]*Schema{
+			"type":     {Type: "string"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string"},
+			"errors": {
+				Type: "array",
+				Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"field":   {Type: "string"},
+						"message": {Type: "string"},
+					},
+				},
+			},
+		},
+		Required: []string{"type", "title", "status", "detail"},
+	}
+}
+
+// AddTag describes a tag used by routes.
+func (d *Document) AddTag(name, description string) {
+	d.Tags = append(d.Tags, Tag{Name: name, Description: description})
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Path converts a gin route path to OpenAPI syntax, e.g. /movies/:id to
+// /movies/{id}.
+func Path(ginPath string) string {
+	return pathParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Add documents routes. Path parameters are documented as positive
+// integers, which all ids in this API are.
+func (d *Document) Add(routes ...Route) {
+	for _, route := range routes {
+		path := Path(route.Path)
+		method := strings.ToLower(route.Method)
+
+		op := &Operation{
+			Summary:     route.Summary,
+			Description: strings.TrimSpace(route.Description),
+			OperationID: operationID(route.Method, path),
+			Responses:   map[string]Response{},
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+
+		for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "integer", Minimum: float(1)},
+			})
+		}
+		if route.Query != nil {
+			op.Parameters = append(op.Parameters, d.parameters(route.Query)...)
+		}
+
+		switch {
+		case route.Content != nil:
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+			for mediaType, schema := range route.Content {
+				op.RequestBody.Content[mediaType] = MediaType{Schema: schema}
+			}
+		case route.Body != nil:
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: d.Schema(route.Body)}},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := Response{Description: http.StatusText(status)}
+		switch {
+		case route.Files != nil:
+			success.Content = map[string]MediaType{}
+			for mediaType, schema := range route.Files {
+				success.Content[mediaType] = MediaType{Schema: schema}
+			}
+		case route.Response != nil:
+			success.Content = map[string]MediaType{"application/json": {Schema: route.Response}}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		for other, schema := range route.Responses {
+			op.Responses[strconv.Itoa(other)] = Response{
+				Description: http.StatusText(other),
+				Content:     map[string]MediaType{"application/json": {Schema: schema}},
+			}
+		}
+
+		problem := d.Components.Responses["Problem"]
+		if !route.Public {
+			op.Security = []map[string][]string{{bearerScheme: {}}}
+			if !route.SessionOnly {
+				op.Security = append(op.Security, map[string][]string{apiKeyScheme: {}})
+			}
+			op.Responses["401"] = problem
+			if len(route.Roles) > 0 {
+				op.Responses["403"] = problem
+				op.Description = strings.TrimSpace(op.Description + "\n\nRequires one of the roles: " + strings.Join(route.Roles, ", ") + ".")
+			}
+		}
+		op.Responses["default"] = problem
+
+		item, ok := d.Paths[path]
+		if !ok {
+			item = PathItem{}
+			d.Paths[path] = item
+		}
+		item[method] = op
+	}
+}
+
+// Has reports whether the document describes method on the gin route path.
+func (d *Document) Has(method, ginPath string) bool {
+	_, ok := d.Paths[Path(ginPath)][strings.ToLower(method)]
+	return ok
+}
+
+// Operations lists the documented operations as "METHOD /path" in OpenAPI
+// path syntax, sorted.
+func (d *Document) Operations() []string {
+	var list []string
+	for path, item := range d.Paths {
+		for method := range item {
+			list = append(list, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+// operationID derives a stable identifier such as getMoviesId from the
+// method and path.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '_' || r == '-' || r == '.'
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	return id
+}
@@ -0,0 +1,370 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Default     interface{}        `json:"default,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// Ref refers to the component schema called name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Object returns an object schema with the given properties.
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// Array returns an array of items.
+func Array(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// String, Integer and Number return schemas of primitive values.
+func String() *Schema  { return &Schema{Type: "string"} }
+func Integer() *Schema { return &Schema{Type: "integer"} }
+func Number() *Schema  { return &Schema{Type: "number"} }
+
+// Binary is the schema of an uploaded or downloaded file.
+func Binary() *Schema {
+	return &Schema{Type: "string", Format: "binary"}
+}
+
+// Data is the body {"data": v, "message": "..."} most handlers respond with.
+func (d *Document) Data(v interface{}) *Schema {
+	return Object(map[string]*Schema{
+		"data":    d.Schema(v),
+		"message": String(),
+	}, "data")
+}
+
+// List is the body {"data": {"results": [v], "count": n}} of paginated
+// lists. Extra adds members next to results, e.g. cursors.
+func (d *Document) List(v interface{}, extra map[string]*Schema) *Schema {
+	page := Object(map[string]*Schema{
+		"results": Array(d.Schema(v)),
+		"count":   &Schema{Type: "integer", Description: "Total number of matches"},
+	}, "results")
+	for name, schema := range extra {
+		page.Properties[name] = schema
+	}
+
+	return Object(map[string]*Schema{"data": page}, "data")
+}
+
+// Message is the body {"message": "..."} of handlers without data.
+func Message() *Schema {
+	return Object(map[string]*Schema{"message": String()}, "message")
+}
+
+// Schema returns the schema of v's type. Named struct types become
+// components, referenced as package.Type, so that recursive and shared
+// types are described once.
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer()
+	case reflect.Float32, reflect.Float64:
+		return Number()
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		return Array(d.schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.component(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) component(t reflect.Type) *Schema {
+	if name, ok := d.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndexByte(pkg, '/'); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+
+	// Register the name first so that recursive types refer to themselves.
+	d.names[t] = name
+	d.Components.Schemas[name] = d.structSchema(t)
+
+	return Ref(name)
+}
+
+// structSchema describes the JSON encoding of a struct: fields are named
+// after their json tag, embedded structs are flattened and fields declared
+// directly win over promoted ones.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			embedded = append(embedded, field)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := d.schemaOf(field.Type)
+		if required := applyRules(property, field.Tag.Get("binding")); required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	for _, field := range embedded {
+		inner := d.structSchema(indirect(field.Type))
+		for name, property := range inner.Properties {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Properties[name] = property
+			}
+		}
+		schema.Required = append(schema.Required, inner.Required...)
+	}
+
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+
+	return schema
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	return strings.Split(tag, ",")[0], false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// parameters describes the query parameters bound from the form tags of v.
+func (d *Document) parameters(v interface{}) []Parameter {
+	return d.queryParameters(indirect(reflect.TypeOf(v)))
+}
+
+func (d *Document) queryParameters(t reflect.Type) []Parameter {
+	var params []Parameter
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && indirect(field.Type).Kind() == reflect.Struct {
+			params = append(params, d.queryParameters(indirect(field.Type))...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("form"), ",")
+		name := tag[0]
+		if name == "-" || name == "" {
+			continue
+		}
+
+		schema := d.schemaOf(field.Type)
+		if indirect(field.Type) == timeType && field.Tag.Get("time_format") == "2006-01-02" {
+			schema.Format = "date"
+		}
+		for _, option := range tag[1:] {
+			if value, ok := strings.CutPrefix(option, "default="); ok {
+				schema.Default = typed(schema, value)
+			}
+		}
+
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: applyRules(schema, field.Tag.Get("binding")),
+			Schema:   schema,
+		})
+	}
+
+	return params
+}
+
+// applyRules copies the validator rules of a binding tag onto schema and
+// reports whether the field is required. Rules after "dive" apply to the
+// items of a slice.
+func applyRules(schema *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	required := false
+	target := schema
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			required = target == schema
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			}
+		case "min", "gte":
+			limit(target, param, true)
+		case "max", "lte":
+			limit(target, param, false)
+		case "len":
+			limit(target, param, true)
+			limit(target, param, false)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, typed(target, value))
+			}
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "datetime":
+			if param == "2006-01-02" {
+				target.Format = "date"
+			} else {
+				target.Format = "date-time"
+			}
+		}
+	}
+
+	return required
+}
+
+// limit sets a lower or upper bound: a value for numbers, a length for
+// strings and a number of items for arrays.
+func limit(schema *Schema, param string, lower bool) {
+	switch schema.Type {
+	case "integer", "number":
+		value, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			schema.Minimum = &value
+		} else {
+			schema.Maximum = &value
+		}
+	case "string", "array":
+		value, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		switch {
+		case schema.Type == "string" && lower:
+			schema.MinLength = &value
+		case schema.Type == "string":
+			schema.MaxLength = &value
+		case lower:
+			schema.MinItems = &value
+		default:
+			schema.MaxItems = &value
+		}
+	}
+}
+
+// typed converts a tag value to the JSON type of schema.
+func typed(schema *Schema, value string) interface{} {
+	switch schema.Type {
+	case "integer":
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+
+	return value
+}
+
+func float(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a time source the tests move by hand.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = c.Now
+
+	return store, c
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store, clock := newTestStore()
+	// One token per second, bursts of three.
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		want    Result
+	}{
+		{name: "first", want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+		{name: "second", want: Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+		{name: "third", want: Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+		{name: "empty", want: Result{Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}},
+		{name: "half refilled", advance: 500 * time.Millisecond,
+			want: Result{Remaining: 0, Reset: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{name: "refilled", advance: 500 * time.Millisecond, want: Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+		{name: "refill capped", advance: time.Hour, want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		step.want.Limit = limit
+
+		got, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: Take() = %+v, want %+v", step.name, got, step.want)
+		}
+	}
+
+	// Other keys have buckets of their own.
+	if got, _ := store.Take(context.Background(), "other", limit); !got.Allowed || got.Remaining != 2 {
+		t.Errorf("other key: Take() = %+v, want a full bucket", got)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	ctx := context.Background()
+
+	// refilled is full again a second after its token was taken; drained
+	// needs three seconds after its last one.
+	store.Take(ctx, "refilled", limit)
+	clock.Advance(sweepInterval)
+	for i := 0; i < 3; i++ {
+		store.Take(ctx, "drained", limit)
+	}
+
+	// The first Take swept at the start; the next sweep is due now.
+	clock.Advance(time.Second)
+	store.Take(ctx, "new", limit)
+
+	if _, ok := store.buckets["refilled"]; ok {
+		t.Error("full bucket not evicted")
+	}
+	if b, ok := store.buckets["drained"]; !ok || b.tokens >= 3 {
+		t.Error("bucket still refilling was evicted")
+	}
+
+	// Sweeps run at most once per interval.
+	clock.Advance(10 * time.Second)
+	store.Take(ctx, "later", limit)
+	if _, ok := store.buckets["drained"]; !ok {
+		t.Error("swept again within the interval")
+	}
+
+	clock.Advance(sweepInterval)
+	store.Take(ctx, "last", limit)
+	for _, key := range []string{"drained", "new", "later"} {
+		if _, ok := store.buckets[key]; ok {
+			t.Errorf("bucket %q not evicted once full", key)
+		}
+	}
+	if len(store.buckets) != 1 {
+		t.Errorf("%d buckets left, want 1", len(store.buckets))
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	store, _ := newTestStore()
+
+	for _, limiter := range []*Limiter{nil, NewLimiter(store, "test", Limit{})} {
+		for i := 0; i < 10; i++ {
+			result, err := limiter.Allow(context.Background(), "key")
+			if err != nil || !result.Allowed {
+				t.Fatalf("disabled limiter: Allow() = %+v, %v, want allowed", result, err)
+			}
+		}
+	}
+
+	if len(store.buckets) != 0 {
+		t.Errorf("disabled limiter created %d buckets", len(store.buckets))
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "10/1m", want: Limit{Requests: 10, Period: time.Minute}},
+		{in: "off", want: Limit{}},
+		{in: "10", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "x/1m", wantErr: true},
+		{in: "10/0s", wantErr: true},
+		{in: "10/soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v, want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if (Limit{}).Enabled() {
+		t.Error("the zero Limit is enabled")
+	}
+	if got := (Limit{Requests: 10, Period: 90 * time.Second}).Policy(); got != "10;w=90" {
+		t.Errorf("Policy() = %q, want 10;w=90", got)
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"Movies-Go/internal/pkg/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc returns the key a request is limited under.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP limits each client address separately.
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// Middleware limits requests with l, keyed by key. Responses carry the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers; refused requests get a 429 problem with Retry-After. Requests
+// are let through when the store fails.
+func Middleware(l *Limiter, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Apply(c, l, key(c)) {
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Apply takes a token for key from l and checks the result like Check.
+// Requests are let through when the store fails.
+func Apply(c *gin.Context, l *Limiter, key string) bool {
+	result, err := l.Allow(c.Request.Context(), key)
+	if err != nil {
+		log.Printf("Rate limiter unavailable: %v", err)
+		return true
+	}
+
+	return Check(c, result)
+}
+
+// Check writes the headers of result and, if the request was refused,
+// attaches the 429 error. It reports whether the request may proceed.
+func Check(c *gin.Context, result Result) bool {
+	if !result.Limit.Enabled() {
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+	c.Header("RateLimit-Policy", result.Limit.Policy())
+
+	if result.Allowed {
+		return true
+	}
+
+	Refuse(c, result.RetryAfter, "Too many requests, retry in "+ceilSeconds(result.RetryAfter)+" seconds")
+	return false
+}
+
+// Refuse attaches a 429 error asking the client to retry after wait.
+func Refuse(c *gin.Context, wait time.Duration, message string) {
+	c.Header("Retry-After", ceilSeconds(wait))
+	c.Error(apperror.TooManyRequests("%s", message).With("retry_after", int(math.Ceil(wait.Seconds()))))
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,104 @@
+// Package ratelimit throttles requests with token buckets. The buckets live
+// in a Store: MemoryStore keeps them in the process, and a shared store,
+// e.g. on Redis, lets several instances enforce one limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, with bursts of up to Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses limits written as "requests/period", e.g. "10/1m". "off"
+// disables limiting, which is the zero Limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "off" {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q is not requests/period", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("rate limit %q needs a positive number of requests", s)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q needs a positive period", s)
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Enabled reports whether l limits anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Policy describes l in the RateLimit-Policy header format, e.g. "10;w=60".
+func (l Limit) Policy() string {
+	return strconv.Itoa(l.Requests) + ";w=" + strconv.Itoa(int(math.Ceil(l.Period.Seconds())))
+}
+
+// rate is the number of tokens refilled per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the state of a bucket after taking a token from it.
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, when it was
+	// refused.
+	RetryAfter time.Duration
+}
+
+// Store holds the buckets. Take removes a token from the bucket of key,
+// creating a full one if needed, and reports whether there was one.
+// Implementations must be safe for concurrent use; shared ones must update
+// a bucket atomically, e.g. with a script on Redis.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter applies one limit to keys in a namespace of a Store, so that
+// several limiters can share it.
+type Limiter struct {
+	store     Store
+	namespace string
+	limit     Limit
+}
+
+func NewLimiter(store Store, namespace string, limit Limit) *Limiter {
+	return &Limiter{
+		store:     store,
+		namespace: namespace,
+		limit:     limit,
+	}
+}
+
+// Allow takes a token for key. A disabled limiter allows everything.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	if l == nil || !l.limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	return l.store.Take(ctx, l.namespace+":"+key, l.limit)
+}
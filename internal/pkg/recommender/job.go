@@ -0,0 +1,90 @@
+// Package recommender keeps the precomputed similar movies and personal
+// recommendations up to date in the background.
+package recommender
+
+import (
+	"Movies-Go/internal/pkg/tracing"
+	"Movies-Go/internal/repository/postgres/recommendations"
+	"context"
+	"log"
+	"time"
+)
+
+type Refresher interface {
+	Refresh(ctx context.Context) (recommendations.RefreshResult, error)
+}
+
+// Job refreshes the recommendations once when started and then every
+// interval until stopped.
+type Job struct {
+	refresher Refresher
+	interval  time.Duration
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewJob(refresher Refresher, interval time.Duration) *Job {
+	return &Job{
+		refresher: refresher,
+		interval:  interval,
+	}
+}
+
+func (j *Job) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	go j.run(ctx)
+}
+
+// Stop cancels a running refresh and waits for the job to exit, or for ctx
+// to be done.
+func (j *Job) Stop(ctx context.Context) error {
+	if j.cancel == nil {
+		return nil
+	}
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *Job) run(ctx context.Context) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *Job) refresh(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "recommendations.refresh")
+	defer span.End()
+
+	result, err := j.refresher.Refresh(ctx)
+	switch {
+	case ctx.Err() != nil:
+	case err != nil:
+		span.RecordError(err)
+		log.Printf("Refreshing recommendations failed: %v", err)
+	case result.Skipped:
+		log.Println("Recommendations are being refreshed by another instance")
+	default:
+		log.Printf("Refreshed recommendations in %s: %d similar pairs, %d user recommendations",
+			result.Duration.Round(time.Millisecond), result.Similarities, result.Recommendations)
+	}
+}
@@ -3,18 +3,42 @@ package postgres
 import (
 	"Movies-Go/internal/entity"
 	"Movies-Go/internal/pkg/config"
+	"Movies-Go/internal/pkg/healthcheck"
+	"Movies-Go/internal/pkg/repository/migrate"
+	"Movies-Go/internal/pkg/repository/script/migrations"
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/extra/bundebug"
 	"log"
-	"os"
-	"strings"
 )
 
+// NewPostgres connects to the database and applies any pending migrations.
+// The application does not start against an outdated or modified schema.
 func NewPostgres() *bun.DB {
+	db := Connect()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalf("Error running migrations: %v", err)
+	}
+
+	return db
+}
+
+// Connect opens the database without touching its schema.
+func Connect() *bun.DB {
 	dsn := "postgres://" + config.GetConf().DBUsername + ":" + config.GetConf().DBPassword + "@" +
 		config.GetConf().DBHost + ":" + config.GetConf().DBPort + "/" + config.GetConf().DBName +
 		"?sslmode=disable"
@@ -22,100 +46,38 @@ func NewPostgres() *bun.DB {
 	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
 
 	db := bun.NewDB(sqldb, pgdialect.New())
+	db.RegisterModel((*entity.MovieGenre)(nil))
 	db.AddQueryHook(bundebug.NewQueryHook(
 		bundebug.WithVerbose(true),
 		bundebug.FromEnv("BUNDEBUG"),
 	))
 
-	if err := createTablesIfNotExist(db); err != nil {
-		log.Printf("Error creating tables: %v", err)
-	}
-
-	if err := runMigrations(db); err != nil {
-		log.Printf("Error running migrations: %v", err)
-	}
-
 	return db
 }
 
-func createTablesIfNotExist(db *bun.DB) error {
-	models := []interface{}{
-		(*entity.User)(nil),
-		(*entity.Movie)(nil),
-	}
-
-	for _, model := range models {
-		log.Printf("Creating table for model: %T", model)
-		_, err := db.NewCreateTable().Model(model).IfNotExists().Exec(context.Background())
-		if err != nil {
-			log.Printf("Error creating table for model %T: %v", model, err)
-			return err
-		}
-		log.Printf("Table for model %T created or already exists", model)
-	}
-
-	return nil
+// NewMigrator returns a migrator over the migrations embedded in the binary.
+func NewMigrator(db *bun.DB) (*migrate.Migrator, error) {
+	return migrate.NewMigrator(db.DB, migrations.FS)
 }
 
-func runMigrations(db *bun.DB) error {
-	migrationFiles := []string{
-		"internal/pkg/script/migrations/users.sql",
-		"internal/pkg/script/migrations/genres.sql",
-		"internal/pkg/script/migrations/movies.sql",
-	}
-
-	for _, file := range migrationFiles {
-		log.Printf("Checking migration file: %s", file)
-		content, err := os.ReadFile(file)
-		if err != nil {
-			log.Printf("Migration file %s not found, skipping: %v", file, err)
-			continue
-		}
-
-		log.Printf("Running migration file: %s", file)
-
-		tx, err := db.Begin()
+// SchemaCheck fails while the database schema is older than the newest
+// migration embedded in the binary. A newer schema passes with a note: during
+// a rolling deploy the instances still on the previous version keep serving
+// once the first new one has migrated.
+func SchemaCheck(migrator *migrate.Migrator) healthcheck.Check {
+	return func(ctx context.Context) error {
+		applied, latest, err := migrator.Versions(ctx)
 		if err != nil {
-			log.Printf("Error starting transaction for %s: %v", file, err)
-			continue
-		}
-
-		statements := strings.Split(string(content), ";")
-		executionFailed := false
-
-		for i, stmt := range statements {
-			stmt = strings.TrimSpace(stmt)
-			if stmt == "" {
-				continue
-			}
-
-			log.Printf("Executing statement %d from %s", i+1, file)
-			_, err = tx.Exec(stmt)
-			if err != nil {
-				log.Printf("Error executing statement %d from %s: %v", i+1, file, err)
-				log.Printf("Statement was: %s", stmt)
-				executionFailed = true
-				break
-			}
-			log.Printf("Successfully executed statement %d from %s", i+1, file)
+			return err
 		}
 
-		if executionFailed {
-			log.Printf("Rolling back migration for %s due to errors", file)
-			if err := tx.Rollback(); err != nil {
-				log.Printf("Error rolling back transaction: %v", err)
-			}
-			continue
+		if applied < latest {
+			return fmt.Errorf("schema is at version %d, expected %d", applied, latest)
 		}
-
-		if err := tx.Commit(); err != nil {
-			log.Printf("Error committing transaction for %s: %v", file, err)
-			continue
+		if applied > latest {
+			return healthcheck.Note(fmt.Sprintf("schema is at version %d, newer than %d of this build", applied, latest))
 		}
 
-		log.Printf("Completed migration file: %s", file)
+		return nil
 	}
-
-	log.Println("All migrations completed")
-	return nil
 }
@@ -1,4 +1,4 @@
-CREATE TABLE movies (
+CREATE TABLE IF NOT EXISTS movies (
                         id SERIAL PRIMARY KEY,
                         title VARCHAR(255) NOT NULL,
                         director VARCHAR(255) NOT NULL,
@@ -10,10 +10,8 @@ CREATE TABLE movies (
                         deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL
 );
 
-CREATE INDEX idx_movies_title ON movies(title);
-CREATE INDEX idx_movies_director ON movies(director);
-CREATE INDEX idx_movies_year ON movies(year);
-CREATE INDEX idx_movies_rating ON movies(rating);
-CREATE INDEX idx_movies_deleted_at ON movies(deleted_at) WHERE deleted_at IS NULL;
-
-ALTER TABLE movies OWNER TO postgres;
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_movies_title ON movies(title);
+CREATE INDEX IF NOT EXISTS idx_movies_director ON movies(director);
+CREATE INDEX IF NOT EXISTS idx_movies_year ON movies(year);
+CREATE INDEX IF NOT EXISTS idx_movies_rating ON movies(rating);
+CREATE INDEX IF NOT EXISTS idx_movies_deleted_at ON movies(deleted_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS genres (
+                        id SERIAL PRIMARY KEY,
+                        name VARCHAR(100) NOT NULL,
+                        description TEXT,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_genres_name ON genres(LOWER(name)) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_genres_deleted_at ON genres(deleted_at) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        genre_id INTEGER NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+                        PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_genres_genre_id ON movie_genres(genre_id);
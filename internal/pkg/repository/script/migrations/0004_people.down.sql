@@ -0,0 +1,3 @@
+-- The director column still holds the names, so only the credits are lost.
+DROP TABLE IF EXISTS movie_credits;
+DROP TABLE IF EXISTS people;
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS people (
+                        id SERIAL PRIMARY KEY,
+                        name VARCHAR(255) NOT NULL,
+                        bio TEXT,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_people_name ON people(LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_people_deleted_at ON people(deleted_at) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+                        id SERIAL PRIMARY KEY,
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        person_id INTEGER NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+                        role VARCHAR(50) NOT NULL,
+                        character_name VARCHAR(255),
+                        billing_order INTEGER NOT NULL DEFAULT 0,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_credits_movie_id ON movie_credits(movie_id, billing_order);
+CREATE INDEX IF NOT EXISTS idx_movie_credits_person_id ON movie_credits(person_id);
+
+-- Move the free-text director column into people and director credits.
+-- Co-directors written as "A, B" or "A & B" become separate people.
+INSERT INTO people (name, created_at, updated_at)
+SELECT DISTINCT ON (LOWER(d.name)) d.name, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM (
+         SELECT TRIM(regexp_split_to_table(director, '\s*(,|&)\s*')) AS name
+         FROM movies
+         WHERE deleted_at IS NULL
+     ) d
+WHERE d.name <> ''
+  AND NOT EXISTS (
+    SELECT 1 FROM people p WHERE LOWER(p.name) = LOWER(d.name) AND p.deleted_at IS NULL
+);
+
+INSERT INTO movie_credits (movie_id, person_id, role, billing_order, created_at, updated_at)
+SELECT d.movie_id, p.id, 'director', d.position - 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM (
+         SELECT m.id AS movie_id, TRIM(s.name) AS name, s.position
+         FROM movies m,
+              regexp_split_to_table(m.director, '\s*(,|&)\s*') WITH ORDINALITY AS s(name, position)
+         WHERE m.deleted_at IS NULL
+     ) d
+         JOIN people p ON LOWER(p.name) = LOWER(d.name) AND p.deleted_at IS NULL
+WHERE d.name <> ''
+  AND NOT EXISTS (
+    SELECT 1 FROM movie_credits c
+    WHERE c.movie_id = d.movie_id AND c.person_id = p.id AND c.role = 'director'
+);
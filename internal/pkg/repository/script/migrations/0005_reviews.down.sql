@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS reviews;
+
+DROP INDEX IF EXISTS idx_movies_weighted_rating;
+ALTER TABLE movies DROP COLUMN IF EXISTS weighted_rating;
+ALTER TABLE movies DROP COLUMN IF EXISTS vote_count;
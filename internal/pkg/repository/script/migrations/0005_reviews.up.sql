@@ -0,0 +1,21 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS vote_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS weighted_rating FLOAT NOT NULL DEFAULT 0;
+
+-- The rating becomes the average review score. Hand-entered ratings are
+-- dropped, since no movie has reviews yet, so that filters and sorts do not
+-- mix them with review averages.
+UPDATE movies SET rating = 0;
+
+CREATE TABLE IF NOT EXISTS reviews (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        score FLOAT NOT NULL CHECK (score >= 0 AND score <= 10),
+                        body TEXT,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_movie ON reviews(user_id, movie_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_movie_id ON reviews(movie_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_movies_weighted_rating ON movies(weighted_rating);
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'viewer';
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+
+-- Existing accounts all become viewers. The oldest account is not
+-- necessarily the operator's, so the first admin is chosen explicitly with
+-- the set-role command after upgrading.
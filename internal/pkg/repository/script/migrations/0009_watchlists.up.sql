@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS watchlist_items (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        position INTEGER NOT NULL CHECK (position > 0),
+                        note TEXT,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watchlist_items_user_movie ON watchlist_items(user_id, movie_id);
+CREATE INDEX IF NOT EXISTS idx_watchlist_items_user_position ON watchlist_items(user_id, position);
+
+CREATE TABLE IF NOT EXISTS watch_history (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        watched_at DATE NOT NULL,
+                        rewatch_count INTEGER NOT NULL DEFAULT 0 CHECK (rewatch_count >= 0),
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watch_history_user_movie ON watch_history(user_id, movie_id);
+CREATE INDEX IF NOT EXISTS idx_watch_history_user_watched_at ON watch_history(user_id, watched_at);
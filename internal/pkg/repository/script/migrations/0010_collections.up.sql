@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS collections (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        name VARCHAR(255) NOT NULL,
+                        description TEXT,
+                        visibility VARCHAR(20) NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'unlisted', 'public')),
+                        share_token VARCHAR(64) NOT NULL UNIQUE,
+                        copied_from_id INTEGER REFERENCES collections(id) ON DELETE SET NULL,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_collections_user_id ON collections(user_id);
+CREATE INDEX IF NOT EXISTS idx_collections_public ON collections(updated_at) WHERE visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS collection_items (
+                        id SERIAL PRIMARY KEY,
+                        collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        position INTEGER NOT NULL CHECK (position > 0),
+                        note TEXT,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_items_collection_movie ON collection_items(collection_id, movie_id);
+CREATE INDEX IF NOT EXISTS idx_collection_items_collection_position ON collection_items(collection_id, position);
+CREATE INDEX IF NOT EXISTS idx_collection_items_movie_id ON collection_items(movie_id);
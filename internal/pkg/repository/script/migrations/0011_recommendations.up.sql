@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS movie_similarities (
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        similar_movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        score FLOAT NOT NULL,
+                        genre_score FLOAT NOT NULL DEFAULT 0,
+                        people_score FLOAT NOT NULL DEFAULT 0,
+                        plot_score FLOAT NOT NULL DEFAULT 0,
+                        rating_score FLOAT NOT NULL DEFAULT 0,
+                        computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        PRIMARY KEY (movie_id, similar_movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_similarities_score ON movie_similarities(movie_id, score DESC);
+
+CREATE TABLE IF NOT EXISTS user_recommendations (
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+                        score FLOAT NOT NULL,
+                        collaborative_score FLOAT NOT NULL DEFAULT 0,
+                        content_score FLOAT NOT NULL DEFAULT 0,
+                        computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        PRIMARY KEY (user_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recommendations_score ON user_recommendations(user_id, score DESC);
@@ -0,0 +1,6 @@
+-- external_id identifies a movie in the catalog it was imported from, so
+-- that re-importing the catalog updates it instead of adding a duplicate.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_external_id ON movies(external_id)
+    WHERE external_id IS NOT NULL AND deleted_at IS NULL;
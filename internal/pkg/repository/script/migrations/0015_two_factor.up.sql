@@ -0,0 +1,24 @@
+-- totp_secret is set when enrollment starts and totp_enabled_at once the
+-- user confirmed it with a code. totp_last_step is the time step of the
+-- last accepted code, so that a code cannot be used twice.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+-- One-time codes that replace a TOTP code when the authenticator is lost.
+-- Only the SHA-256 hash of each code is stored.
+CREATE TABLE IF NOT EXISTS recovery_codes (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        code_hash VARCHAR(64) NOT NULL,
+                        used_at TIMESTAMP WITH TIME ZONE,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        UNIQUE (user_id, code_hash)
+);
+
+-- Roles whose members must use two-factor authentication.
+CREATE TABLE IF NOT EXISTS role_policies (
+                        role VARCHAR(20) PRIMARY KEY,
+                        require_two_factor BOOLEAN NOT NULL DEFAULT FALSE,
+                        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,16 @@
+-- Personal API keys for scripts. A key is looked up by its public prefix
+-- and checked against the SHA-256 hash of the whole key.
+CREATE TABLE IF NOT EXISTS api_keys (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        name VARCHAR(100) NOT NULL,
+                        prefix VARCHAR(16) NOT NULL UNIQUE,
+                        key_hash VARCHAR(64) NOT NULL,
+                        scopes TEXT[] NOT NULL DEFAULT '{}',
+                        expires_at TIMESTAMP WITH TIME ZONE,
+                        last_used_at TIMESTAMP WITH TIME ZONE,
+                        revoked_at TIMESTAMP WITH TIME ZONE,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
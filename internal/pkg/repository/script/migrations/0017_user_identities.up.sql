@@ -0,0 +1,13 @@
+-- Accounts at external OpenID Connect identity providers, linked to the
+-- users they sign in as. A provider identifies its users by subject.
+CREATE TABLE IF NOT EXISTS user_identities (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        provider VARCHAR(50) NOT NULL,
+                        subject VARCHAR(255) NOT NULL,
+                        email VARCHAR(255),
+                        last_login_at TIMESTAMP WITH TIME ZONE,
+                        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                        UNIQUE (provider, subject),
+                        UNIQUE (user_id, provider)
+);
@@ -0,0 +1,9 @@
+// Package migrations holds the numbered SQL migrations of the database
+// schema. Every change is a pair of files, NNNN_name.up.sql and
+// NNNN_name.down.sql, applied in version order by the migrate package.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -1,16 +0,0 @@
-CREATE TABLE users (
-                       id SERIAL PRIMARY KEY,
-                       full_name VARCHAR(255) NOT NULL,
-                       email VARCHAR(255) UNIQUE NOT NULL,
-                       password VARCHAR(255) NOT NULL,
-                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-                       deleted_at TIMESTAMP DEFAULT NULL
-);
-
-CREATE INDEX idx_users_email ON users(email);
-CREATE INDEX idx_users_full_name ON users(full_name);
-CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NULL;
-CREATE INDEX idx_users_created_at ON users(created_at);
-
-ALTER TABLE users OWNER TO postgres;
\ No newline at end of file
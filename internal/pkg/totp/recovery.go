@@ -0,0 +1,41 @@
+package totp
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets at a time.
+const RecoveryCodeCount = 10
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes returns n random one-time codes formatted like
+// "abcd-efgh-jkmn-pqrs", 80 bits each, from an alphabet without the
+// characters that are easily confused.
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		raw := recoveryEncoding.EncodeToString(buf)
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode makes codes typed with other case, spaces or
+// without dashes match the issued form.
+func NormalizeRecoveryCode(code string) string {
+	raw := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(raw) != 16 {
+		return raw
+	}
+
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+}
@@ -0,0 +1,155 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of RFC 6238 Appendix B, "12345678901234567890",
+// in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 checks the SHA-1 test vectors of RFC 6238 Appendix B,
+// truncated to the last 6 of their 8 digits.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// Secrets are accepted in lower case, as some apps display them.
+	if got, _ := Code(strings.ToLower(rfcSecret), Step(time.Unix(59, 0))); got != "287082" {
+		t.Errorf("Code with a lower case secret = %s, want 287082", got)
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		code  string
+		after int64
+		// want is the step accepted, 0 when the code is refused.
+		want int64
+	}{
+		{name: "current step", code: code(step), want: step},
+		{name: "with spaces", code: code(step)[:3] + " " + code(step)[3:], want: step},
+		{name: "previous step", code: code(step - Skew), want: step - Skew},
+		{name: "next step", code: code(step + Skew), want: step + Skew},
+		{name: "beyond the skew before", code: code(step - Skew - 1)},
+		{name: "beyond the skew after", code: code(step + Skew + 1)},
+		{name: "step already used", code: code(step), after: step},
+		{name: "later step used", code: code(step - 1), after: step},
+		{name: "earlier step used", code: code(step), after: step - 1, want: step},
+		{name: "too short", code: code(step)[:5]},
+		{name: "too long", code: code(step) + "0"},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Validate(rfcSecret, tt.code, now, tt.after)
+			if ok != (tt.want != 0) || got != tt.want {
+				t.Errorf("Validate(%q, after %d) = %d, %v, want %d", tt.code, tt.after, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	got := URI("Movies Go", "ann@example.com", rfcSecret)
+	want := "otpauth://totp/Movies%20Go:ann@example.com?algorithm=SHA1&digits=6&issuer=Movies%20Go&period=30&secret=" + rfcSecret
+
+	if got != want {
+		t.Errorf("URI() = %s, want %s", got, want)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("NewSecret() = %q, want 20 bytes in base32", secret)
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"abcd-efgh-jkmn-pqrs", "abcd-efgh-jkmn-pqrs"},
+		{"ABCD-EFGH-JKMN-PQRS", "abcd-efgh-jkmn-pqrs"},
+		{"abcdefghjkmnpqrs", "abcd-efgh-jkmn-pqrs"},
+		{" abcd efgh jkmn pqrs ", "abcd-efgh-jkmn-pqrs"},
+		{"ab-cdef-ghjk-mnpq-rs", "abcd-efgh-jkmn-pqrs"},
+		// Codes of another length cannot match and are left unformatted.
+		{"abcd-efgh", "abcdefgh"},
+		{"123456", "123456"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), RecoveryCodeCount)
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if NormalizeRecoveryCode(code) != code {
+			t.Errorf("code %q is not in normal form", code)
+		}
+		if strings.ContainsAny(code, "01lo") {
+			t.Errorf("code %q has a confusable character", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q issued twice", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"Movies-Go/internal/pkg/dbquery"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxQueryText bounds the SQL recorded on a span.
+const maxQueryText = 4096
+
+// InstrumentDB records every query run through db as a span, a child of the
+// span in the query's context. withQueryText adds the SQL, which includes
+// the query arguments.
+func InstrumentDB(db *bun.DB, withQueryText bool) {
+	db.AddQueryHook(queryHook{withQueryText: withQueryText})
+}
+
+type queryHook struct {
+	withQueryText bool
+}
+
+func (h queryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	operation, table := dbquery.Describe(event)
+
+	name := operation
+	if table != dbquery.NoTable {
+		name += " " + table
+	}
+
+	ctx, span := tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(event.StartTime),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+		),
+	)
+	if table != dbquery.NoTable {
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	if h.withQueryText && span.IsRecording() {
+		query := event.Query
+		if len(query) > maxQueryText {
+			query = query[:maxQueryText]
+		}
+		span.SetAttributes(semconv.DBQueryText(query))
+	}
+
+	return ctx
+}
+
+func (queryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+}
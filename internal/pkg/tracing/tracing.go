@@ -0,0 +1,99 @@
+// Package tracing sets up OpenTelemetry tracing: a span per HTTP request,
+// continued from the W3C traceparent header, with a child span per database
+// query.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"Movies-Go/internal/pkg/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the service in traces.
+const ServiceName = "movies-api"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// instrumentation names the tracer of this package.
+const instrumentation = "Movies-Go/internal/pkg/tracing"
+
+type Options struct {
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector URL. When empty the exporter reads
+	// the OTEL_EXPORTER_OTLP_* environment variables.
+	Endpoint    string
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and the W3C trace-context and
+// baggage propagators. The returned function flushes the spans still
+// buffered and must be called on shutdown. With ExporterNone no spans are
+// recorded, but trace context is still passed on.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(version.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span for work outside HTTP requests, such as background
+// jobs, so that their queries are grouped in one trace.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation, trace.WithInstrumentationVersion(version.Version))
+}
@@ -0,0 +1,48 @@
+// Package version identifies the running build. Version and Commit are set
+// at build time with
+//
+//	go build -ldflags "-X Movies-Go/internal/pkg/version.Version=v1.4.0 -X Movies-Go/internal/pkg/version.Commit=$(git rev-parse --short HEAD)" ./cmd
+//
+// Without them Version is "dev" and Commit is the VCS revision Go stamps
+// into binaries built inside a git checkout, if any.
+package version
+
+import "runtime/debug"
+
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+func init() {
+	if Commit != "" {
+		return
+	}
+
+	Commit = "unknown"
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+
+	if revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if modified == "true" {
+			revision += "-dirty"
+		}
+		Commit = revision
+	}
+}
@@ -0,0 +1,210 @@
+// Package xlsx writes single-sheet Office Open XML workbooks row by row, so
+// that large spreadsheets can be streamed without holding them in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of .xlsx files.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// dateFormat is the built-in number format for yyyy-mm-dd hh:mm.
+const dateFormat = 22
+
+// excelEpoch is day zero of the 1900 date system, accounting for the
+// nonexistent 1900-02-29 that Excel counts.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+var parts = []struct {
+	name string
+	body string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="` + strconv.Itoa(dateFormat) + `" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs></styleSheet>`},
+}
+
+// Cell styles, indexes into cellXfs above.
+const (
+	styleHeader = 1
+	styleDate   = 2
+)
+
+// Writer writes a workbook with one sheet. The first row written with
+// WriteHeader is frozen and bold. Close must be called to finish the file.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// NewWriter starts a workbook whose only sheet is called sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range parts {
+		if err := writePart(archive, part.name, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + escape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(archive, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &Writer{zip: archive, sheet: bufio.NewWriter(sheet)}
+	writer.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`)
+
+	return writer, nil
+}
+
+func writePart(archive *zip.Writer, name, body string) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(part, body)
+	return err
+}
+
+// WriteHeader writes a row of bold column titles.
+func (w *Writer) WriteHeader(titles []string) error {
+	cells := make([]interface{}, len(titles))
+	for i, title := range titles {
+		cells[i] = title
+	}
+
+	return w.writeRow(cells, styleHeader)
+}
+
+// WriteRow writes a row of cells. Strings, integers, floats, booleans and
+// times are supported; nil and nil pointers leave the cell empty.
+func (w *Writer) WriteRow(cells []interface{}) error {
+	return w.writeRow(cells, 0)
+}
+
+func (w *Writer) writeRow(cells []interface{}, style int) error {
+	w.row++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+
+	for i, value := range cells {
+		ref := column(i) + strconv.Itoa(w.row)
+		if err := w.writeCell(ref, value, style); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *Writer) writeCell(ref string, value interface{}, style int) error {
+	attrs := `r="` + ref + `"`
+	if style != 0 {
+		attrs += ` s="` + strconv.Itoa(style) + `"`
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return w.writeCell(ref, *v, style)
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return w.writeCell(ref, *v, style)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return w.writeCell(ref, *v, style)
+	case string:
+		fmt.Fprintf(w.sheet, `<c %s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, attrs, escape(v))
+	case int:
+		fmt.Fprintf(w.sheet, `<c %s><v>%d</v></c>`, attrs, v)
+	case int64:
+		fmt.Fprintf(w.sheet, `<c %s><v>%d</v></c>`, attrs, v)
+	case float64:
+		fmt.Fprintf(w.sheet, `<c %s><v>%s</v></c>`, attrs, strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		b := 0
+		if v {
+			b = 1
+		}
+		fmt.Fprintf(w.sheet, `<c %s t="b"><v>%d</v></c>`, attrs, b)
+	case time.Time:
+		days := v.UTC().Sub(excelEpoch).Hours() / 24
+		fmt.Fprintf(w.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, strconv.FormatFloat(days, 'f', -1, 64))
+	default:
+		return fmt.Errorf("xlsx: unsupported cell type %T", value)
+	}
+
+	return nil
+}
+
+// Flush writes the buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zip.Flush()
+}
+
+// Close finishes the sheet and the archive. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
+
+// column returns the letters of the zero-based column index, e.g. 27 is AB.
+func column(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+
+	return name
+}
+
+// escape escapes s for use in XML text and attributes, replacing characters
+// XML cannot represent.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
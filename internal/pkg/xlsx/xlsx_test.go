@@ -0,0 +1,110 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Movies & more")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nilString *string
+	err = w.WriteHeader([]string{"title", "year"})
+	if err == nil {
+		err = w.WriteRow([]interface{}{"=1+1", 1995, 8.25, true, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), nilString, nil, "<b>"})
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(body)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive lacks %s", name)
+		}
+	}
+
+	if !strings.Contains(files["xl/workbook.xml"], `name="Movies &amp; more"`) {
+		t.Errorf("sheet name not escaped:\n%s", files["xl/workbook.xml"])
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<row r="1"><c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">title</t></is></c>`,
+		// Strings are inline text, never formulas.
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">=1+1</t></is></c>`,
+		`<c r="B2"><v>1995</v></c>`,
+		`<c r="C2"><v>8.25</v></c>`,
+		`<c r="D2" t="b"><v>1</v></c>`,
+		`<c r="E2" s="2"><v>45293.5</v></c>`,
+		`<c r="H2" t="inlineStr"><is><t xml:space="preserve">&lt;b&gt;</t></is></c></row>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet lacks %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, "<f>") || strings.Contains(sheet, `r="F2"`) || strings.Contains(sheet, `r="G2"`) {
+		t.Errorf("sheet has a formula or a cell for nil:\n%s", sheet)
+	}
+}
+
+func TestWriteRowUnsupportedType(t *testing.T) {
+	w, err := NewWriter(io.Discard, "Sheet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteRow([]interface{}{struct{}{}}); err == nil {
+		t.Error("WriteRow accepted a struct")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := column(tt.index); got != tt.want {
+			t.Errorf("column(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
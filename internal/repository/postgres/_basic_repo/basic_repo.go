@@ -2,19 +2,31 @@ package basic_repo
 
 import (
 	"context"
+	"database/sql"
 	"github.com/uptrace/bun"
 	"time"
 )
 
+// BasicDelete soft-deletes the row with the given id. It returns
+// sql.ErrNoRows when there is no such row or it is already deleted.
 func BasicDelete(ctx context.Context, data Delete, table interface{}, r *bun.DB) error {
-	_, err := r.NewUpdate().
+	res, err := r.NewUpdate().
 		Model(table).
 		Set("deleted_at = ?", time.Now()).
 		Where("id = ?", *data.Id).
+		Where("deleted_at IS NULL").
 		Exec(ctx)
 	if err != nil {
 		return err
 	}
 
-	return err
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
@@ -0,0 +1,62 @@
+package basic_repo
+
+import (
+	"Movies-Go/internal/pkg/apperror"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// PostgreSQL error codes the repositories translate for clients.
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+	pgInvalidText         = "22P02"
+)
+
+// DBError translates a database error about resource (e.g. "movie") into
+// an apperror: a missing row becomes not found, a unique violation becomes a
+// conflict and a constraint violation becomes a validation error. Other
+// errors are returned unchanged and end up as internal errors.
+func DBError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.NotFound("%s not found", capitalize(resource)).Wrap(err)
+	}
+
+	var pgErr pgdriver.Error
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Field('C') {
+	case pgUniqueViolation:
+		return apperror.Conflict("%s already exists", capitalize(resource)).Wrap(err)
+	case pgForeignKeyViolation:
+		return apperror.Conflict("%s refers to or is referred to by another record", capitalize(resource)).Wrap(err)
+	case pgNotNullViolation, pgCheckViolation, pgInvalidText:
+		return apperror.Validation("Invalid %s data", resource).Wrap(err)
+	default:
+		return err
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,50 @@
+package basic_repo
+
+import "strings"
+
+// Sorts maps the public sort keys of a list, e.g. "title", to the columns
+// they order by.
+type Sorts struct {
+	Columns map[string]string
+	// Tiebreaker is the unique column ordering rows with equal keys.
+	Tiebreaker string
+	// Default is the sort key used for unknown keys; without one the rows
+	// are in Tiebreaker order.
+	Default string
+}
+
+// OrderBy turns a validated sort key such as "-title" into an ORDER BY
+// expression with the tiebreaker as the final key.
+func (s Sorts) OrderBy(sort string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+
+	column, ok := s.Columns[sort]
+	if !ok {
+		if s.Default != "" {
+			return Sorts{Columns: s.Columns, Tiebreaker: s.Tiebreaker}.OrderBy(s.Default)
+		}
+		return s.Tiebreaker + " ASC"
+	}
+
+	return column + " " + direction + ", " + s.Tiebreaker + " " + direction
+}
+
+// Pagination returns the page and page size requested, page 1 and
+// defaultLimit when not given.
+func Pagination(pageParam, limitParam *int, defaultLimit int) (int, int) {
+	page := 1
+	if pageParam != nil && *pageParam > 0 {
+		page = *pageParam
+	}
+
+	limit := defaultLimit
+	if limitParam != nil && *limitParam > 0 {
+		limit = *limitParam
+	}
+
+	return page, limit
+}
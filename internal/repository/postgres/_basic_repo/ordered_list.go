@@ -0,0 +1,103 @@
+package basic_repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// OrderedList is a list whose items are numbered from 1 by their position
+// column, without gaps, e.g. the movies of a collection. Its methods run
+// inside a transaction that called Lock first.
+type OrderedList struct {
+	// Items is a nil pointer to the item model, e.g.
+	// (*entity.CollectionItem)(nil).
+	Items interface{}
+	// Owner is a nil pointer to the model the list belongs to, whose row
+	// is locked while the list changes.
+	Owner interface{}
+	// OwnerColumn is the column of the items referencing the owner.
+	OwnerColumn string
+	OwnerID     int
+	// Resource names the owner in errors, e.g. "collection".
+	Resource string
+}
+
+// Lock serializes changes to the list by locking the owner row, and
+// returns the current number of items.
+func (l OrderedList) Lock(ctx context.Context, tx bun.Tx) (int, error) {
+	var id int
+	err := tx.NewSelect().
+		Model(l.Owner).
+		Column("id").
+		Where("id = ?", l.OwnerID).
+		For("UPDATE").
+		Scan(ctx, &id)
+	if err != nil {
+		return 0, DBError(err, l.Resource)
+	}
+
+	return tx.NewSelect().
+		Model(l.Items).
+		Where("? = ?", bun.Ident(l.OwnerColumn), l.OwnerID).
+		Count(ctx)
+}
+
+// Insert makes room for a new item at position and returns the position to
+// insert it at. A zero or too large position appends the item; otherwise
+// the items from that position on move down.
+func (l OrderedList) Insert(ctx context.Context, tx bun.Tx, size, position int) (int, error) {
+	if position < 1 || position > size {
+		return size + 1, nil
+	}
+
+	_, err := tx.NewUpdate().
+		Model(l.Items).
+		Set("position = position + 1").
+		Where("? = ? AND position >= ?", bun.Ident(l.OwnerColumn), l.OwnerID, position).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
+
+// Move shifts the items between the positions from and to, so that the
+// item at from can take to, and returns the position it takes. Positions
+// past the end of the list move it to the end.
+func (l OrderedList) Move(ctx context.Context, tx bun.Tx, size, from, to int) (int, error) {
+	if to > size {
+		to = size
+	}
+	if to == from {
+		return to, nil
+	}
+
+	shift := tx.NewUpdate().
+		Model(l.Items).
+		Where("? = ?", bun.Ident(l.OwnerColumn), l.OwnerID)
+	if to < from {
+		shift = shift.Set("position = position + 1").
+			Where("position >= ? AND position < ?", to, from)
+	} else {
+		shift = shift.Set("position = position - 1").
+			Where("position > ? AND position <= ?", from, to)
+	}
+	if _, err := shift.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return to, nil
+}
+
+// Close closes the gap an item removed from position left.
+func (l OrderedList) Close(ctx context.Context, tx bun.Tx, position int) error {
+	_, err := tx.NewUpdate().
+		Model(l.Items).
+		Set("position = position - 1").
+		Where("? = ? AND position > ?", bun.Ident(l.OwnerColumn), l.OwnerID, position).
+		Exec(ctx)
+
+	return err
+}
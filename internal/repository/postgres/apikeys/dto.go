@@ -0,0 +1,27 @@
+package apikeys
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read write"`
+	// ExpiresAt is optional; keys without it work until revoked.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+type CreatedAPIKeyResponse struct {
+	// Key is the secret itself, returned only when the key is created.
+	Key    string         `json:"key"`
+	APIKey APIKeyResponse `json:"api_key"`
+}
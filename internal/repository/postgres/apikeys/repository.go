@@ -0,0 +1,120 @@
+package apikeys
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/auth"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// lastUsedResolution is how stale last_used_at may get, so that busy keys
+// do not cost a write per request.
+const lastUsedResolution = time.Minute
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, key *entity.APIKey) error {
+	now := time.Now()
+	key.CreatedAt = &now
+
+	_, err := r.db.NewInsert().Model(key).Returning("id").Exec(ctx)
+	return basic_repo.DBError(err, "API key")
+}
+
+// GetAll lists the keys of the user, newest first, including revoked and
+// expired ones.
+func (r *Repository) GetAll(ctx context.Context, userID int) ([]*entity.APIKey, error) {
+	keys := []*entity.APIKey{}
+
+	err := r.db.NewSelect().
+		Model(&keys).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Scan(ctx)
+
+	return keys, err
+}
+
+// Revoke stops one of the user's keys from working.
+func (r *Repository) Revoke(ctx context.Context, userID, id int) error {
+	res, err := r.db.NewUpdate().
+		Model((*entity.APIKey)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperror.NotFound("API key not found")
+	}
+
+	return nil
+}
+
+// ValidateAPIKey implements auth.APIKeyValidator. The identity carries the
+// current role of the user, so role changes apply to their keys at once.
+func (r *Repository) ValidateAPIKey(ctx context.Context, prefix, keyHash string) (*auth.APIKeyIdentity, error) {
+	var key entity.APIKey
+
+	err := r.db.NewSelect().
+		Model(&key).
+		Relation("User").
+		Where("api_key.prefix = ?", prefix).
+		Where("api_key.revoked_at IS NULL").
+		Where("\"user\".deleted_at IS NULL").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(keyHash)) != 1 ||
+		(key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution {
+		_, err = r.db.NewUpdate().
+			Model((*entity.APIKey)(nil)).
+			Set("last_used_at = ?", now).
+			Where("id = ?", key.Id).
+			Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &auth.APIKeyIdentity{
+		KeyID:  key.Id,
+		UserID: key.UserId,
+		Email:  key.User.Email,
+		Role:   key.User.Role,
+		Scopes: key.Scopes,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package collections
+
+import "time"
+
+// ListFilter selects collections. OwnerID, MovieID and PublicOnly are set
+// by the controller from the route, not from the query string.
+type ListFilter struct {
+	Page  *int    `form:"page,default=1" binding:"min=1"`
+	Limit *int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Query *string `form:"query" binding:"omitempty,max=255"`
+	Sort  string  `form:"sort,default=-updated_at" binding:"oneof=name -name created_at -created_at updated_at -updated_at item_count -item_count"`
+
+	OwnerID    *int `form:"-"`
+	MovieID    *int `form:"-"`
+	PublicOnly bool `form:"-"`
+}
+
+type ItemFilter struct {
+	Page  *int `form:"page,default=1" binding:"min=1"`
+	Limit *int `form:"limit,default=50" binding:"min=1,max=100"`
+}
+
+type CreateCollectionRequest struct {
+	Name        *string `json:"name" binding:"required,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	Visibility  *string `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+}
+
+type UpdateCollectionRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	Visibility  *string `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+	// RotateShareToken invalidates the current share link.
+	RotateShareToken bool `json:"rotate_share_token"`
+}
+
+// CopyCollectionRequest names the copy. By default it is called
+// "<name> (copy)" and is private.
+type CopyCollectionRequest struct {
+	Name       *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Visibility *string `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+}
+
+type AddItemRequest struct {
+	MovieID *int `json:"movie_id" binding:"required,min=1"`
+	// Position inserts the movie at that place, shifting the rest down.
+	// The movie is appended when it is omitted.
+	Position *int    `json:"position" binding:"omitempty,min=1"`
+	Note     *string `json:"note" binding:"omitempty,max=1000"`
+}
+
+type UpdateItemRequest struct {
+	Position *int    `json:"position" binding:"omitempty,min=1"`
+	Note     *string `json:"note" binding:"omitempty,max=1000"`
+}
+
+// ReorderRequest lists every movie of the collection in its new order.
+type ReorderRequest struct {
+	MovieIDs []int `json:"movie_ids" binding:"required,dive,min=1"`
+}
+
+type CollectionOwner struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type CollectionResponse struct {
+	ID           int              `json:"id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description,omitempty"`
+	Visibility   string           `json:"visibility"`
+	Owner        *CollectionOwner `json:"owner,omitempty"`
+	ItemCount    int              `json:"item_count"`
+	CopiedFromID *int             `json:"copied_from_id,omitempty"`
+	// ShareToken is only shown to the owner.
+	ShareToken *string    `json:"share_token,omitempty"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+type ItemMovie struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Director string `json:"director"`
+	Year     int    `json:"year"`
+}
+
+type ItemResponse struct {
+	MovieID   int        `json:"movie_id"`
+	Position  int        `json:"position"`
+	Note      string     `json:"note,omitempty"`
+	Movie     *ItemMovie `json:"movie,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
@@ -0,0 +1,479 @@
+package collections
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// itemCountExpr counts the movies of the collection being selected.
+const itemCountExpr = "(SELECT COUNT(*) FROM collection_items AS ci WHERE ci.collection_id = collection.id)"
+
+var collectionSorts = basic_repo.Sorts{
+	Columns: map[string]string{
+		"name":       "collection.name",
+		"created_at": "collection.created_at",
+		"updated_at": "collection.updated_at",
+		"item_count": itemCountExpr,
+	},
+	Tiebreaker: "collection.id",
+	Default:    "-updated_at",
+}
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Create inserts the collection with a fresh share token.
+func (r *Repository) Create(ctx context.Context, collection *entity.Collection) error {
+	token, err := newShareToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	collection.ShareToken = token
+	collection.CreatedAt = &now
+	collection.UpdatedAt = &now
+
+	_, err = r.db.NewInsert().
+		Model(collection).
+		Returning("id").
+		Exec(ctx)
+
+	return basic_repo.DBError(err, "collection")
+}
+
+// GetByID returns the collection with its owner and number of movies.
+func (r *Repository) GetByID(ctx context.Context, id int) (*entity.Collection, error) {
+	collection := new(entity.Collection)
+
+	err := r.db.NewSelect().
+		Model(collection).
+		ColumnExpr("?TableColumns").
+		ColumnExpr(itemCountExpr+" AS item_count").
+		Relation("User").
+		Where("collection.id = ?", id).
+		Where(`"user".deleted_at IS NULL`).
+		Scan(ctx)
+	if err != nil {
+		return nil, basic_repo.DBError(err, "collection")
+	}
+
+	return collection, nil
+}
+
+// List returns a page of collections matching filter, most recently updated
+// first unless another sort is requested.
+func (r *Repository) List(ctx context.Context, filter ListFilter) ([]*entity.Collection, int, error) {
+	page, limit := basic_repo.Pagination(filter.Page, filter.Limit, 20)
+
+	var list []*entity.Collection
+
+	query := r.db.NewSelect().
+		Model(&list).
+		ColumnExpr("?TableColumns").
+		ColumnExpr(itemCountExpr + " AS item_count").
+		Relation("User").
+		Where(`"user".deleted_at IS NULL`)
+
+	if filter.OwnerID != nil {
+		query = query.Where("collection.user_id = ?", *filter.OwnerID)
+	}
+
+	if filter.PublicOnly {
+		query = query.Where("collection.visibility = ?", entity.VisibilityPublic)
+	}
+
+	if filter.MovieID != nil {
+		query = query.Where(`EXISTS (
+			SELECT 1 FROM collection_items AS ci
+			WHERE ci.collection_id = collection.id AND ci.movie_id = ?
+		)`, *filter.MovieID)
+	}
+
+	if filter.Query != nil && *filter.Query != "" {
+		query = query.Where("collection.name ILIKE ?", "%"+*filter.Query+"%")
+	}
+
+	count, err := query.
+		OrderExpr(collectionSorts.OrderBy(filter.Sort)).
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing collections: %w", err)
+	}
+
+	return list, count, nil
+}
+
+// Update saves the name, description and visibility of the collection, and
+// replaces its share token when rotateToken is set.
+func (r *Repository) Update(ctx context.Context, collection *entity.Collection, rotateToken bool) error {
+	columns := []string{"name", "description", "visibility", "updated_at"}
+
+	if rotateToken {
+		token, err := newShareToken()
+		if err != nil {
+			return err
+		}
+		collection.ShareToken = token
+		columns = append(columns, "share_token")
+	}
+
+	now := time.Now()
+	collection.UpdatedAt = &now
+
+	_, err := r.db.NewUpdate().
+		Model(collection).
+		Column(columns...).
+		WherePK().
+		Exec(ctx)
+
+	return basic_repo.DBError(err, "collection")
+}
+
+// Delete removes the collection and its items. Copies of it are kept.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.NewDelete().
+		Model((*entity.Collection)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return apperror.NotFound("Collection not found")
+	}
+
+	return nil
+}
+
+// GetItems returns a page of the collection's movies in list order.
+func (r *Repository) GetItems(ctx context.Context, collectionID int, filter ItemFilter) ([]*entity.CollectionItem, int, error) {
+	page, limit := basic_repo.Pagination(filter.Page, filter.Limit, 50)
+
+	var items []*entity.CollectionItem
+
+	count, err := r.db.NewSelect().
+		Model(&items).
+		Relation("Movie").
+		Where("collection_item.collection_id = ?", collectionID).
+		Where("movie.deleted_at IS NULL").
+		Order("collection_item.position ASC", "collection_item.id ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing collection items: %w", err)
+	}
+
+	return items, count, nil
+}
+
+// GetItem returns the movie's entry in the collection.
+func (r *Repository) GetItem(ctx context.Context, collectionID, movieID int) (*entity.CollectionItem, error) {
+	item := new(entity.CollectionItem)
+
+	err := r.db.NewSelect().
+		Model(item).
+		Relation("Movie").
+		Where("collection_item.collection_id = ? AND collection_item.movie_id = ?", collectionID, movieID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.NotFound("Movie is not in the collection")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// AddItem inserts item into its collection. A zero or too large Position
+// appends it; otherwise the movies from that position on move down.
+func (r *Repository) AddItem(ctx context.Context, item *entity.CollectionItem) error {
+	now := time.Now()
+	item.CreatedAt = &now
+	item.UpdatedAt = &now
+
+	list := itemList(item.CollectionId)
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		size, err := list.Lock(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		exists, err := tx.NewSelect().
+			Model((*entity.CollectionItem)(nil)).
+			Where("collection_id = ? AND movie_id = ?", item.CollectionId, item.MovieId).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return apperror.Conflict("Movie is already in the collection")
+		}
+
+		item.Position, err = list.Insert(ctx, tx, size, item.Position)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.NewInsert().Model(item).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		return touch(ctx, tx, item.CollectionId)
+	})
+
+	return basic_repo.DBError(err, "collection item")
+}
+
+// UpdateItem moves the movie to position, if given, and replaces its note,
+// if given. Positions past the end of the collection move it to the end.
+func (r *Repository) UpdateItem(ctx context.Context, collectionID, movieID int, position *int, note *string) error {
+	list := itemList(collectionID)
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		size, err := list.Lock(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		item := new(entity.CollectionItem)
+		err = tx.NewSelect().
+			Model(item).
+			Where("collection_id = ? AND movie_id = ?", collectionID, movieID).
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.NotFound("Movie is not in the collection")
+		}
+		if err != nil {
+			return err
+		}
+
+		if position != nil {
+			item.Position, err = list.Move(ctx, tx, size, item.Position, *position)
+			if err != nil {
+				return err
+			}
+		}
+
+		if note != nil {
+			item.Note = *note
+		}
+
+		now := time.Now()
+		item.UpdatedAt = &now
+
+		_, err = tx.NewUpdate().
+			Model(item).
+			Column("position", "note", "updated_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return touch(ctx, tx, collectionID)
+	})
+
+	return basic_repo.DBError(err, "collection item")
+}
+
+// RemoveItem deletes the movie from the collection and closes the gap it
+// leaves.
+func (r *Repository) RemoveItem(ctx context.Context, collectionID, movieID int) error {
+	list := itemList(collectionID)
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := list.Lock(ctx, tx); err != nil {
+			return err
+		}
+
+		var positions []int
+		_, err := tx.NewDelete().
+			Model((*entity.CollectionItem)(nil)).
+			Where("collection_id = ? AND movie_id = ?", collectionID, movieID).
+			Returning("position").
+			Exec(ctx, &positions)
+		if err != nil {
+			return err
+		}
+		if len(positions) == 0 {
+			return apperror.NotFound("Movie is not in the collection")
+		}
+
+		if err := list.Close(ctx, tx, positions[0]); err != nil {
+			return err
+		}
+
+		return touch(ctx, tx, collectionID)
+	})
+
+	return basic_repo.DBError(err, "collection item")
+}
+
+// Reorder puts the collection's movies in the order of movieIDs, which must
+// list each of them exactly once.
+func (r *Repository) Reorder(ctx context.Context, collectionID int, movieIDs []int) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := itemList(collectionID).Lock(ctx, tx); err != nil {
+			return err
+		}
+
+		var current []int
+		err := tx.NewSelect().
+			Model((*entity.CollectionItem)(nil)).
+			Column("collection_item.movie_id").
+			Join("JOIN movies AS m ON m.id = collection_item.movie_id").
+			Where("collection_item.collection_id = ?", collectionID).
+			Where("m.deleted_at IS NULL").
+			Scan(ctx, &current)
+		if err != nil {
+			return err
+		}
+
+		if !samePermutation(current, movieIDs) {
+			return apperror.InvalidField("movie_ids", "must list every movie of the collection exactly once")
+		}
+
+		_, err = tx.NewRaw(`
+			UPDATE collection_items AS ci
+			SET position = v.position, updated_at = ?
+			FROM unnest(?::int[]) WITH ORDINALITY AS v(movie_id, position)
+			WHERE ci.collection_id = ? AND ci.movie_id = v.movie_id`,
+			time.Now(), pgdialect.Array(movieIDs), collectionID,
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Movies deleted from the catalog are hidden from the collection;
+		// keep them after the visible ones.
+		_, err = tx.NewRaw(`
+			UPDATE collection_items AS ci
+			SET position = ? + h.n
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS n
+				FROM collection_items
+				WHERE collection_id = ? AND movie_id <> ALL(?::int[])
+			) AS h
+			WHERE ci.id = h.id`,
+			len(movieIDs), collectionID, pgdialect.Array(movieIDs),
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return touch(ctx, tx, collectionID)
+	})
+
+	return basic_repo.DBError(err, "collection")
+}
+
+// Copy creates a collection owned by collection.UserId with the movies and notes
+// of the source collection, in the same order.
+func (r *Repository) Copy(ctx context.Context, sourceID int, collection *entity.Collection) error {
+	token, err := newShareToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	collection.ShareToken = token
+	collection.CopiedFromId = &sourceID
+	collection.CreatedAt = &now
+	collection.UpdatedAt = &now
+
+	err = r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(collection).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.NewRaw(`
+			INSERT INTO collection_items (collection_id, movie_id, position, note, created_at, updated_at)
+			SELECT ?, ci.movie_id, ROW_NUMBER() OVER (ORDER BY ci.position, ci.id), ci.note, ?, ?
+			FROM collection_items AS ci
+			JOIN movies AS m ON m.id = ci.movie_id AND m.deleted_at IS NULL
+			WHERE ci.collection_id = ?`,
+			collection.Id, now, now, sourceID,
+		).Exec(ctx)
+		return err
+	})
+
+	return basic_repo.DBError(err, "collection")
+}
+
+// itemList is the ordered list of the collection's movies, locked through
+// the collection row.
+func itemList(collectionID int) basic_repo.OrderedList {
+	return basic_repo.OrderedList{
+		Items:       (*entity.CollectionItem)(nil),
+		Owner:       (*entity.Collection)(nil),
+		OwnerColumn: "collection_id",
+		OwnerID:     collectionID,
+		Resource:    "collection",
+	}
+}
+
+// touch marks the collection as updated after a change to its items.
+func touch(ctx context.Context, tx bun.Tx, collectionID int) error {
+	_, err := tx.NewUpdate().
+		Model((*entity.Collection)(nil)).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", collectionID).
+		Exec(ctx)
+
+	return err
+}
+
+func samePermutation(current, proposed []int) bool {
+	if len(current) != len(proposed) {
+		return false
+	}
+
+	seen := make(map[int]bool, len(current))
+	for _, id := range current {
+		seen[id] = true
+	}
+
+	for _, id := range proposed {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+
+	return true
+}
+
+// newShareToken returns a random, URL-safe token for share links.
+func newShareToken() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
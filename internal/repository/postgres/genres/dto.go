@@ -0,0 +1,21 @@
+package genres
+
+import "time"
+
+type CreateGenreRequest struct {
+	Name        *string `json:"name" binding:"required,max=100"`
+	Description *string `json:"description"`
+}
+
+type UpdateGenreRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description"`
+}
+
+type GenreResponse struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
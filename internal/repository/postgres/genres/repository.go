@@ -0,0 +1,141 @@
+package genres
+
+import (
+	"Movies-Go/internal/entity"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"Movies-Go/internal/repository/postgres/movies"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, genre *entity.Genre) error {
+	now := time.Now()
+	genre.CreatedAt = &now
+	genre.UpdatedAt = &now
+
+	_, err := r.db.NewInsert().Model(genre).Exec(ctx)
+	return basic_repo.DBError(err, "genre")
+}
+
+func (r *Repository) GetAll(ctx context.Context) ([]*entity.Genre, error) {
+	var genres []*entity.Genre
+
+	err := r.db.NewSelect().
+		Model(&genres).
+		Where("deleted_at IS NULL").
+		Order("name ASC").
+		Scan(ctx)
+
+	return genres, err
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*entity.Genre, error) {
+	genre := new(entity.Genre)
+
+	err := r.db.NewSelect().
+		Model(genre).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, basic_repo.DBError(err, "genre")
+	}
+
+	return genre, nil
+}
+
+func (r *Repository) GetByName(ctx context.Context, name string) (*entity.Genre, error) {
+	genre := new(entity.Genre)
+
+	err := r.db.NewSelect().
+		Model(genre).
+		Where("LOWER(name) = LOWER(?) AND deleted_at IS NULL", name).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, basic_repo.DBError(err, "genre")
+	}
+
+	return genre, nil
+}
+
+func (r *Repository) Update(ctx context.Context, genre *entity.Genre) error {
+	now := time.Now()
+	genre.UpdatedAt = &now
+
+	_, err := r.db.NewUpdate().
+		Model(genre).
+		Where("id = ? AND deleted_at IS NULL", genre.Id).
+		Exec(ctx)
+
+	return basic_repo.DBError(err, "genre")
+}
+
+// Delete soft-deletes the genre and detaches it from every movie tagged with it.
+func (r *Repository) Delete(ctx context.Context, data basic_repo.Delete) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*entity.MovieGenre)(nil)).
+			Where("genre_id = ?", *data.Id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*entity.Genre)(nil)).
+			Set("deleted_at = ?", time.Now()).
+			Where("id = ? AND deleted_at IS NULL", *data.Id).
+			Exec(ctx)
+
+		return err
+	})
+
+	return basic_repo.DBError(err, "genre")
+}
+
+// GetMovies returns a page of the movies tagged with the given genre.
+func (r *Repository) GetMovies(ctx context.Context, genreID int, filter movies.Filter) ([]*entity.Movie, int, error) {
+	page := 1
+	if filter.Page != nil && *filter.Page > 0 {
+		page = *filter.Page
+	}
+
+	limit := 10
+	if filter.Limit != nil && *filter.Limit > 0 {
+		limit = *filter.Limit
+	}
+
+	var list []*entity.Movie
+
+	count, err := r.db.NewSelect().
+		Model(&list).
+		Relation("Genres", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("?TableAlias.deleted_at IS NULL")
+		}).
+		Join("JOIN movie_genres AS mg ON mg.movie_id = movie.id").
+		Where("mg.genre_id = ?", genreID).
+		Where("movie.deleted_at IS NULL").
+		Order("movie.id ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing movies for genre: %w", err)
+	}
+
+	return list, count, nil
+}
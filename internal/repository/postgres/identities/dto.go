@@ -0,0 +1,20 @@
+package identities
+
+type ProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type AuthorizationResponse struct {
+	// AuthorizationURL is where to send the browser to sign in.
+	AuthorizationURL string `json:"authorization_url"`
+	// FlowToken is kept by the client and sent back with the code.
+	FlowToken string `json:"flow_token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+type CallbackRequest struct {
+	Code      string `json:"code" binding:"required"`
+	State     string `json:"state" binding:"required"`
+	FlowToken string `json:"flow_token" binding:"required"`
+}
@@ -0,0 +1,96 @@
+package identities
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrAlreadyLinked is returned when a user already has another identity at
+// the provider.
+var ErrAlreadyLinked = apperror.Conflict("The account is already linked to another identity at this provider")
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// SignIn returns the user the identity is linked to and records the time
+// of the login.
+func (r *Repository) SignIn(ctx context.Context, provider, subject string) (*entity.User, error) {
+	var identity entity.UserIdentity
+
+	err := r.db.NewSelect().
+		Model(&identity).
+		Relation("User").
+		Where("user_identity.provider = ?", provider).
+		Where("user_identity.subject = ?", subject).
+		Where("\"user\".deleted_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		return nil, basic_repo.DBError(err, "identity")
+	}
+
+	_, err = r.db.NewUpdate().
+		Model((*entity.UserIdentity)(nil)).
+		Set("last_login_at = ?", time.Now()).
+		Where("id = ?", identity.Id).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return identity.User, nil
+}
+
+// Link links the identity to an existing user.
+func (r *Repository) Link(ctx context.Context, userID int, provider, subject, email string) error {
+	return insert(ctx, r.db, userID, provider, subject, email)
+}
+
+// Provision creates the user together with their identity.
+func (r *Repository) Provision(ctx context.Context, user *entity.User, provider, subject string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		user.CreatedAt = &now
+		user.UpdatedAt = &now
+
+		if _, err := tx.NewInsert().Model(user).Exec(ctx); err != nil {
+			return basic_repo.DBError(err, "user")
+		}
+
+		return insert(ctx, tx, user.Id, provider, subject, user.Email)
+	})
+}
+
+func insert(ctx context.Context, db bun.IDB, userID int, provider, subject, email string) error {
+	now := time.Now()
+
+	_, err := db.NewInsert().
+		Model(&entity.UserIdentity{
+			UserId:      userID,
+			Provider:    provider,
+			Subject:     subject,
+			Email:       &email,
+			LastLoginAt: &now,
+			CreatedAt:   &now,
+		}).
+		Exec(ctx)
+
+	err = basic_repo.DBError(err, "identity")
+	if errors.Is(err, apperror.ErrConflict) {
+		return ErrAlreadyLinked
+	}
+
+	return err
+}
@@ -3,31 +3,53 @@ package movies
 import "time"
 
 type CreateMovieRequest struct {
-	Title    *string  `json:"title" binding:"required"`
-	Director *string  `json:"director" binding:"required"`
-	Year     *int     `json:"year" binding:"required,min=1800,max=2100"`
-	Plot     *string  `json:"plot"`
-	Rating   *float64 `json:"rating" binding:"min=0,max=10"`
+	ExternalID *string `json:"external_id" binding:"omitempty,max=255"`
+	Title      *string `json:"title" binding:"required"`
+	Director   *string `json:"director"`
+	Year       *int    `json:"year" binding:"required,min=1800,max=2100"`
+	Plot       *string `json:"plot"`
+	GenreIDs   []int   `json:"genre_ids" binding:"omitempty,dive,min=1"`
 }
 
 type UpdateMovieRequest struct {
-	Id       *int     `json:"id" form:"id"`
-	Title    *string  `json:"title"`
-	Director *string  `json:"director"`
-	Year     *int     `json:"year" binding:"omitempty,min=1800,max=2100"`
-	Plot     *string  `json:"plot"`
-	Rating   *float64 `json:"rating" binding:"omitempty,min=0,max=10"`
+	Id         *int    `json:"id" form:"id"`
+	ExternalID *string `json:"external_id" binding:"omitempty,max=255"`
+	Title      *string `json:"title"`
+	Director   *string `json:"director"`
+	Year       *int    `json:"year" binding:"omitempty,min=1800,max=2100"`
+	Plot       *string `json:"plot"`
+	GenreIDs   []int   `json:"genre_ids" binding:"omitempty,dive,min=1"`
 }
 
 type MovieResponse struct {
-	ID        *int       `json:"id"`
-	Title     *string    `json:"title"`
-	Director  *string    `json:"director"`
-	Year      *int       `json:"year"`
-	Plot      *string    `json:"plot,omitempty"`
-	Rating    *float64   `json:"rating"`
-	CreatedAt *time.Time `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID             *int       `json:"id"`
+	ExternalID     *string    `json:"external_id,omitempty"`
+	Title          *string    `json:"title"`
+	Director       *string    `json:"director"`
+	Year           *int       `json:"year"`
+	Plot           *string    `json:"plot,omitempty"`
+	Rating         *float64   `json:"rating"`
+	VoteCount      *int       `json:"vote_count"`
+	WeightedRating *float64   `json:"weighted_rating"`
+	Genres         []*Genre   `json:"genres"`
+	CreatedAt      *time.Time `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+	InWatchlist    *bool      `json:"in_watchlist,omitempty"`
+	Watched        *bool      `json:"watched,omitempty"`
+
+	Rank       *float64         `json:"rank,omitempty"`
+	Highlights *MovieHighlights `json:"highlights,omitempty"`
+}
+
+// MovieHighlights holds search snippets with matched terms wrapped in <mark>.
+type MovieHighlights struct {
+	Title string `json:"title"`
+	Plot  string `json:"plot,omitempty"`
+}
+
+type Genre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
 type Filter struct {
@@ -39,6 +61,27 @@ type SearchMovieRequest struct {
 	Query *string `form:"query" binding:"required"`
 	Page  *int    `form:"page,default=1" binding:"min=1"`
 	Limit *int    `form:"limit,default=10" binding:"min=1,max=100"`
+	// Cursor is a next_cursor or prev_cursor token from a previous response.
+	// When set it takes precedence over Page.
+	Cursor *string `form:"cursor"`
+	MovieFilter
+}
+
+// MovieFilter holds the structured filters and sort order accepted by
+// GET /movies and GET /movies/search. Date ranges are inclusive and use the
+// YYYY-MM-DD format.
+type MovieFilter struct {
+	YearFrom    *int       `form:"year_from" binding:"omitempty,min=1800,max=2100"`
+	YearTo      *int       `form:"year_to" binding:"omitempty,min=1800,max=2100"`
+	RatingMin   *float64   `form:"rating_min" binding:"omitempty,min=0,max=10"`
+	RatingMax   *float64   `form:"rating_max" binding:"omitempty,min=0,max=10"`
+	Director    *string    `form:"director" binding:"omitempty,max=255"`
+	Genre       *string    `form:"genre" binding:"omitempty,max=255"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02"`
+	UpdatedFrom *time.Time `form:"updated_from" time_format:"2006-01-02"`
+	UpdatedTo   *time.Time `form:"updated_to" time_format:"2006-01-02"`
+	Sort        *string    `form:"sort"`
 }
 
 type SearchMovieResponse struct {
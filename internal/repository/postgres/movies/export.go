@@ -0,0 +1,113 @@
+package movies
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/cursor"
+	"context"
+	"database/sql"
+	"net/url"
+
+	"github.com/uptrace/bun"
+)
+
+// exportBatchSize is the number of movies fetched from the export cursor at
+// a time.
+const exportBatchSize = 500
+
+// exportParams are the query parameters understood by GET /movies/export.
+var exportParams = []string{
+	"format", "query",
+	"year_from", "year_to",
+	"rating_min", "rating_max",
+	"director", "genre",
+	"created_from", "created_to",
+	"updated_from", "updated_to",
+	"sort",
+}
+
+// CheckExportParams rejects query parameters GET /movies/export does not
+// understand.
+func CheckExportParams(values url.Values) error {
+	return checkParams(values, exportParams)
+}
+
+// Export passes every movie matching filter, in its sort order and with its
+// genres, to fn in batches. The movies are read through a server-side cursor
+// inside a read-only transaction, so the result is a consistent snapshot
+// that is never held in memory as a whole. Page, Limit and Cursor are
+// ignored. Export stops at the first error returned by fn.
+func (r *Repository) Export(ctx context.Context, filter SearchMovieRequest, fn func([]*entity.Movie) error) error {
+	_, keys, err := filter.sortOrder()
+	if err != nil {
+		return err
+	}
+
+	query := r.db.NewSelect().
+		Model((*entity.Movie)(nil)).
+		Apply(filter.where).
+		OrderExpr(cursor.OrderBy(keys, false))
+
+	return r.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewRaw("DECLARE movie_export NO SCROLL CURSOR FOR ?", query).Exec(ctx); err != nil {
+			return err
+		}
+
+		for {
+			var batch []*entity.Movie
+			if err := tx.NewRaw("FETCH FORWARD ? FROM movie_export", exportBatchSize).Scan(ctx, &batch); err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+
+			if err := loadGenres(ctx, tx, batch); err != nil {
+				return err
+			}
+
+			if err := fn(batch); err != nil {
+				return err
+			}
+
+			if len(batch) < exportBatchSize {
+				return nil
+			}
+		}
+	})
+}
+
+type movieGenreLink struct {
+	MovieId int    `bun:"movie_id"`
+	Id      int    `bun:"id"`
+	Name    string `bun:"name"`
+}
+
+// loadGenres sets the active genres of the movies.
+func loadGenres(ctx context.Context, db bun.IDB, list []*entity.Movie) error {
+	ids := make([]int, 0, len(list))
+	byID := make(map[int]*entity.Movie, len(list))
+	for _, movie := range list {
+		movie.Genres = []*entity.Genre{}
+		ids = append(ids, movie.Id)
+		byID[movie.Id] = movie
+	}
+
+	var links []movieGenreLink
+	err := db.NewSelect().
+		TableExpr("movie_genres AS mg").
+		Join("JOIN genres AS g ON g.id = mg.genre_id AND g.deleted_at IS NULL").
+		ColumnExpr("mg.movie_id, g.id, g.name").
+		Where("mg.movie_id IN (?)", bun.In(ids)).
+		OrderExpr("g.name ASC").
+		Scan(ctx, &links)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		movie := byID[link.MovieId]
+		movie.Genres = append(movie.Genres, &entity.Genre{Id: link.Id, Name: link.Name})
+	}
+
+	return nil
+}
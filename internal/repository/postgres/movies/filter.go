@@ -0,0 +1,323 @@
+package movies
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/cursor"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// filterError reports an invalid or unknown movie list parameter together
+// with the values that would have been accepted.
+func filterError(field, message string, allowed ...string) *apperror.Error {
+	e := apperror.InvalidField(field, message)
+	if len(allowed) > 0 {
+		e.With("allowed", allowed)
+	}
+
+	return e
+}
+
+// sortColumn describes a public sort key: the expression it orders by and
+// how to read its value from a fetched movie when building cursors.
+type sortColumn struct {
+	expr  string
+	value func(m *entity.Movie) interface{}
+}
+
+var sortColumns = map[string]sortColumn{
+	"id":              {"movie.id", func(m *entity.Movie) interface{} { return m.Id }},
+	"title":           {"movie.title", func(m *entity.Movie) interface{} { return m.Title }},
+	"year":            {"movie.year", func(m *entity.Movie) interface{} { return m.Year }},
+	"rating":          {"movie.rating", func(m *entity.Movie) interface{} { return m.Rating }},
+	"weighted_rating": {"movie.weighted_rating", func(m *entity.Movie) interface{} { return m.WeightedRating }},
+	"vote_count":      {"movie.vote_count", func(m *entity.Movie) interface{} { return m.VoteCount }},
+	"created_at":      {"movie.created_at", func(m *entity.Movie) interface{} { return timeValue(m.CreatedAt) }},
+	"updated_at":      {"movie.updated_at", func(m *entity.Movie) interface{} { return timeValue(m.UpdatedAt) }},
+	"relevance":       {"ts_rank(movie.search_vector, search_query)", func(m *entity.Movie) interface{} { return m.SearchRank }},
+}
+
+// listParams are the query parameters understood by GET /movies.
+var listParams = []string{
+	"page", "limit", "query",
+	"year_from", "year_to",
+	"rating_min", "rating_max",
+	"director", "genre",
+	"created_from", "created_to",
+	"updated_from", "updated_to",
+	"sort", "cursor",
+}
+
+// SortFields returns the allowed sort keys in alphabetical order.
+func SortFields() []string {
+	fields := make([]string, 0, len(sortColumns))
+	for field := range sortColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// CheckParams rejects query parameters GET /movies does not understand, so
+// that typos are reported instead of silently ignored.
+func CheckParams(values url.Values) error {
+	return checkParams(values, listParams)
+}
+
+func checkParams(values url.Values, allowed []string) error {
+	for key := range values {
+		known := false
+		for _, param := range allowed {
+			if key == param {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return filterError(key, "unknown query parameter", allowed...)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the cross-field rules binding tags cannot express.
+func (r SearchMovieRequest) Validate() error {
+	f := r.MovieFilter
+
+	if f.YearFrom != nil && f.YearTo != nil && *f.YearFrom > *f.YearTo {
+		return filterError("year_from", "must not be greater than year_to")
+	}
+
+	if f.RatingMin != nil && f.RatingMax != nil && *f.RatingMin > *f.RatingMax {
+		return filterError("rating_min", "must not be greater than rating_max")
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return filterError("created_from", "must not be after created_to")
+	}
+
+	if f.UpdatedFrom != nil && f.UpdatedTo != nil && f.UpdatedFrom.After(*f.UpdatedTo) {
+		return filterError("updated_from", "must not be after updated_to")
+	}
+
+	names, keys, err := r.sortOrder()
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.position(names, keys)
+	return err
+}
+
+// searching reports whether the request carries a full-text query.
+func (r SearchMovieRequest) searching() bool {
+	return r.Query != nil && strings.TrimSpace(*r.Query) != ""
+}
+
+// where restricts q to the non-deleted movies matching the filters and, for
+// searches, the full-text query, which it exposes as search_query.
+func (r SearchMovieRequest) where(q *bun.SelectQuery) *bun.SelectQuery {
+	q = q.Where("movie.deleted_at IS NULL")
+	q = r.MovieFilter.apply(q)
+
+	if r.searching() {
+		q = q.
+			TableExpr("websearch_to_tsquery(?, ?) AS search_query", searchConfig, strings.TrimSpace(*r.Query)).
+			Where("movie.search_vector @@ search_query")
+	}
+
+	return q
+}
+
+// sortOrder parses the comma-separated sort parameter, where a leading "-"
+// means descending, into keyset keys and their public names. Results always
+// end with movie.id so that pages and cursors are stable. Without an
+// explicit sort, searches are ordered by relevance and listings by id.
+func (r SearchMovieRequest) sortOrder() ([]string, []cursor.Key, error) {
+	var fields []string
+	if r.Sort != nil && strings.TrimSpace(*r.Sort) != "" {
+		fields = strings.Split(*r.Sort, ",")
+	} else if r.searching() {
+		fields = []string{"-relevance"}
+	}
+
+	var names []string
+	var keys []cursor.Key
+	seen := make(map[string]bool)
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+
+		desc := strings.HasPrefix(field, "-")
+		name := strings.TrimPrefix(field, "-")
+
+		column, ok := sortColumns[name]
+		if !ok {
+			return nil, nil, filterError("sort", strconv.Quote(name)+" is not a sortable field", SortFields()...)
+		}
+
+		if name == "relevance" && !r.searching() {
+			return nil, nil, filterError("sort", "relevance requires a query")
+		}
+
+		if seen[name] {
+			return nil, nil, filterError("sort", strconv.Quote(name)+" is listed more than once")
+		}
+		seen[name] = true
+
+		names = append(names, name)
+		keys = append(keys, cursor.Key{Column: column.expr, Desc: desc})
+	}
+
+	if !seen["id"] {
+		names = append(names, "id")
+		keys = append(keys, cursor.Key{Column: sortColumns["id"].expr})
+	}
+
+	return names, keys, nil
+}
+
+// position decodes the request's cursor, checking that it was issued for
+// the same sort order, and returns the boundary values typed like the
+// corresponding sort columns.
+func (r SearchMovieRequest) position(names []string, keys []cursor.Key) (*cursor.Cursor, []interface{}, error) {
+	if r.Cursor == nil || *r.Cursor == "" {
+		return nil, nil, nil
+	}
+
+	c, err := cursor.Decode(*r.Cursor)
+	if err != nil {
+		return nil, nil, filterError("cursor", "is invalid or has been tampered with")
+	}
+
+	if c.Sort != cursor.Spec(names, keys) {
+		return nil, nil, filterError("cursor", "was issued for a different sort order")
+	}
+
+	targets := make([]reflect.Value, len(names))
+	dest := make([]interface{}, len(names))
+	for i, name := range names {
+		targets[i] = reflect.New(reflect.TypeOf(sortColumns[name].value(&entity.Movie{})))
+		dest[i] = targets[i].Interface()
+	}
+
+	if err := c.Scan(dest...); err != nil {
+		return nil, nil, filterError("cursor", "is invalid or has been tampered with")
+	}
+
+	values := make([]interface{}, len(names))
+	for i := range targets {
+		values[i] = targets[i].Elem().Interface()
+	}
+
+	return &c, values, nil
+}
+
+// cursorFor returns the signed token positioned on movie.
+func cursorFor(movie *entity.Movie, names []string, keys []cursor.Key, backward bool) (string, error) {
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = sortColumns[name].value(movie)
+	}
+
+	c, err := cursor.New(cursor.Spec(names, keys), values, backward)
+	if err != nil {
+		return "", err
+	}
+
+	return cursor.Encode(c)
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
+
+// apply adds the filter's WHERE clauses to q.
+func (f MovieFilter) apply(q *bun.SelectQuery) *bun.SelectQuery {
+	if f.YearFrom != nil {
+		q = q.Where("movie.year >= ?", *f.YearFrom)
+	}
+
+	if f.YearTo != nil {
+		q = q.Where("movie.year <= ?", *f.YearTo)
+	}
+
+	if f.RatingMin != nil {
+		q = q.Where("movie.rating >= ?", *f.RatingMin)
+	}
+
+	if f.RatingMax != nil {
+		q = q.Where("movie.rating <= ?", *f.RatingMax)
+	}
+
+	if f.Director != nil && strings.TrimSpace(*f.Director) != "" {
+		q = q.Where(`EXISTS (
+			SELECT 1 FROM movie_credits AS c
+			JOIN people AS p ON p.id = c.person_id AND p.deleted_at IS NULL
+			WHERE c.movie_id = movie.id AND c.role = 'director' AND p.name ILIKE ?
+		)`, "%"+strings.TrimSpace(*f.Director)+"%")
+	}
+
+	if f.Genre != nil && strings.TrimSpace(*f.Genre) != "" {
+		ids, names := splitGenres(*f.Genre)
+		q = q.Where(`EXISTS (
+			SELECT 1 FROM movie_genres AS mg
+			JOIN genres AS g ON g.id = mg.genre_id AND g.deleted_at IS NULL
+			WHERE mg.movie_id = movie.id AND (g.id IN (?) OR LOWER(g.name) IN (?))
+		)`, bun.In(ids), bun.In(names))
+	}
+
+	if f.CreatedFrom != nil {
+		q = q.Where("movie.created_at >= ?", *f.CreatedFrom)
+	}
+
+	if f.CreatedTo != nil {
+		q = q.Where("movie.created_at < ?", f.CreatedTo.Add(24*time.Hour))
+	}
+
+	if f.UpdatedFrom != nil {
+		q = q.Where("movie.updated_at >= ?", *f.UpdatedFrom)
+	}
+
+	if f.UpdatedTo != nil {
+		q = q.Where("movie.updated_at < ?", f.UpdatedTo.Add(24*time.Hour))
+	}
+
+	return q
+}
+
+// splitGenres separates the comma-separated genre ids and case-insensitive
+// names in value.
+func splitGenres(value string) ([]int, []string) {
+	var ids []int
+	var names []string
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if id, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, id)
+		} else {
+			names = append(names, strings.ToLower(part))
+		}
+	}
+
+	return ids, names
+}
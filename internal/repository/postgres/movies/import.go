@@ -0,0 +1,266 @@
+package movies
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ImportRow is one movie read from an import file.
+type ImportRow struct {
+	// Line is the line of the file the row starts on.
+	Line int
+	CreateMovieRequest
+	// Genres names genres in addition to GenreIDs, case-insensitively.
+	Genres []string
+	// Errors holds the problems found while parsing and validating the row.
+	// Rows with errors are reported without being written.
+	Errors []apperror.FieldError
+}
+
+// ImportOptions control how rows are written.
+type ImportOptions struct {
+	// DryRun validates every row against the database and rolls back.
+	DryRun bool `form:"dry_run"`
+	// Upsert updates the movie with the row's external_id if there is one,
+	// instead of reporting the row as a conflict.
+	Upsert bool `form:"upsert"`
+	// BatchSize commits every BatchSize rows, keeping the valid rows of a
+	// batch even when others fail. Zero imports all rows in one transaction
+	// that is rolled back if any row fails.
+	BatchSize int `form:"batch_size" binding:"min=0,max=1000"`
+}
+
+// ImportRequest holds the query parameters of POST /movies/import. Format
+// overrides the format detected from the upload.
+type ImportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	ImportOptions
+}
+
+// ImportReport summarizes an import. Created and Updated count the rows that
+// were written, or would have been when Committed is false.
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Committed bool             `json:"committed"`
+	Total     int              `json:"total"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Failed    int              `json:"failed"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// ImportRowError lists why a row was rejected.
+type ImportRowError struct {
+	Line       int                   `json:"line"`
+	ExternalID *string               `json:"external_id,omitempty"`
+	Errors     []apperror.FieldError `json:"errors"`
+}
+
+func (r *ImportReport) fail(row *ImportRow, errs []apperror.FieldError) {
+	r.Failed++
+	r.Errors = append(r.Errors, ImportRowError{
+		Line:       row.Line,
+		ExternalID: row.ExternalID,
+		Errors:     errs,
+	})
+}
+
+// Import writes rows as movies and reports the rows that could not be
+// written. Each row runs in its own savepoint, so a failing row does not
+// abort the rows around it. Rows are expected to satisfy the
+// CreateMovieRequest rules already, see movieimport.Parse. An error is
+// returned only when the import could not run at all, e.g. because the
+// database is unreachable.
+func (r *Repository) Import(ctx context.Context, rows []ImportRow, opts ImportOptions) (ImportReport, error) {
+	report := ImportReport{
+		DryRun: opts.DryRun,
+		Total:  len(rows),
+		Errors: []ImportRowError{},
+	}
+
+	genres, err := r.genreIDsByName(ctx)
+	if err != nil {
+		return report, basic_repo.DBError(err, "genre")
+	}
+
+	atomic := opts.BatchSize <= 0
+	size := opts.BatchSize
+	if atomic {
+		size = len(rows)
+	}
+
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		if err := r.importBatch(ctx, rows[start:end], opts, genres, &report); err != nil {
+			return report, err
+		}
+	}
+
+	report.Committed = !opts.DryRun && !(atomic && report.Failed > 0)
+
+	return report, nil
+}
+
+func (r *Repository) importBatch(ctx context.Context, rows []ImportRow, opts ImportOptions, genres map[string]int, report *ImportReport) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	failed := report.Failed
+	for i := range rows {
+		row := &rows[i]
+		if len(row.Errors) > 0 {
+			report.fail(row, row.Errors)
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+			return err
+		}
+
+		created, err := importRow(ctx, tx, row, opts.Upsert, genres)
+		if err != nil {
+			var appErr *apperror.Error
+			if !errors.As(err, &appErr) {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); err != nil {
+				return err
+			}
+			report.fail(row, fieldErrors(appErr))
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT import_row"); err != nil {
+			return err
+		}
+		if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	if opts.DryRun || (opts.BatchSize <= 0 && report.Failed > failed) {
+		return tx.Rollback()
+	}
+
+	return tx.Commit()
+}
+
+// importRow creates the row's movie, or updates the one with the same
+// external_id when upsert is set. It reports whether a movie was created.
+func importRow(ctx context.Context, tx bun.Tx, row *ImportRow, upsert bool, genres map[string]int) (bool, error) {
+	genreIDs, err := rowGenreIDs(row, genres)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	movie := &entity.Movie{
+		ExternalId: row.ExternalID,
+		Title:      *row.Title,
+		Year:       *row.Year,
+		CreatedAt:  &now,
+		UpdatedAt:  &now,
+	}
+	if row.Director != nil {
+		movie.Director = *row.Director
+	}
+	if row.Plot != nil {
+		movie.Plot = *row.Plot
+	}
+
+	if row.ExternalID != nil {
+		existing := new(entity.Movie)
+		err := tx.NewSelect().
+			Model(existing).
+			Column("id", "created_at").
+			Where("external_id = ? AND deleted_at IS NULL", *row.ExternalID).
+			For("UPDATE").
+			Scan(ctx)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return false, err
+		case !upsert:
+			return false, apperror.InvalidField("external_id", "a movie with this external_id already exists")
+		default:
+			movie.Id = existing.Id
+			movie.CreatedAt = existing.CreatedAt
+			return false, basic_repo.DBError(updateMovie(ctx, tx, movie, genreIDs), "movie")
+		}
+	}
+
+	return true, basic_repo.DBError(insertMovie(ctx, tx, movie, genreIDs), "movie")
+}
+
+// rowGenreIDs merges the row's genre ids with the ids of the genres it names.
+// It returns nil when the row lists no genres, so that upserts keep the
+// current ones.
+func rowGenreIDs(row *ImportRow, genres map[string]int) ([]int, error) {
+	if len(row.GenreIDs) == 0 && len(row.Genres) == 0 {
+		return nil, nil
+	}
+
+	ids := append([]int{}, row.GenreIDs...)
+	var unknown []string
+	for _, name := range row.Genres {
+		id, ok := genres[strings.ToLower(name)]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	if len(unknown) > 0 {
+		return nil, apperror.InvalidField("genres", fmt.Sprintf("unknown genres: %s", strings.Join(unknown, ", ")))
+	}
+
+	return ids, nil
+}
+
+// genreIDsByName maps the lowercased names of the active genres to their ids.
+func (r *Repository) genreIDsByName(ctx context.Context) (map[string]int, error) {
+	var genres []*entity.Genre
+	err := r.db.NewSelect().
+		Model(&genres).
+		Column("id", "name").
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int, len(genres))
+	for _, genre := range genres {
+		ids[strings.ToLower(genre.Name)] = genre.Id
+	}
+
+	return ids, nil
+}
+
+func fieldErrors(err *apperror.Error) []apperror.FieldError {
+	if len(err.Fields) > 0 {
+		return err.Fields
+	}
+
+	return []apperror.FieldError{{Message: err.Message}}
+}
@@ -2,8 +2,12 @@ package movies
 
 import (
 	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/cursor"
 	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,6 +15,15 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// searchConfig is the text search configuration used to build
+// movies.search_vector; queries must be parsed with the same one.
+const searchConfig = "english"
+
+const (
+	titleHeadlineOptions = "HighlightAll=true, StartSel=<mark>, StopSel=</mark>"
+	plotHeadlineOptions  = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
 type Repository struct {
 	db *bun.DB
 }
@@ -21,13 +34,29 @@ func NewRepository(db *bun.DB) *Repository {
 	}
 }
 
-func (r *Repository) Create(ctx context.Context, movie *entity.Movie) error {
+// Create inserts the movie and tags it with genreIDs in a single transaction.
+func (r *Repository) Create(ctx context.Context, movie *entity.Movie, genreIDs []int) error {
 	now := time.Now()
 	movie.CreatedAt = &now
 	movie.UpdatedAt = &now
 
-	_, err := r.db.NewInsert().Model(movie).Exec(ctx)
-	return err
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return insertMovie(ctx, tx, movie, genreIDs)
+	})
+
+	return basic_repo.DBError(err, "movie")
+}
+
+func insertMovie(ctx context.Context, tx bun.Tx, movie *entity.Movie, genreIDs []int) error {
+	if _, err := tx.NewInsert().Model(movie).Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := setDirectors(ctx, tx, movie); err != nil {
+		return err
+	}
+
+	return setGenres(ctx, tx, movie, genreIDs)
 }
 
 func (r *Repository) GetByID(ctx context.Context, id int) (*entity.Movie, error) {
@@ -35,33 +64,238 @@ func (r *Repository) GetByID(ctx context.Context, id int) (*entity.Movie, error)
 
 	err := r.db.NewSelect().
 		Model(movie).
-		Where("id = ? AND deleted_at IS NULL", id).
+		Relation("Genres", activeGenres).
+		Relation("Credits", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("?TableAlias.billing_order ASC, ?TableAlias.id ASC")
+		}).
+		Relation("Credits.Person").
+		Where("movie.id = ? AND movie.deleted_at IS NULL", id).
 		Scan(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, basic_repo.DBError(err, "movie")
 	}
 
 	return movie, nil
 }
 
-func (r *Repository) Update(ctx context.Context, movie *entity.Movie) error {
+// Update saves the movie. A nil genreIDs leaves the genre tags untouched,
+// while an empty slice removes all of them. The rating aggregates are owned
+// by the reviews repository and are never written here.
+func (r *Repository) Update(ctx context.Context, movie *entity.Movie, genreIDs []int) error {
 	now := time.Now()
 	movie.UpdatedAt = &now
 
-	_, err := r.db.NewUpdate().
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return updateMovie(ctx, tx, movie, genreIDs)
+	})
+
+	return basic_repo.DBError(err, "movie")
+}
+
+func updateMovie(ctx context.Context, tx bun.Tx, movie *entity.Movie, genreIDs []int) error {
+	var currentDirector string
+	err := tx.NewSelect().
+		Model((*entity.Movie)(nil)).
+		Column("director").
+		Where("id = ? AND deleted_at IS NULL", movie.Id).
+		Scan(ctx, &currentDirector)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewUpdate().
 		Model(movie).
+		ExcludeColumn("rating", "vote_count", "weighted_rating").
 		Where("id = ? AND deleted_at IS NULL", movie.Id).
 		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if movie.Director != currentDirector {
+		if err := setDirectors(ctx, tx, movie); err != nil {
+			return err
+		}
+	}
+
+	if genreIDs == nil {
+		return nil
+	}
+
+	_, err = tx.NewDelete().
+		Model((*entity.MovieGenre)(nil)).
+		Where("movie_id = ?", movie.Id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return setGenres(ctx, tx, movie, genreIDs)
+}
+
+// setGenres links the movie to genreIDs and reloads movie.Genres. Every id
+// must refer to an existing, non-deleted genre.
+func setGenres(ctx context.Context, tx bun.Tx, movie *entity.Movie, genreIDs []int) error {
+	movie.Genres = []*entity.Genre{}
+
+	ids := uniqueIDs(genreIDs)
+	if len(ids) == 0 {
+		return nil
+	}
 
+	err := tx.NewSelect().
+		Model(&movie.Genres).
+		Where("id IN (?) AND deleted_at IS NULL", bun.In(ids)).
+		Order("name ASC").
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(movie.Genres) != len(ids) {
+		return apperror.InvalidField("genre_ids", fmt.Sprintf("unknown genre ids: %v", missingIDs(ids, movie.Genres)))
+	}
+
+	links := make([]*entity.MovieGenre, 0, len(ids))
+	for _, id := range ids {
+		links = append(links, &entity.MovieGenre{MovieId: movie.Id, GenreId: id})
+	}
+
+	_, err = tx.NewInsert().Model(&links).Exec(ctx)
 	return err
 }
 
+// setDirectors replaces the movie's director credits with the people named in
+// movie.Director, creating people that do not exist yet. Co-directors are
+// separated by "," or "&".
+func setDirectors(ctx context.Context, tx bun.Tx, movie *entity.Movie) error {
+	_, err := tx.NewDelete().
+		Model((*entity.MovieCredit)(nil)).
+		Where("movie_id = ? AND role = ?", movie.Id, entity.CreditRoleDirector).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := splitDirectors(movie.Director)
+	movie.Director = strings.Join(names, ", ")
+
+	now := time.Now()
+	for i, name := range names {
+		person := new(entity.Person)
+		err := tx.NewSelect().
+			Model(person).
+			Where("LOWER(name) = LOWER(?) AND deleted_at IS NULL", name).
+			Order("id ASC").
+			Limit(1).
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			person = &entity.Person{Name: name, CreatedAt: &now, UpdatedAt: &now}
+			_, err = tx.NewInsert().Model(person).Exec(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		credit := &entity.MovieCredit{
+			MovieId:      movie.Id,
+			PersonId:     person.Id,
+			Role:         entity.CreditRoleDirector,
+			BillingOrder: i,
+			CreatedAt:    &now,
+			UpdatedAt:    &now,
+		}
+		if _, err := tx.NewInsert().Model(credit).Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return RefreshDirector(ctx, tx, movie.Id)
+}
+
+// RefreshDirector rewrites the denormalized movies.director column from the
+// movie's director credits so that search and legacy clients stay in sync.
+func RefreshDirector(ctx context.Context, db bun.IDB, movieID int) error {
+	_, err := db.NewUpdate().
+		Model((*entity.Movie)(nil)).
+		Set(`director = COALESCE((
+			SELECT string_agg(p.name, ', ' ORDER BY c.billing_order, c.id)
+			FROM movie_credits AS c
+			JOIN people AS p ON p.id = c.person_id
+			WHERE c.movie_id = ? AND c.role = ?
+		), '')`, movieID, entity.CreditRoleDirector).
+		Where("id = ?", movieID).
+		Exec(ctx)
+
+	return err
+}
+
+func splitDirectors(director string) []string {
+	parts := strings.FieldsFunc(director, func(r rune) bool {
+		return r == ',' || r == '&'
+	})
+
+	var names []string
+	for _, part := range parts {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func activeGenres(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Where("?TableAlias.deleted_at IS NULL").Order("name ASC")
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	result := make([]int, 0, len(ids))
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+
+	return result
+}
+
+func missingIDs(ids []int, genres []*entity.Genre) []int {
+	found := make(map[int]bool, len(genres))
+	for _, genre := range genres {
+		found[genre.Id] = true
+	}
+
+	var missing []int
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
 func (r Repository) Delete(ctx context.Context, data basic_repo.Delete) error {
-	return basic_repo.BasicDelete(ctx, data, &entity.User{}, r.db)
+	err := basic_repo.BasicDelete(ctx, data, (*entity.Movie)(nil), r.db)
+	return basic_repo.DBError(err, "movie")
 }
 
-func (r *Repository) GetAll(ctx context.Context, filter SearchMovieRequest) ([]*entity.Movie, int, error) {
+// GetAll returns a page of movies matching the structured filters, in the
+// requested sort order, together with the total match count and cursors for
+// the neighbouring pages. Pages are addressed either by filter.Page (OFFSET)
+// or, when filter.Cursor is set, by keyset on the sort columns.
+//
+// When filter.Query is set the movies are also matched against the
+// full-text search_vector column using websearch syntax ("quoted phrases",
+// -exclusions, OR), ordered by relevance unless another sort is given, and
+// annotated with highlighted title and plot snippets.
+func (r *Repository) GetAll(ctx context.Context, filter SearchMovieRequest) ([]*entity.Movie, int, cursor.Page, error) {
 	page := 1
 	if filter.Page != nil && *filter.Page > 0 {
 		page = *filter.Page
@@ -72,53 +306,83 @@ func (r *Repository) GetAll(ctx context.Context, filter SearchMovieRequest) ([]*
 		limit = *filter.Limit
 	}
 
-	offset := (page - 1) * limit
+	names, keys, err := filter.sortOrder()
+	if err != nil {
+		return nil, 0, cursor.Page{}, err
+	}
 
-	var movies []*entity.Movie
-	var count int
+	position, values, err := filter.position(names, keys)
+	if err != nil {
+		return nil, 0, cursor.Page{}, err
+	}
 
-	var query string
-	if filter.Query != nil {
-		query = *filter.Query
+	count, err := r.db.NewSelect().
+		Model((*entity.Movie)(nil)).
+		Apply(filter.where).
+		Count(ctx)
+	if err != nil {
+		return nil, 0, cursor.Page{}, fmt.Errorf("error counting movies: %w", err)
 	}
 
-	searchTerms := strings.Split(query, " ")
-	var conditions []string
-	var params []interface{}
+	var movies []*entity.Movie
 
-	for _, term := range searchTerms {
-		if term == "" {
-			continue
-		}
-		term = "%" + strings.ToLower(term) + "%"
-		conditions = append(conditions, "LOWER(title) LIKE ? OR LOWER(director) LIKE ? OR LOWER(plot) LIKE ?")
-		params = append(params, term, term, term)
+	query := r.db.NewSelect().
+		Model(&movies).
+		Relation("Genres", activeGenres).
+		Apply(filter.where)
+
+	if filter.searching() {
+		query = query.
+			ColumnExpr("?TableColumns").
+			ColumnExpr("ts_rank(movie.search_vector, search_query) AS search_rank").
+			ColumnExpr("ts_headline(?, movie.title, search_query, ?) AS title_highlight", searchConfig, titleHeadlineOptions).
+			ColumnExpr("ts_headline(?, COALESCE(movie.plot, ''), search_query, ?) AS plot_highlight", searchConfig, plotHeadlineOptions)
 	}
 
-	var whereClause string
-	if len(conditions) > 0 {
-		whereClause = "(" + strings.Join(conditions, " OR ") + ") AND deleted_at IS NULL"
+	backward := false
+	if position != nil {
+		backward = position.Backward
+		where, args := cursor.Where(keys, values, backward)
+		query = query.Where(where, args...)
 	} else {
-		whereClause = "deleted_at IS NULL"
+		query = query.Offset((page - 1) * limit)
 	}
 
-	countQuery := r.db.NewSelect().Model((*entity.Movie)(nil)).Where(whereClause, params...)
-	count, err := countQuery.Count(ctx)
+	err = query.
+		OrderExpr(cursor.OrderBy(keys, backward)).
+		Limit(limit + 1).
+		Scan(ctx)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error counting search results: %w", err)
+		return nil, 0, cursor.Page{}, fmt.Errorf("error searching movies: %w", err)
 	}
 
-	err = r.db.NewSelect().
-		Model(&movies).
-		Where(whereClause, params...).
-		Order("id ASC").
-		Limit(limit).
-		Offset(offset).
-		Scan(ctx)
+	hasMore := len(movies) > limit
+	if hasMore {
+		movies = movies[:limit]
+	}
 
-	if err != nil {
-		return nil, 0, fmt.Errorf("error searching movies: %w", err)
+	if backward {
+		for i, j := 0, len(movies)-1; i < j; i, j = i+1, j-1 {
+			movies[i], movies[j] = movies[j], movies[i]
+		}
+	}
+
+	// A forward page always has rows before it unless it is the first
+	// offset page; a backward page always has rows after it.
+	hasNext := hasMore || backward
+	hasPrev := (position != nil && !backward) || (backward && hasMore) || (position == nil && page > 1)
+
+	var links cursor.Page
+	if len(movies) > 0 && hasNext {
+		if links.NextCursor, err = cursorFor(movies[len(movies)-1], names, keys, false); err != nil {
+			return nil, 0, cursor.Page{}, err
+		}
+	}
+	if len(movies) > 0 && hasPrev {
+		if links.PrevCursor, err = cursorFor(movies[0], names, keys, true); err != nil {
+			return nil, 0, cursor.Page{}, err
+		}
 	}
 
-	return movies, count, nil
+	return movies, count, links, nil
 }
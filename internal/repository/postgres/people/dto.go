@@ -0,0 +1,73 @@
+package people
+
+import "time"
+
+type CreatePersonRequest struct {
+	Name *string `json:"name" binding:"required,max=255"`
+	Bio  *string `json:"bio"`
+}
+
+type UpdatePersonRequest struct {
+	Name *string `json:"name" binding:"omitempty,max=255"`
+	Bio  *string `json:"bio"`
+}
+
+type PersonResponse struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Bio       string     `json:"bio,omitempty"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type Filter struct {
+	Query *string `form:"query"`
+	Page  *int    `form:"page,default=1" binding:"min=1"`
+	Limit *int    `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+type CreateCreditRequest struct {
+	PersonID      *int    `json:"person_id" binding:"required,min=1"`
+	Role          *string `json:"role" binding:"required,oneof=director actor writer producer composer cinematographer editor"`
+	CharacterName *string `json:"character_name" binding:"omitempty,max=255"`
+	BillingOrder  *int    `json:"billing_order" binding:"omitempty,min=0"`
+}
+
+type UpdateCreditRequest struct {
+	Role          *string `json:"role" binding:"omitempty,oneof=director actor writer producer composer cinematographer editor"`
+	CharacterName *string `json:"character_name" binding:"omitempty,max=255"`
+	BillingOrder  *int    `json:"billing_order" binding:"omitempty,min=0"`
+}
+
+type CreditPerson struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreditResponse struct {
+	ID            int          `json:"id"`
+	MovieID       int          `json:"movie_id"`
+	Person        CreditPerson `json:"person"`
+	Role          string       `json:"role"`
+	CharacterName string       `json:"character_name,omitempty"`
+	BillingOrder  int          `json:"billing_order"`
+}
+
+type FilmographyMovie struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+}
+
+type FilmographyEntry struct {
+	CreditID      int              `json:"credit_id"`
+	Role          string           `json:"role"`
+	CharacterName string           `json:"character_name,omitempty"`
+	BillingOrder  int              `json:"billing_order"`
+	Movie         FilmographyMovie `json:"movie"`
+}
+
+type FilmographyResponse struct {
+	Person  PersonResponse      `json:"person"`
+	Credits []*FilmographyEntry `json:"credits"`
+}
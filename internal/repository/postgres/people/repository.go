@@ -0,0 +1,253 @@
+package people
+
+import (
+	"Movies-Go/internal/entity"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"Movies-Go/internal/repository/postgres/movies"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, person *entity.Person) error {
+	now := time.Now()
+	person.CreatedAt = &now
+	person.UpdatedAt = &now
+
+	_, err := r.db.NewInsert().Model(person).Exec(ctx)
+	return basic_repo.DBError(err, "person")
+}
+
+func (r *Repository) GetAll(ctx context.Context, filter Filter) ([]*entity.Person, int, error) {
+	page := 1
+	if filter.Page != nil && *filter.Page > 0 {
+		page = *filter.Page
+	}
+
+	limit := 10
+	if filter.Limit != nil && *filter.Limit > 0 {
+		limit = *filter.Limit
+	}
+
+	var list []*entity.Person
+
+	query := r.db.NewSelect().
+		Model(&list).
+		Where("deleted_at IS NULL")
+
+	if filter.Query != nil && *filter.Query != "" {
+		query = query.Where("LOWER(name) LIKE LOWER(?)", "%"+*filter.Query+"%")
+	}
+
+	count, err := query.
+		Order("name ASC", "id ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing people: %w", err)
+	}
+
+	return list, count, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*entity.Person, error) {
+	person := new(entity.Person)
+
+	err := r.db.NewSelect().
+		Model(person).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, basic_repo.DBError(err, "person")
+	}
+
+	return person, nil
+}
+
+// Update saves the person and refreshes the director text of every movie
+// they directed, since a rename changes it.
+func (r *Repository) Update(ctx context.Context, person *entity.Person) error {
+	now := time.Now()
+	person.UpdatedAt = &now
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model(person).
+			Where("id = ? AND deleted_at IS NULL", person.Id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return refreshDirectedMovies(ctx, tx, person.Id)
+	})
+
+	return basic_repo.DBError(err, "person")
+}
+
+// Delete soft-deletes the person and removes all of their credits.
+func (r *Repository) Delete(ctx context.Context, data basic_repo.Delete) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*entity.Person)(nil)).
+			Set("deleted_at = ?", time.Now()).
+			Where("id = ? AND deleted_at IS NULL", *data.Id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		var movieIDs []int
+		_, err = tx.NewDelete().
+			Model((*entity.MovieCredit)(nil)).
+			Where("person_id = ?", *data.Id).
+			Returning("movie_id").
+			Exec(ctx, &movieIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, movieID := range movieIDs {
+			if err := movies.RefreshDirector(ctx, tx, movieID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return basic_repo.DBError(err, "person")
+}
+
+// GetFilmography returns every credit of the person on a non-deleted movie,
+// newest movies first.
+func (r *Repository) GetFilmography(ctx context.Context, personID int) ([]*entity.MovieCredit, error) {
+	var credits []*entity.MovieCredit
+
+	err := r.db.NewSelect().
+		Model(&credits).
+		Relation("Movie").
+		Where("movie_credit.person_id = ?", personID).
+		Where("movie.deleted_at IS NULL").
+		Order("movie.year DESC", "movie.id DESC", "movie_credit.billing_order ASC").
+		Scan(ctx)
+
+	return credits, err
+}
+
+func (r *Repository) GetCredits(ctx context.Context, movieID int) ([]*entity.MovieCredit, error) {
+	var credits []*entity.MovieCredit
+
+	err := r.db.NewSelect().
+		Model(&credits).
+		Relation("Person").
+		Where("movie_credit.movie_id = ?", movieID).
+		Where("person.deleted_at IS NULL").
+		Order("movie_credit.billing_order ASC", "movie_credit.id ASC").
+		Scan(ctx)
+
+	return credits, err
+}
+
+func (r *Repository) GetCreditByID(ctx context.Context, movieID, creditID int) (*entity.MovieCredit, error) {
+	credit := new(entity.MovieCredit)
+
+	err := r.db.NewSelect().
+		Model(credit).
+		Relation("Person").
+		Where("movie_credit.id = ? AND movie_credit.movie_id = ?", creditID, movieID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, basic_repo.DBError(err, "credit")
+	}
+
+	return credit, nil
+}
+
+func (r *Repository) CreateCredit(ctx context.Context, credit *entity.MovieCredit) error {
+	now := time.Now()
+	credit.CreatedAt = &now
+	credit.UpdatedAt = &now
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(credit).Exec(ctx); err != nil {
+			return err
+		}
+
+		return movies.RefreshDirector(ctx, tx, credit.MovieId)
+	})
+
+	return basic_repo.DBError(err, "credit")
+}
+
+func (r *Repository) UpdateCredit(ctx context.Context, credit *entity.MovieCredit) error {
+	now := time.Now()
+	credit.UpdatedAt = &now
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model(credit).
+			ExcludeColumn("movie_id", "person_id", "created_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return movies.RefreshDirector(ctx, tx, credit.MovieId)
+	})
+
+	return basic_repo.DBError(err, "credit")
+}
+
+func (r *Repository) DeleteCredit(ctx context.Context, credit *entity.MovieCredit) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model(credit).
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return movies.RefreshDirector(ctx, tx, credit.MovieId)
+	})
+
+	return basic_repo.DBError(err, "credit")
+}
+
+func refreshDirectedMovies(ctx context.Context, tx bun.Tx, personID int) error {
+	var movieIDs []int
+
+	err := tx.NewSelect().
+		Model((*entity.MovieCredit)(nil)).
+		Column("movie_id").
+		Where("person_id = ? AND role = ?", personID, entity.CreditRoleDirector).
+		Scan(ctx, &movieIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, movieID := range movieIDs {
+		if err := movies.RefreshDirector(ctx, tx, movieID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package recommendations
+
+import "time"
+
+type Filter struct {
+	Limit *int `form:"limit,default=10" binding:"min=1,max=30"`
+}
+
+type UserFilter struct {
+	Limit *int `form:"limit,default=20" binding:"min=1,max=50"`
+}
+
+// Sources of personal recommendations.
+const (
+	SourcePersonalized = "personalized"
+	// SourcePopular marks the fallback for users without recommendations
+	// yet: the best rated movies they have not seen.
+	SourcePopular = "popular"
+)
+
+type RecommendedMovie struct {
+	ID             int      `json:"id"`
+	Title          string   `json:"title"`
+	Director       string   `json:"director"`
+	Year           int      `json:"year"`
+	Rating         float64  `json:"rating"`
+	WeightedRating float64  `json:"weighted_rating"`
+	Genres         []string `json:"genres"`
+}
+
+type SimilarityScores struct {
+	Genres  float64 `json:"genres"`
+	People  float64 `json:"people"`
+	Plot    float64 `json:"plot"`
+	Ratings float64 `json:"ratings"`
+}
+
+type SimilarMovieResponse struct {
+	Movie  RecommendedMovie `json:"movie"`
+	Score  float64          `json:"score"`
+	Scores SimilarityScores `json:"scores"`
+}
+
+type RecommendationScores struct {
+	Collaborative float64 `json:"collaborative"`
+	Content       float64 `json:"content"`
+}
+
+type RecommendationResponse struct {
+	Movie  RecommendedMovie      `json:"movie"`
+	Score  float64               `json:"score"`
+	Scores *RecommendationScores `json:"scores,omitempty"`
+}
+
+type SimilarListResponse struct {
+	Results    []SimilarMovieResponse `json:"results"`
+	ComputedAt *time.Time             `json:"computed_at"`
+}
+
+type RecommendationListResponse struct {
+	Results    []RecommendationResponse `json:"results"`
+	Source     string                   `json:"source"`
+	ComputedAt *time.Time               `json:"computed_at"`
+}
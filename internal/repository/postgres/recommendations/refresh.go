@@ -0,0 +1,315 @@
+package recommendations
+
+import (
+	"Movies-Go/internal/entity"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// refreshLockKey identifies the advisory lock held while refreshing, so that
+// several instances of the service do not compute the same tables at once.
+const refreshLockKey int64 = 7_203_114_524
+
+const (
+	// similarPerMovie and recommendationsPerUser bound the stored results.
+	similarPerMovie        = 30
+	recommendationsPerUser = 50
+	// minCoRaters is the number of users who must have reviewed both movies
+	// before their ratings are compared.
+	minCoRaters = 2
+)
+
+// centeredReviewsQuery keeps every review of an active movie by a user with
+// at least two reviews, as the difference from that user's mean score, so
+// that harsh and generous reviewers can be compared.
+const centeredReviewsQuery = `
+CREATE TEMPORARY TABLE centered_reviews ON COMMIT DROP AS
+WITH active_reviews AS (
+	SELECT r.user_id, r.movie_id, r.score
+	FROM reviews AS r
+	JOIN movies AS m ON m.id = r.movie_id AND m.deleted_at IS NULL
+	JOIN users AS u ON u.id = r.user_id AND u.deleted_at IS NULL
+),
+user_means AS (
+	SELECT user_id, AVG(score) AS mean
+	FROM active_reviews
+	GROUP BY user_id
+	HAVING COUNT(*) >= 2
+)
+SELECT r.user_id, r.movie_id, r.score - u.mean AS deviation
+FROM active_reviews AS r
+JOIN user_means AS u ON u.user_id = r.user_id`
+
+// ratingSimilarityQuery computes the adjusted cosine similarity of every
+// pair of movies reviewed by at least minCoRaters common users.
+const ratingSimilarityQuery = `
+CREATE TEMPORARY TABLE rating_similarity ON COMMIT DROP AS
+WITH norms AS (
+	SELECT movie_id, SQRT(SUM(deviation * deviation)) AS norm
+	FROM centered_reviews
+	GROUP BY movie_id
+)
+SELECT a.movie_id, b.movie_id AS other_id,
+	SUM(a.deviation * b.deviation) / (na.norm * nb.norm) AS score
+FROM centered_reviews AS a
+JOIN centered_reviews AS b ON b.user_id = a.user_id AND b.movie_id <> a.movie_id
+JOIN norms AS na ON na.movie_id = a.movie_id
+JOIN norms AS nb ON nb.movie_id = b.movie_id
+WHERE na.norm > 0 AND nb.norm > 0
+GROUP BY a.movie_id, b.movie_id, na.norm, nb.norm
+HAVING COUNT(*) >= ?`
+
+// similaritiesQuery scores pairs of active movies that share a genre, a
+// person, a distinctive plot word or reviewers:
+//
+//   - genres and people by the Jaccard index of the two sets,
+//   - plots by the cosine of their TF-IDF vectors over the lexemes of the
+//     english text search configuration, ignoring words that appear in a
+//     single plot or in more than a tenth of them,
+//   - ratings by the adjusted cosine computed above; only positive
+//     correlations count towards the score.
+//
+// It keeps the best similarPerMovie pairs per movie.
+const similaritiesQuery = `
+INSERT INTO movie_similarities
+	(movie_id, similar_movie_id, score, genre_score, people_score, plot_score, rating_score, computed_at)
+WITH active AS (
+	SELECT id, plot FROM movies WHERE deleted_at IS NULL
+),
+genre_sets AS (
+	SELECT mg.movie_id, mg.genre_id
+	FROM movie_genres AS mg
+	JOIN active AS a ON a.id = mg.movie_id
+	JOIN genres AS g ON g.id = mg.genre_id AND g.deleted_at IS NULL
+),
+genre_sizes AS (
+	SELECT movie_id, COUNT(*) AS n FROM genre_sets GROUP BY movie_id
+),
+genre_sim AS (
+	SELECT a.movie_id, b.movie_id AS other_id,
+		COUNT(*)::float / (sa.n + sb.n - COUNT(*)) AS score
+	FROM genre_sets AS a
+	JOIN genre_sets AS b ON b.genre_id = a.genre_id AND b.movie_id <> a.movie_id
+	JOIN genre_sizes AS sa ON sa.movie_id = a.movie_id
+	JOIN genre_sizes AS sb ON sb.movie_id = b.movie_id
+	GROUP BY a.movie_id, b.movie_id, sa.n, sb.n
+),
+people_sets AS (
+	SELECT DISTINCT mc.movie_id, mc.person_id
+	FROM movie_credits AS mc
+	JOIN active AS a ON a.id = mc.movie_id
+	JOIN people AS p ON p.id = mc.person_id AND p.deleted_at IS NULL
+),
+people_sizes AS (
+	SELECT movie_id, COUNT(*) AS n FROM people_sets GROUP BY movie_id
+),
+people_sim AS (
+	SELECT a.movie_id, b.movie_id AS other_id,
+		COUNT(*)::float / (sa.n + sb.n - COUNT(*)) AS score
+	FROM people_sets AS a
+	JOIN people_sets AS b ON b.person_id = a.person_id AND b.movie_id <> a.movie_id
+	JOIN people_sizes AS sa ON sa.movie_id = a.movie_id
+	JOIN people_sizes AS sb ON sb.movie_id = b.movie_id
+	GROUP BY a.movie_id, b.movie_id, sa.n, sb.n
+),
+lexemes AS (
+	SELECT DISTINCT a.id AS movie_id, l.lexeme
+	FROM active AS a,
+		unnest(tsvector_to_array(to_tsvector('english', COALESCE(a.plot, '')))) AS l(lexeme)
+),
+document_frequency AS (
+	SELECT lexeme, COUNT(*) AS df FROM lexemes GROUP BY lexeme
+),
+plot_terms AS (
+	SELECT l.movie_id, l.lexeme, LN((SELECT COUNT(*) FROM active)::float / d.df) AS weight
+	FROM lexemes AS l
+	JOIN document_frequency AS d ON d.lexeme = l.lexeme
+	WHERE d.df > 1 AND d.df <= GREATEST(2, (SELECT COUNT(*) FROM active) / 10)
+),
+plot_norms AS (
+	SELECT movie_id, SQRT(SUM(weight * weight)) AS norm FROM plot_terms GROUP BY movie_id
+),
+plot_sim AS (
+	SELECT a.movie_id, b.movie_id AS other_id,
+		SUM(a.weight * b.weight) / (na.norm * nb.norm) AS score
+	FROM plot_terms AS a
+	JOIN plot_terms AS b ON b.lexeme = a.lexeme AND b.movie_id <> a.movie_id
+	JOIN plot_norms AS na ON na.movie_id = a.movie_id
+	JOIN plot_norms AS nb ON nb.movie_id = b.movie_id
+	WHERE na.norm > 0 AND nb.norm > 0
+	GROUP BY a.movie_id, b.movie_id, na.norm, nb.norm
+),
+pairs AS (
+	SELECT movie_id, other_id FROM genre_sim
+	UNION SELECT movie_id, other_id FROM people_sim
+	UNION SELECT movie_id, other_id FROM plot_sim
+	UNION SELECT movie_id, other_id FROM rating_similarity
+),
+scored AS (
+	SELECT p.movie_id, p.other_id,
+		COALESCE(g.score, 0) AS genre_score,
+		COALESCE(pe.score, 0) AS people_score,
+		COALESCE(pl.score, 0) AS plot_score,
+		COALESCE(r.score, 0) AS rating_score,
+		0.35 * COALESCE(g.score, 0)
+			+ 0.25 * COALESCE(pe.score, 0)
+			+ 0.25 * COALESCE(pl.score, 0)
+			+ 0.15 * GREATEST(COALESCE(r.score, 0), 0) AS score
+	FROM pairs AS p
+	JOIN active AS other ON other.id = p.other_id
+	LEFT JOIN genre_sim AS g ON g.movie_id = p.movie_id AND g.other_id = p.other_id
+	LEFT JOIN people_sim AS pe ON pe.movie_id = p.movie_id AND pe.other_id = p.other_id
+	LEFT JOIN plot_sim AS pl ON pl.movie_id = p.movie_id AND pl.other_id = p.other_id
+	LEFT JOIN rating_similarity AS r ON r.movie_id = p.movie_id AND r.other_id = p.other_id
+),
+ranked AS (
+	SELECT *, ROW_NUMBER() OVER (PARTITION BY movie_id ORDER BY score DESC, other_id) AS rank
+	FROM scored
+	WHERE score > 0
+)
+SELECT movie_id, other_id, score, genre_score, people_score, plot_score, rating_score, ?::timestamptz
+FROM ranked
+WHERE rank <= ?`
+
+// recommendationsQuery blends two signals for every user:
+//
+//   - collaborative: the item-based prediction of how much above their own
+//     mean the user would rate a movie, from the movies they reviewed and
+//     the rating similarity of the two, scaled so that 5 points is 1;
+//   - content: the sum of the similarities of a movie to the movies the
+//     user liked (weighted by how far above 5 they rated them), watched or
+//     put on their watchlist, scaled so that the user's best match is 1.
+//
+// Movies the user already reviewed, watched or put on their watchlist are
+// never recommended. It keeps the best recommendationsPerUser movies per
+// user.
+const recommendationsQuery = `
+INSERT INTO user_recommendations
+	(user_id, movie_id, score, collaborative_score, content_score, computed_at)
+WITH collaborative AS (
+	SELECT c.user_id, s.other_id AS movie_id,
+		SUM(s.score * c.deviation) / SUM(ABS(s.score)) AS prediction
+	FROM centered_reviews AS c
+	JOIN rating_similarity AS s ON s.movie_id = c.movie_id
+	GROUP BY c.user_id, s.other_id
+	HAVING SUM(ABS(s.score)) > 0
+),
+seeds AS (
+	SELECT user_id, movie_id, (score - 5) / 5 AS weight
+	FROM reviews
+	UNION ALL
+	SELECT h.user_id, h.movie_id, 0.6
+	FROM watch_history AS h
+	WHERE NOT EXISTS (SELECT 1 FROM reviews AS r WHERE r.user_id = h.user_id AND r.movie_id = h.movie_id)
+	UNION ALL
+	SELECT w.user_id, w.movie_id, 0.4
+	FROM watchlist_items AS w
+	WHERE NOT EXISTS (SELECT 1 FROM reviews AS r WHERE r.user_id = w.user_id AND r.movie_id = w.movie_id)
+		AND NOT EXISTS (SELECT 1 FROM watch_history AS h WHERE h.user_id = w.user_id AND h.movie_id = w.movie_id)
+),
+content AS (
+	SELECT s.user_id, ms.similar_movie_id AS movie_id, SUM(s.weight * ms.score) AS affinity
+	FROM seeds AS s
+	JOIN movie_similarities AS ms ON ms.movie_id = s.movie_id
+	GROUP BY s.user_id, ms.similar_movie_id
+),
+content_scores AS (
+	SELECT user_id, movie_id, affinity / MAX(affinity) OVER (PARTITION BY user_id) AS score
+	FROM content
+	WHERE affinity > 0
+),
+combined AS (
+	SELECT COALESCE(cf.user_id, ct.user_id) AS user_id,
+		COALESCE(cf.movie_id, ct.movie_id) AS movie_id,
+		LEAST(GREATEST(COALESCE(cf.prediction, 0) / 5, 0), 1) AS collaborative_score,
+		COALESCE(ct.score, 0) AS content_score
+	FROM collaborative AS cf
+	FULL JOIN content_scores AS ct ON ct.user_id = cf.user_id AND ct.movie_id = cf.movie_id
+),
+ranked AS (
+	SELECT c.*,
+		0.6 * c.collaborative_score + 0.4 * c.content_score AS score,
+		ROW_NUMBER() OVER (
+			PARTITION BY c.user_id
+			ORDER BY 0.6 * c.collaborative_score + 0.4 * c.content_score DESC, c.movie_id
+		) AS rank
+	FROM combined AS c
+	JOIN movies AS m ON m.id = c.movie_id AND m.deleted_at IS NULL
+	JOIN users AS u ON u.id = c.user_id AND u.deleted_at IS NULL
+	WHERE 0.6 * c.collaborative_score + 0.4 * c.content_score > 0
+		AND NOT EXISTS (SELECT 1 FROM reviews AS r WHERE r.user_id = c.user_id AND r.movie_id = c.movie_id)
+		AND NOT EXISTS (SELECT 1 FROM watch_history AS h WHERE h.user_id = c.user_id AND h.movie_id = c.movie_id)
+		AND NOT EXISTS (SELECT 1 FROM watchlist_items AS w WHERE w.user_id = c.user_id AND w.movie_id = c.movie_id)
+)
+SELECT user_id, movie_id, score, collaborative_score, content_score, ?::timestamptz
+FROM ranked
+WHERE rank <= ?`
+
+// RefreshResult reports what a refresh stored.
+type RefreshResult struct {
+	Similarities    int
+	Recommendations int
+	// Skipped is set when another instance was already refreshing.
+	Skipped  bool
+	Duration time.Duration
+}
+
+// Refresh recomputes movie similarities and user recommendations. The old
+// results stay visible until the new ones are committed.
+func (r *Repository) Refresh(ctx context.Context) (RefreshResult, error) {
+	var result RefreshResult
+	start := time.Now()
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var locked bool
+		err := tx.NewRaw("SELECT pg_try_advisory_xact_lock(?)", refreshLockKey).Scan(ctx, &locked)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			result.Skipped = true
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, centeredReviewsQuery); err != nil {
+			return fmt.Errorf("centered reviews: %w", err)
+		}
+
+		if _, err := tx.NewRaw(ratingSimilarityQuery, minCoRaters).Exec(ctx); err != nil {
+			return fmt.Errorf("rating similarity: %w", err)
+		}
+
+		if _, err := tx.NewDelete().Model((*entity.MovieSimilarity)(nil)).Where("TRUE").Exec(ctx); err != nil {
+			return err
+		}
+
+		res, err := tx.NewRaw(similaritiesQuery, start, similarPerMovie).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("movie similarities: %w", err)
+		}
+		similarities, _ := res.RowsAffected()
+
+		if _, err := tx.NewDelete().Model((*entity.UserRecommendation)(nil)).Where("TRUE").Exec(ctx); err != nil {
+			return err
+		}
+
+		res, err = tx.NewRaw(recommendationsQuery, start, recommendationsPerUser).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("user recommendations: %w", err)
+		}
+		recommendations, _ := res.RowsAffected()
+
+		result.Similarities = int(similarities)
+		result.Recommendations = int(recommendations)
+		return nil
+	})
+	if err != nil {
+		return RefreshResult{}, err
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package recommendations
+
+import (
+	"Movies-Go/internal/entity"
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Similar returns the movies most similar to movieID, best first.
+func (r *Repository) Similar(ctx context.Context, movieID, limit int) ([]*entity.MovieSimilarity, error) {
+	var list []*entity.MovieSimilarity
+
+	err := r.db.NewSelect().
+		Model(&list).
+		Relation("SimilarMovie").
+		Relation("SimilarMovie.Genres", activeGenres).
+		Where("movie_similarity.movie_id = ?", movieID).
+		Where("similar_movie.deleted_at IS NULL").
+		Order("movie_similarity.score DESC", "movie_similarity.similar_movie_id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing similar movies: %w", err)
+	}
+
+	return list, nil
+}
+
+// ForUser returns the stored recommendations of the user, best first,
+// leaving out movies they reviewed, watched or listed since the last
+// refresh.
+func (r *Repository) ForUser(ctx context.Context, userID, limit int) ([]*entity.UserRecommendation, error) {
+	var list []*entity.UserRecommendation
+
+	err := r.db.NewSelect().
+		Model(&list).
+		Relation("Movie").
+		Relation("Movie.Genres", activeGenres).
+		Where("user_recommendation.user_id = ?", userID).
+		Where("movie.deleted_at IS NULL").
+		Where(notSeen, userID, userID, userID).
+		Order("user_recommendation.score DESC", "user_recommendation.movie_id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing recommendations: %w", err)
+	}
+
+	return list, nil
+}
+
+// Popular returns the best rated movies the user has not reviewed, watched
+// or listed, for users without personal recommendations.
+func (r *Repository) Popular(ctx context.Context, userID, limit int) ([]*entity.Movie, error) {
+	var list []*entity.Movie
+
+	err := r.db.NewSelect().
+		Model(&list).
+		Relation("Genres", activeGenres).
+		Where("movie.deleted_at IS NULL").
+		Where(notSeen, userID, userID, userID).
+		Order("movie.weighted_rating DESC", "movie.vote_count DESC", "movie.id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing popular movies: %w", err)
+	}
+
+	return list, nil
+}
+
+func activeGenres(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Where("?TableAlias.deleted_at IS NULL").Order("name ASC")
+}
+
+// notSeen filters out the movies (aliased movie) a user, given three times,
+// has already reviewed, watched or put on their watchlist.
+const notSeen = `NOT EXISTS (SELECT 1 FROM reviews AS r WHERE r.movie_id = movie.id AND r.user_id = ?)
+	AND NOT EXISTS (SELECT 1 FROM watch_history AS h WHERE h.movie_id = movie.id AND h.user_id = ?)
+	AND NOT EXISTS (SELECT 1 FROM watchlist_items AS w WHERE w.movie_id = movie.id AND w.user_id = ?)`
@@ -0,0 +1,30 @@
+package reviews
+
+import "time"
+
+type UpsertReviewRequest struct {
+	Score *float64 `json:"score" binding:"required,min=0,max=10"`
+	Body  *string  `json:"body" binding:"omitempty,max=5000"`
+}
+
+type ReviewUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ReviewMovie struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+}
+
+type ReviewResponse struct {
+	ID        int          `json:"id"`
+	MovieID   int          `json:"movie_id"`
+	User      *ReviewUser  `json:"user,omitempty"`
+	Movie     *ReviewMovie `json:"movie,omitempty"`
+	Score     float64      `json:"score"`
+	Body      string       `json:"body,omitempty"`
+	CreatedAt *time.Time   `json:"created_at"`
+	UpdatedAt *time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,186 @@
+package reviews
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"Movies-Go/internal/repository/postgres/movies"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// bayesianMinVotes is the number of votes a movie needs before its weighted
+// rating leans more on its own average than on bayesianPrior.
+const bayesianMinVotes = 10
+
+// bayesianPrior is the score titles with few votes are pulled towards, the
+// middle of the scale. It is fixed rather than the catalog mean so that the
+// weighted rating of a movie only changes with its own reviews.
+const bayesianPrior = 5.0
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Upsert creates the user's review of the movie or replaces their existing
+// one, and recomputes the movie's aggregate rating in the same transaction.
+func (r *Repository) Upsert(ctx context.Context, review *entity.Review) error {
+	now := time.Now()
+	review.CreatedAt = &now
+	review.UpdatedAt = &now
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockMovie(ctx, tx, review.MovieId); err != nil {
+			return err
+		}
+
+		_, err := tx.NewInsert().
+			Model(review).
+			On("CONFLICT (user_id, movie_id) DO UPDATE").
+			Set("score = EXCLUDED.score").
+			Set("body = EXCLUDED.body").
+			Set("updated_at = EXCLUDED.updated_at").
+			Returning("id, created_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return refreshRating(ctx, tx, review.MovieId)
+	})
+
+	return basic_repo.DBError(err, "review")
+}
+
+// Delete removes the user's review of the movie and recomputes the movie's
+// aggregate rating.
+func (r *Repository) Delete(ctx context.Context, userID, movieID int) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := lockMovie(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().
+			Model((*entity.Review)(nil)).
+			Where("user_id = ? AND movie_id = ?", userID, movieID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return apperror.NotFound("Review not found")
+		}
+
+		return refreshRating(ctx, tx, movieID)
+	})
+
+	return basic_repo.DBError(err, "review")
+}
+
+func (r *Repository) GetByUserAndMovie(ctx context.Context, userID, movieID int) (*entity.Review, error) {
+	review := new(entity.Review)
+
+	err := r.db.NewSelect().
+		Model(review).
+		Relation("User").
+		Where("review.user_id = ? AND review.movie_id = ?", userID, movieID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, basic_repo.DBError(err, "review")
+	}
+
+	return review, nil
+}
+
+// GetByMovie returns a page of the movie's reviews, most recently updated first.
+func (r *Repository) GetByMovie(ctx context.Context, movieID int, filter movies.Filter) ([]*entity.Review, int, error) {
+	var list []*entity.Review
+
+	page, limit := basic_repo.Pagination(filter.Page, filter.Limit, 10)
+
+	count, err := r.db.NewSelect().
+		Model(&list).
+		Relation("User").
+		Where("review.movie_id = ?", movieID).
+		Order("review.updated_at DESC", "review.id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing movie reviews: %w", err)
+	}
+
+	return list, count, nil
+}
+
+// GetByUser returns a page of the user's reviews of non-deleted movies, most
+// recently updated first.
+func (r *Repository) GetByUser(ctx context.Context, userID int, filter movies.Filter) ([]*entity.Review, int, error) {
+	var list []*entity.Review
+
+	page, limit := basic_repo.Pagination(filter.Page, filter.Limit, 10)
+
+	count, err := r.db.NewSelect().
+		Model(&list).
+		Relation("Movie").
+		Where("review.user_id = ?", userID).
+		Where("movie.deleted_at IS NULL").
+		Order("review.updated_at DESC", "review.id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing user reviews: %w", err)
+	}
+
+	return list, count, nil
+}
+
+// lockMovie takes a row lock on the movie so that concurrent reviews of the
+// same title recompute its aggregate one after another.
+func lockMovie(ctx context.Context, tx bun.Tx, movieID int) error {
+	var id int
+
+	err := tx.NewSelect().
+		Model((*entity.Movie)(nil)).
+		Column("id").
+		Where("id = ? AND deleted_at IS NULL", movieID).
+		For("UPDATE").
+		Scan(ctx, &id)
+
+	return basic_repo.DBError(err, "movie")
+}
+
+// refreshRating stores the movie's average score, vote count and Bayesian
+// weighted rating computed from its reviews.
+func refreshRating(ctx context.Context, tx bun.Tx, movieID int) error {
+	_, err := tx.NewRaw(`
+		UPDATE movies AS m
+		SET rating = s.average,
+		    vote_count = s.votes,
+		    weighted_rating = CASE
+		        WHEN s.votes = 0 THEN 0
+		        ELSE (s.votes * s.average + ? * ?) / (s.votes + ?)
+		    END
+		FROM (
+		    SELECT COUNT(*) AS votes, COALESCE(AVG(score), 0) AS average
+		    FROM reviews
+		    WHERE movie_id = ?
+		) AS s
+		WHERE m.id = ?`,
+		bayesianMinVotes, bayesianPrior, bayesianMinVotes, movieID, movieID,
+	).Exec(ctx)
+
+	return err
+}
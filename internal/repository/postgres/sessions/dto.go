@@ -0,0 +1,5 @@
+package sessions
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,231 @@
+package sessions
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrTokenNotFound  = apperror.Unauthorized("Invalid or expired refresh token")
+	ErrTokenReused    = apperror.Unauthorized("Refresh token has already been used, session revoked")
+	ErrSessionRevoked = apperror.Unauthorized("Invalid or expired refresh token")
+)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Create starts a session for the user and stores the hash of its first
+// refresh token.
+func (r *Repository) Create(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (*entity.Session, error) {
+	now := time.Now()
+	session := &entity.Session{
+		UserId:     userID,
+		ExpiresAt:  expiresAt,
+		LastUsedAt: &now,
+		CreatedAt:  &now,
+	}
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(session).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertToken(ctx, tx, session.Id, tokenHash, expiresAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Rotate exchanges a refresh token for a new one and extends the session to
+// expiresAt. A token can only be exchanged once: presenting one that was
+// already used means it has leaked, so the whole session is revoked and
+// ErrTokenReused is returned.
+func (r *Repository) Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*entity.Session, error) {
+	var session entity.Session
+	var reused bool
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var token entity.RefreshToken
+		err := tx.NewSelect().
+			Model(&token).
+			Where("token_hash = ?", oldHash).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTokenNotFound
+			}
+			return err
+		}
+
+		err = tx.NewSelect().
+			Model(&session).
+			Where("id = ?", token.SessionId).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+			return ErrSessionRevoked
+		}
+
+		if token.UsedAt != nil {
+			reused = true
+			_, err = tx.NewUpdate().
+				Model((*entity.Session)(nil)).
+				Set("revoked_at = ?", now).
+				Where("id = ?", session.Id).
+				Exec(ctx)
+			return err
+		}
+
+		if !token.ExpiresAt.After(now) {
+			return ErrSessionRevoked
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*entity.RefreshToken)(nil)).
+			Set("used_at = ?", now).
+			Where("id = ?", token.Id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		session.ExpiresAt = expiresAt
+		session.LastUsedAt = &now
+		_, err = tx.NewUpdate().
+			Model(&session).
+			Column("expires_at", "last_used_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return insertToken(ctx, tx, session.Id, newHash, expiresAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, ErrTokenReused
+	}
+
+	return &session, nil
+}
+
+// IsSessionActive reports whether the session exists, has not been revoked
+// or expired, and belongs to a user that has not been deleted.
+func (r *Repository) IsSessionActive(ctx context.Context, sessionID int) (bool, error) {
+	return r.db.NewSelect().
+		Model((*entity.Session)(nil)).
+		Join(`JOIN users AS "user" ON "user".id = session.user_id`).
+		Where("session.id = ?", sessionID).
+		Where("session.revoked_at IS NULL").
+		Where("session.expires_at > ?", time.Now()).
+		Where(`"user".deleted_at IS NULL`).
+		Exists(ctx)
+}
+
+// Revoke ends one of the user's sessions.
+func (r *Repository) Revoke(ctx context.Context, userID, sessionID int) error {
+	res, err := r.db.NewUpdate().
+		Model((*entity.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", sessionID).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperror.NotFound("Session not found")
+	}
+
+	return nil
+}
+
+// RevokeAll ends every active session of the user and returns how many
+// were revoked.
+func (r *Repository) RevokeAll(ctx context.Context, userID int) (int, error) {
+	res, err := r.db.NewUpdate().
+		Model((*entity.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+// RevokeOthers ends every active session of the user but sessionID, e.g.
+// after a password change made in that session, and returns how many were
+// revoked.
+func (r *Repository) RevokeOthers(ctx context.Context, userID, sessionID int) (int, error) {
+	res, err := r.db.NewUpdate().
+		Model((*entity.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("id <> ?", sessionID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+func insertToken(ctx context.Context, tx bun.Tx, sessionID int, tokenHash string, expiresAt time.Time) error {
+	now := time.Now()
+	_, err := tx.NewInsert().
+		Model(&entity.RefreshToken{
+			SessionId: sessionID,
+			TokenHash: tokenHash,
+			ExpiresAt: expiresAt,
+			CreatedAt: &now,
+		}).
+		Exec(ctx)
+
+	return err
+}
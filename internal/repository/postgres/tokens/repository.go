@@ -0,0 +1,174 @@
+package tokens
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrTokenInvalid is returned for tokens that do not exist, have expired,
+// were already used or were issued for another purpose.
+var ErrTokenInvalid = apperror.InvalidField("token", "is invalid, expired or already used")
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Issue stores the hash of a new token of the user for purpose. Unused
+// tokens issued earlier for the same purpose stop working, so that only
+// the latest email is valid.
+func (r *Repository) Issue(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+
+		_, err := tx.NewUpdate().
+			Model((*entity.UserToken)(nil)).
+			Set("used_at = ?", now).
+			Where("user_id = ?", userID).
+			Where("purpose = ?", purpose).
+			Where("used_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().
+			Model(&entity.UserToken{
+				UserId:    userID,
+				Purpose:   purpose,
+				TokenHash: tokenHash,
+				ExpiresAt: expiresAt,
+				CreatedAt: &now,
+			}).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+// VerifyEmail uses an email verification token and marks the email address
+// of its user as verified. It returns the user id.
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		token, err := use(ctx, tx, entity.TokenPurposeVerifyEmail, tokenHash)
+		if err != nil {
+			return err
+		}
+		userID = token.UserId
+
+		_, err = tx.NewUpdate().
+			Model((*entity.User)(nil)).
+			Set("email_verified_at = COALESCE(email_verified_at, ?)", time.Now()).
+			Where("id = ?", token.UserId).
+			Exec(ctx)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ResetPassword uses a password reset token and replaces the password of
+// its user with passwordHash. Every session of the user is revoked and a
+// lockout after failed logins is lifted. Receiving the token also proves
+// the email address, so it is marked as verified. It returns the user id.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	var userID int
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		token, err := use(ctx, tx, entity.TokenPurposeResetPassword, tokenHash)
+		if err != nil {
+			return err
+		}
+		userID = token.UserId
+
+		now := time.Now()
+		_, err = tx.NewUpdate().
+			Model((*entity.User)(nil)).
+			Set("password = ?", passwordHash).
+			Set("failed_attempts = 0").
+			Set("locked_until = NULL").
+			Set("email_verified_at = COALESCE(email_verified_at, ?)", now).
+			Set("updated_at = ?", now).
+			Where("id = ?", token.UserId).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*entity.Session)(nil)).
+			Set("revoked_at = ?", now).
+			Where("user_id = ?", token.UserId).
+			Where("revoked_at IS NULL").
+			Exec(ctx)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// use marks the token as used, failing with ErrTokenInvalid unless it is
+// an unused, unexpired token for purpose of a user that still exists.
+func use(ctx context.Context, tx bun.Tx, purpose, tokenHash string) (*entity.UserToken, error) {
+	var token entity.UserToken
+	err := tx.NewSelect().
+		Model(&token).
+		Where("token_hash = ?", tokenHash).
+		Where("purpose = ?", purpose).
+		For("UPDATE").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, ErrTokenInvalid
+	}
+
+	exists, err := tx.NewSelect().
+		Model((*entity.User)(nil)).
+		Where("id = ? AND deleted_at IS NULL", token.UserId).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTokenInvalid
+	}
+
+	_, err = tx.NewUpdate().
+		Model((*entity.UserToken)(nil)).
+		Set("used_at = ?", now).
+		Where("id = ?", token.Id).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,59 @@
+package twofactor
+
+import "time"
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	// Code is a TOTP code or a recovery code.
+	Code string `json:"code" binding:"required"`
+}
+
+type LoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code is a TOTP code or, unless enrolling, a recovery code.
+	Code string `json:"code" binding:"required"`
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type PolicyRequest struct {
+	RequiredRoles []string `json:"required_roles" binding:"required,dive,oneof=admin editor viewer"`
+}
+
+type PolicyResponse struct {
+	RequiredRoles []string `json:"required_roles"`
+}
+
+type SetupResponse struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI to show as a QR code.
+	URI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type StatusResponse struct {
+	Enabled   bool       `json:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+	// Required is set when the role of the user requires two-factor
+	// authentication.
+	Required           bool `json:"required"`
+	RecoveryCodesCount int  `json:"recovery_codes_left"`
+}
+
+// ChallengeResponse answers a login that needs a second step: entering a
+// code at POST /auth/login/2fa or, with SetupRequired, enrolling first.
+type ChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	SetupRequired     bool   `json:"setup_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
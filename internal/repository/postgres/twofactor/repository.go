@@ -0,0 +1,230 @@
+package twofactor
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var ErrAlreadyEnabled = apperror.Conflict("Two-factor authentication is already enabled")
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Begin stores a new secret for the user to confirm. It replaces a secret
+// that was not confirmed yet, and fails with ErrAlreadyEnabled once one
+// was.
+func (r *Repository) Begin(ctx context.Context, userID int, secret string) error {
+	res, err := r.db.NewUpdate().
+		Model((*entity.User)(nil)).
+		Set("totp_secret = ?", secret).
+		Set("totp_last_step = NULL").
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Where("totp_enabled_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAlreadyEnabled
+	}
+
+	return nil
+}
+
+// Enable turns two-factor authentication on once the user entered the code
+// of step, and stores the hashes of their recovery codes.
+func (r *Repository) Enable(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model((*entity.User)(nil)).
+			Set("totp_enabled_at = ?", time.Now()).
+			Set("totp_last_step = ?", step).
+			Where("id = ?", userID).
+			Where("totp_secret IS NOT NULL AND totp_enabled_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrAlreadyEnabled
+		}
+
+		return replaceCodes(ctx, tx, userID, codeHashes)
+	})
+}
+
+// Disable turns two-factor authentication off and drops the secret and the
+// recovery codes.
+func (r *Repository) Disable(ctx context.Context, userID int) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*entity.User)(nil)).
+			Set("totp_secret = NULL").
+			Set("totp_enabled_at = NULL").
+			Set("totp_last_step = NULL").
+			Where("id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewDelete().
+			Model((*entity.RecoveryCode)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+// UseStep records that the code of step was accepted. It reports false when
+// a code of that step or a later one was already accepted, which means the
+// code is being replayed.
+func (r *Repository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*entity.User)(nil)).
+		Set("totp_last_step = ?", step).
+		Where("id = ?", userID).
+		Where("totp_last_step IS NULL OR totp_last_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used and
+// reports whether there was one.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*entity.RecoveryCode)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// ReplaceRecoveryCodes invalidates the recovery codes of the user in favour
+// of new ones.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return replaceCodes(ctx, tx, userID, codeHashes)
+	})
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has.
+func (r *Repository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	return r.db.NewSelect().
+		Model((*entity.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Count(ctx)
+}
+
+// IsRequired reports whether members of role must use two-factor
+// authentication.
+func (r *Repository) IsRequired(ctx context.Context, role string) (bool, error) {
+	return r.db.NewSelect().
+		Model((*entity.RolePolicy)(nil)).
+		Where("role = ?", role).
+		Where("require_two_factor").
+		Exists(ctx)
+}
+
+// RequiredRoles lists the roles whose members must use two-factor
+// authentication.
+func (r *Repository) RequiredRoles(ctx context.Context) ([]string, error) {
+	roles := []string{}
+
+	err := r.db.NewSelect().
+		Model((*entity.RolePolicy)(nil)).
+		Column("role").
+		Where("require_two_factor").
+		Order("role").
+		Scan(ctx, &roles)
+
+	return roles, basic_repo.DBError(err, "role policy")
+}
+
+// SetRequiredRoles requires two-factor authentication for exactly roles.
+func (r *Repository) SetRequiredRoles(ctx context.Context, roles []string) error {
+	required := map[string]bool{}
+	for _, role := range roles {
+		required[role] = true
+	}
+
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		for _, role := range entity.Roles {
+			_, err := tx.NewInsert().
+				Model(&entity.RolePolicy{Role: role, RequireTwoFactor: required[role], UpdatedAt: &now}).
+				On("CONFLICT (role) DO UPDATE").
+				Set("require_two_factor = EXCLUDED.require_two_factor").
+				Set("updated_at = EXCLUDED.updated_at").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func replaceCodes(ctx context.Context, tx bun.Tx, userID int, codeHashes []string) error {
+	_, err := tx.NewDelete().
+		Model((*entity.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	codes := make([]entity.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = entity.RecoveryCode{UserId: userID, CodeHash: hash, CreatedAt: &now}
+	}
+
+	_, err = tx.NewInsert().Model(&codes).Exec(ctx)
+	return err
+}
@@ -23,20 +23,35 @@ type UpdateUserRequest struct {
 	Password string `json:"password,omitempty" binding:"omitempty,min=6"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor viewer"`
+}
+
 type UserResponse struct {
-	ID        int        `json:"id"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	CreatedAt *time.Time `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TwoFactor       bool       `json:"two_factor_enabled"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
 }
 
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int          `json:"expires_in"`
+	User         UserResponse `json:"user"`
+	// RecoveryCodes are only returned by the login that enrolled the user
+	// in two-factor authentication.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type Filter struct {
 	Page  int `form:"page,default=1" binding:"min=1"`
 	Limit int `form:"limit,default=10" binding:"min=1,max=100"`
+	// Cursor is a next_cursor or prev_cursor token from a previous response.
+	// When set it takes precedence over Page.
+	Cursor *string `form:"cursor"`
 }
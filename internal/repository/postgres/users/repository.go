@@ -2,13 +2,18 @@ package users
 
 import (
 	"Movies-Go/internal/entity"
-	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/pkg/apperror"
+	"Movies-Go/internal/pkg/cursor"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
 	"context"
 	"time"
 
 	"github.com/uptrace/bun"
 )
 
+// ErrLastAdmin is returned when a role change would leave no admin.
+var ErrLastAdmin = apperror.Validation("Cannot demote the last admin")
+
 type Repository struct {
 	db *bun.DB
 }
@@ -25,35 +30,97 @@ func (r *Repository) Create(ctx context.Context, user *entity.User) error {
 	user.UpdatedAt = &now
 
 	_, err := r.db.NewInsert().Model(user).Exec(ctx)
-	return err
+	return basic_repo.DBError(err, "user")
 }
 
-func (r *Repository) GetAll(ctx context.Context, filter movies.Filter) ([]*entity.User, error) {
+// GetAll returns a page of users ordered by id, together with cursors for
+// the neighbouring pages. Pages are addressed either by filter.Page (OFFSET)
+// or, when filter.Cursor is set, by keyset on id.
+func (r *Repository) GetAll(ctx context.Context, filter Filter) ([]*entity.User, cursor.Page, error) {
 	var users []*entity.User
 
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := filter.Limit
+	if limit < 1 {
+		limit = 10
+	}
+
+	names := []string{"id"}
+	keys := []cursor.Key{{Column: "id"}}
+
 	query := r.db.NewSelect().
 		Model(&users).
-		Where("deleted_at IS NULL").
-		Order("id ASC")
+		Where("deleted_at IS NULL")
 
-	if filter.Page != nil && filter.Limit != nil {
-		page := *filter.Page
-		limit := *filter.Limit
+	var position *cursor.Cursor
+	if filter.Cursor != nil && *filter.Cursor != "" {
+		c, err := cursor.Decode(*filter.Cursor)
+		if err != nil {
+			return nil, cursor.Page{}, apperror.InvalidField("cursor", "is invalid or has been tampered with").Wrap(err)
+		}
 
-		if page < 1 {
-			page = 1
+		var id int
+		if c.Sort != cursor.Spec(names, keys) || c.Scan(&id) != nil {
+			return nil, cursor.Page{}, apperror.InvalidField("cursor", "is invalid or has been tampered with").Wrap(cursor.ErrInvalidCursor)
 		}
 
-		if limit < 1 {
-			limit = 10
+		position = &c
+		where, args := cursor.Where(keys, []interface{}{id}, c.Backward)
+		query = query.Where(where, args...)
+	} else {
+		query = query.Offset((page - 1) * limit)
+	}
+
+	backward := position != nil && position.Backward
+
+	err := query.
+		OrderExpr(cursor.OrderBy(keys, backward)).
+		Limit(limit + 1).
+		Scan(ctx)
+	if err != nil {
+		return nil, cursor.Page{}, err
+	}
+
+	hasMore := len(users) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+
+	if backward {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	hasNext := hasMore || backward
+	hasPrev := (position != nil && !backward) || (backward && hasMore) || (position == nil && page > 1)
+
+	var links cursor.Page
+	if len(users) > 0 && hasNext {
+		if links.NextCursor, err = userCursor(users[len(users)-1], names, keys, false); err != nil {
+			return nil, cursor.Page{}, err
+		}
+	}
+	if len(users) > 0 && hasPrev {
+		if links.PrevCursor, err = userCursor(users[0], names, keys, true); err != nil {
+			return nil, cursor.Page{}, err
 		}
+	}
+
+	return users, links, nil
+}
 
-		offset := (page - 1) * limit
-		query = query.Limit(limit).Offset(offset)
+func userCursor(user *entity.User, names []string, keys []cursor.Key, backward bool) (string, error) {
+	c, err := cursor.New(cursor.Spec(names, keys), []interface{}{user.Id}, backward)
+	if err != nil {
+		return "", err
 	}
 
-	err := query.Scan(ctx)
-	return users, err
+	return cursor.Encode(c)
 }
 
 func (r *Repository) GetByID(ctx context.Context, id int) (*entity.User, error) {
@@ -65,7 +132,7 @@ func (r *Repository) GetByID(ctx context.Context, id int) (*entity.User, error)
 		Scan(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, basic_repo.DBError(err, "user")
 	}
 
 	return user, nil
@@ -80,34 +147,112 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*entity.User
 		Scan(ctx)
 
 	if err != nil {
-		return nil, err
+		return nil, basic_repo.DBError(err, "user")
 	}
 
 	return user, nil
 }
 
+// Update saves the profile of the user. Role, login failures and two-factor
+// settings have their own methods and are left untouched, so that a
+// concurrent change to them is not reverted.
 func (r *Repository) Update(ctx context.Context, user *entity.User) error {
 	now := time.Now()
 	user.UpdatedAt = &now
 
 	_, err := r.db.NewUpdate().
 		Model(user).
+		Column("name", "email", "password", "email_verified_at", "updated_at").
 		Where("id = ? AND deleted_at IS NULL", user.Id).
 		Exec(ctx)
 
-	return err
+	return basic_repo.DBError(err, "user")
 }
 
 func (r *Repository) Delete(ctx context.Context, id int) error {
-	now := time.Now()
+	err := basic_repo.BasicDelete(ctx, basic_repo.Delete{Id: &id}, (*entity.User)(nil), r.db)
+	return basic_repo.DBError(err, "user")
+}
 
-	_, err := r.db.NewUpdate().
+// UpdateRole changes the role of user id. Demoting the last remaining admin
+// fails with ErrLastAdmin; the admin rows stay locked from the check to the
+// update, so that concurrent demotions of the last two admins cannot both
+// succeed.
+func (r *Repository) UpdateRole(ctx context.Context, id int, role string) error {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var admins []int
+		err := tx.NewSelect().
+			Model((*entity.User)(nil)).
+			Column("id").
+			Where("role = ? AND deleted_at IS NULL", entity.RoleAdmin).
+			For("UPDATE").
+			Scan(ctx, &admins)
+		if err != nil {
+			return err
+		}
+
+		if role != entity.RoleAdmin && len(admins) == 1 && admins[0] == id {
+			return ErrLastAdmin
+		}
+
+		res, err := tx.NewUpdate().
+			Model((*entity.User)(nil)).
+			Set("role = ?", role).
+			Set("updated_at = ?", time.Now()).
+			Where("id = ? AND deleted_at IS NULL", id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return apperror.NotFound("User not found")
+		}
+
+		return nil
+	})
+
+	return basic_repo.DBError(err, "user")
+}
+
+// RecordFailedLogin counts a failed login of user id. From threshold
+// consecutive failures on, the account is locked for duration, doubled with
+// every further failure up to max. It returns the end of the lock, or nil
+// while the account is not locked.
+func (r *Repository) RecordFailedLogin(ctx context.Context, id, threshold int, duration, max time.Duration) (*time.Time, error) {
+	var lockedUntil *time.Time
+
+	err := r.db.NewUpdate().
 		Model((*entity.User)(nil)).
-		Set("deleted_at = ?", now).
+		Set("failed_attempts = failed_attempts + 1").
+		Set(`locked_until = CASE WHEN failed_attempts + 1 >= ?0
+			THEN now() + make_interval(secs => LEAST(?1 * power(2, LEAST(failed_attempts + 1 - ?0, 30)), ?2))
+			ELSE locked_until END`, threshold, duration.Seconds(), max.Seconds()).
 		Where("id = ? AND deleted_at IS NULL", id).
+		Returning("locked_until").
+		Scan(ctx, &lockedUntil)
+	if err != nil {
+		return nil, basic_repo.DBError(err, "user")
+	}
+
+	if lockedUntil != nil && !lockedUntil.After(time.Now()) {
+		return nil, nil
+	}
+
+	return lockedUntil, nil
+}
+
+// ResetFailedLogins clears the failed logins and the lock of user id after
+// a successful login.
+func (r *Repository) ResetFailedLogins(ctx context.Context, id int) error {
+	_, err := r.db.NewUpdate().
+		Model((*entity.User)(nil)).
+		Set("failed_attempts = 0").
+		Set("locked_until = NULL").
+		Where("id = ?", id).
 		Exec(ctx)
 
-	return err
+	return basic_repo.DBError(err, "user")
 }
 
 func (r *Repository) Login(ctx context.Context, email, password string) (*entity.User, error) {
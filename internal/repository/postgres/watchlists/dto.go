@@ -0,0 +1,73 @@
+package watchlists
+
+import "time"
+
+type WatchlistFilter struct {
+	Page    *int    `form:"page,default=1" binding:"min=1"`
+	Limit   *int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Query   *string `form:"query" binding:"omitempty,max=255"`
+	Genre   *string `form:"genre" binding:"omitempty,max=255"`
+	Watched *bool   `form:"watched"`
+	Sort    string  `form:"sort,default=position" binding:"oneof=position -position added_at -added_at title -title year -year"`
+}
+
+type HistoryFilter struct {
+	Page        *int       `form:"page,default=1" binding:"min=1"`
+	Limit       *int       `form:"limit,default=20" binding:"min=1,max=100"`
+	Query       *string    `form:"query" binding:"omitempty,max=255"`
+	Genre       *string    `form:"genre" binding:"omitempty,max=255"`
+	WatchedFrom *time.Time `form:"watched_from" time_format:"2006-01-02"`
+	WatchedTo   *time.Time `form:"watched_to" time_format:"2006-01-02"`
+	Sort        string     `form:"sort,default=-watched_at" binding:"oneof=watched_at -watched_at title -title rewatch_count -rewatch_count"`
+}
+
+type AddWatchlistRequest struct {
+	MovieID *int `json:"movie_id" binding:"required,min=1"`
+	// Position inserts the movie at that place, shifting the rest down.
+	// The movie is appended when it is omitted.
+	Position *int    `json:"position" binding:"omitempty,min=1"`
+	Note     *string `json:"note" binding:"omitempty,max=1000"`
+}
+
+type UpdateWatchlistRequest struct {
+	Position *int    `json:"position" binding:"omitempty,min=1"`
+	Note     *string `json:"note" binding:"omitempty,max=1000"`
+}
+
+type AddHistoryRequest struct {
+	MovieID   *int    `json:"movie_id" binding:"required,min=1"`
+	WatchedAt *string `json:"watched_at" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type UpdateHistoryRequest struct {
+	WatchedAt    *string `json:"watched_at" binding:"omitempty,datetime=2006-01-02"`
+	RewatchCount *int    `json:"rewatch_count" binding:"omitempty,min=0"`
+}
+
+type MovieSummary struct {
+	ID             int     `json:"id"`
+	Title          string  `json:"title"`
+	Director       string  `json:"director"`
+	Year           int     `json:"year"`
+	Rating         float64 `json:"rating"`
+	WeightedRating float64 `json:"weighted_rating"`
+}
+
+type WatchlistItemResponse struct {
+	MovieID   int           `json:"movie_id"`
+	Position  int           `json:"position"`
+	Note      string        `json:"note,omitempty"`
+	Watched   bool          `json:"watched"`
+	Movie     *MovieSummary `json:"movie,omitempty"`
+	CreatedAt *time.Time    `json:"created_at"`
+	UpdatedAt *time.Time    `json:"updated_at"`
+}
+
+type HistoryEntryResponse struct {
+	MovieID      int           `json:"movie_id"`
+	WatchedAt    string        `json:"watched_at"`
+	RewatchCount int           `json:"rewatch_count"`
+	Movie        *MovieSummary `json:"movie,omitempty"`
+	CreatedAt    *time.Time    `json:"created_at"`
+	UpdatedAt    *time.Time    `json:"updated_at"`
+}
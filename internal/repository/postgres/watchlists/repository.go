@@ -0,0 +1,410 @@
+package watchlists
+
+import (
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/apperror"
+	basic_repo "Movies-Go/internal/repository/postgres/_basic_repo"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// watchlistSorts and historySorts map the public sort keys to columns.
+var watchlistSorts = basic_repo.Sorts{
+	Columns: map[string]string{
+		"position": "watchlist_item.position",
+		"added_at": "watchlist_item.created_at",
+		"title":    "movie.title",
+		"year":     "movie.year",
+	},
+	Tiebreaker: "watchlist_item.id",
+}
+
+var historySorts = basic_repo.Sorts{
+	Columns: map[string]string{
+		"watched_at":    "watch_history_entry.watched_at",
+		"title":         "movie.title",
+		"rewatch_count": "watch_history_entry.rewatch_count",
+	},
+	Tiebreaker: "watch_history_entry.id",
+}
+
+// watchedExpr tells whether a watchlist item's movie is also in its user's
+// watch history.
+const watchedExpr = `EXISTS (
+	SELECT 1 FROM watch_history AS h
+	WHERE h.user_id = watchlist_item.user_id AND h.movie_id = watchlist_item.movie_id
+)`
+
+// Status tells whether a movie is on the user's watchlist and in their
+// watch history.
+type Status struct {
+	InWatchlist bool
+	Watched     bool
+}
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// GetWatchlist returns a page of the user's watchlist, in list order unless
+// another sort is requested.
+func (r *Repository) GetWatchlist(ctx context.Context, userID int, filter WatchlistFilter) ([]*entity.WatchlistItem, int, error) {
+	page, limit := basic_repo.Pagination(filter.Page, filter.Limit, 20)
+
+	var items []*entity.WatchlistItem
+
+	query := r.db.NewSelect().
+		Model(&items).
+		ColumnExpr("?TableColumns").
+		ColumnExpr(watchedExpr+" AS watched").
+		Relation("Movie").
+		Where("watchlist_item.user_id = ?", userID).
+		Where("movie.deleted_at IS NULL")
+
+	query = movieFilter(query, filter.Query, filter.Genre)
+
+	if filter.Watched != nil {
+		if *filter.Watched {
+			query = query.Where(watchedExpr)
+		} else {
+			query = query.Where("NOT " + watchedExpr)
+		}
+	}
+
+	count, err := query.
+		OrderExpr(watchlistSorts.OrderBy(filter.Sort)).
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing watchlist: %w", err)
+	}
+
+	return items, count, nil
+}
+
+// GetWatchlistItem returns the movie's entry on the user's watchlist.
+func (r *Repository) GetWatchlistItem(ctx context.Context, userID, movieID int) (*entity.WatchlistItem, error) {
+	item := new(entity.WatchlistItem)
+
+	err := r.db.NewSelect().
+		Model(item).
+		ColumnExpr("?TableColumns").
+		ColumnExpr(watchedExpr+" AS watched").
+		Relation("Movie").
+		Where("watchlist_item.user_id = ? AND watchlist_item.movie_id = ?", userID, movieID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.NotFound("Movie is not on the watchlist")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// AddToWatchlist inserts item into its user's list. A zero or too large
+// Position appends it; otherwise the items from that position on move down.
+func (r *Repository) AddToWatchlist(ctx context.Context, item *entity.WatchlistItem) error {
+	now := time.Now()
+	item.CreatedAt = &now
+	item.UpdatedAt = &now
+
+	list := watchlist(item.UserId)
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		size, err := list.Lock(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		exists, err := tx.NewSelect().
+			Model((*entity.WatchlistItem)(nil)).
+			Where("user_id = ? AND movie_id = ?", item.UserId, item.MovieId).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return apperror.Conflict("Movie is already on the watchlist")
+		}
+
+		item.Position, err = list.Insert(ctx, tx, size, item.Position)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().Model(item).Returning("id").Exec(ctx)
+		return err
+	})
+
+	return basic_repo.DBError(err, "watchlist item")
+}
+
+// UpdateWatchlistItem moves the movie to position, if given, and replaces its
+// note, if given. Positions past the end of the list move it to the end.
+func (r *Repository) UpdateWatchlistItem(ctx context.Context, userID, movieID int, position *int, note *string) (*entity.WatchlistItem, error) {
+	item := new(entity.WatchlistItem)
+	list := watchlist(userID)
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		size, err := list.Lock(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		err = tx.NewSelect().
+			Model(item).
+			Where("user_id = ? AND movie_id = ?", userID, movieID).
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.NotFound("Movie is not on the watchlist")
+		}
+		if err != nil {
+			return err
+		}
+
+		if position != nil {
+			item.Position, err = list.Move(ctx, tx, size, item.Position, *position)
+			if err != nil {
+				return err
+			}
+		}
+
+		if note != nil {
+			item.Note = *note
+		}
+
+		now := time.Now()
+		item.UpdatedAt = &now
+
+		_, err = tx.NewUpdate().
+			Model(item).
+			Column("position", "note", "updated_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, basic_repo.DBError(err, "watchlist item")
+	}
+
+	return item, nil
+}
+
+// RemoveFromWatchlist deletes the movie from the user's list and closes the
+// gap it leaves.
+func (r *Repository) RemoveFromWatchlist(ctx context.Context, userID, movieID int) error {
+	list := watchlist(userID)
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := list.Lock(ctx, tx); err != nil {
+			return err
+		}
+
+		var positions []int
+		_, err := tx.NewDelete().
+			Model((*entity.WatchlistItem)(nil)).
+			Where("user_id = ? AND movie_id = ?", userID, movieID).
+			Returning("position").
+			Exec(ctx, &positions)
+		if err != nil {
+			return err
+		}
+		if len(positions) == 0 {
+			return apperror.NotFound("Movie is not on the watchlist")
+		}
+
+		return list.Close(ctx, tx, positions[0])
+	})
+
+	return basic_repo.DBError(err, "watchlist item")
+}
+
+// GetHistory returns a page of the movies the user has watched, most
+// recently watched first unless another sort is requested.
+func (r *Repository) GetHistory(ctx context.Context, userID int, filter HistoryFilter) ([]*entity.WatchHistoryEntry, int, error) {
+	page, limit := basic_repo.Pagination(filter.Page, filter.Limit, 20)
+
+	var entries []*entity.WatchHistoryEntry
+
+	query := r.db.NewSelect().
+		Model(&entries).
+		Relation("Movie").
+		Where("watch_history_entry.user_id = ?", userID).
+		Where("movie.deleted_at IS NULL")
+
+	query = movieFilter(query, filter.Query, filter.Genre)
+
+	if filter.WatchedFrom != nil {
+		query = query.Where("watch_history_entry.watched_at >= ?", filter.WatchedFrom.Format("2006-01-02"))
+	}
+	if filter.WatchedTo != nil {
+		query = query.Where("watch_history_entry.watched_at <= ?", filter.WatchedTo.Format("2006-01-02"))
+	}
+
+	count, err := query.
+		OrderExpr(historySorts.OrderBy(filter.Sort)).
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing watch history: %w", err)
+	}
+
+	return entries, count, nil
+}
+
+// MarkWatched records that the user watched the movie on watchedAt. Watching
+// a movie that is already in the history counts as a rewatch; the watched
+// date only moves forward.
+func (r *Repository) MarkWatched(ctx context.Context, userID, movieID int, watchedAt time.Time) (*entity.WatchHistoryEntry, error) {
+	now := time.Now()
+	entry := &entity.WatchHistoryEntry{
+		UserId:    userID,
+		MovieId:   movieID,
+		WatchedAt: watchedAt,
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+
+	_, err := r.db.NewInsert().
+		Model(entry).
+		On("CONFLICT (user_id, movie_id) DO UPDATE").
+		Set("rewatch_count = watch_history_entry.rewatch_count + 1").
+		Set("watched_at = GREATEST(watch_history_entry.watched_at, EXCLUDED.watched_at)").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, basic_repo.DBError(err, "history entry")
+	}
+
+	return entry, nil
+}
+
+// UpdateHistoryEntry corrects the watched date and/or rewatch count.
+func (r *Repository) UpdateHistoryEntry(ctx context.Context, userID, movieID int, watchedAt *time.Time, rewatchCount *int) (*entity.WatchHistoryEntry, error) {
+	entry := new(entity.WatchHistoryEntry)
+
+	err := r.db.NewSelect().
+		Model(entry).
+		Where("user_id = ? AND movie_id = ?", userID, movieID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.NotFound("Movie is not in the watch history")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if watchedAt != nil {
+		entry.WatchedAt = *watchedAt
+	}
+	if rewatchCount != nil {
+		entry.RewatchCount = *rewatchCount
+	}
+
+	now := time.Now()
+	entry.UpdatedAt = &now
+
+	_, err = r.db.NewUpdate().
+		Model(entry).
+		Column("watched_at", "rewatch_count", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return nil, basic_repo.DBError(err, "history entry")
+	}
+
+	return entry, nil
+}
+
+func (r *Repository) RemoveFromHistory(ctx context.Context, userID, movieID int) error {
+	res, err := r.db.NewDelete().
+		Model((*entity.WatchHistoryEntry)(nil)).
+		Where("user_id = ? AND movie_id = ?", userID, movieID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return apperror.NotFound("Movie is not in the watch history")
+	}
+
+	return nil
+}
+
+// Statuses reports, for each of movieIDs, whether it is on the user's
+// watchlist and in their history.
+func (r *Repository) Statuses(ctx context.Context, userID int, movieIDs []int) (map[int]Status, error) {
+	statuses := make(map[int]Status, len(movieIDs))
+	if len(movieIDs) == 0 {
+		return statuses, nil
+	}
+
+	var rows []struct {
+		MovieId     int  `bun:"movie_id"`
+		InWatchlist bool `bun:"in_watchlist"`
+		Watched     bool `bun:"watched"`
+	}
+
+	err := r.db.NewRaw(`
+		SELECT m.id AS movie_id,
+		       EXISTS (SELECT 1 FROM watchlist_items AS w WHERE w.user_id = ? AND w.movie_id = m.id) AS in_watchlist,
+		       EXISTS (SELECT 1 FROM watch_history AS h WHERE h.user_id = ? AND h.movie_id = m.id) AS watched
+		FROM movies AS m
+		WHERE m.id IN (?)`,
+		userID, userID, bun.In(movieIDs),
+	).Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		statuses[row.MovieId] = Status{InWatchlist: row.InWatchlist, Watched: row.Watched}
+	}
+
+	return statuses, nil
+}
+
+// watchlist is the user's ordered watchlist, locked through the user row.
+func watchlist(userID int) basic_repo.OrderedList {
+	return basic_repo.OrderedList{
+		Items:       (*entity.WatchlistItem)(nil),
+		Owner:       (*entity.User)(nil),
+		OwnerColumn: "user_id",
+		OwnerID:     userID,
+		Resource:    "user",
+	}
+}
+
+// movieFilter narrows a list joined with its movie by title and genre name.
+func movieFilter(query *bun.SelectQuery, title, genre *string) *bun.SelectQuery {
+	if title != nil && *title != "" {
+		query = query.Where("movie.title ILIKE ?", "%"+*title+"%")
+	}
+
+	if genre != nil && *genre != "" {
+		query = query.Where(`EXISTS (
+			SELECT 1 FROM movie_genres AS mg
+			JOIN genres AS g ON g.id = mg.genre_id
+			WHERE mg.movie_id = movie.id AND LOWER(g.name) = LOWER(?) AND g.deleted_at IS NULL
+		)`, *genre)
+	}
+
+	return query
+}
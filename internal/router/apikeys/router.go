@@ -0,0 +1,19 @@
+package apikeys
+
+import (
+	"Movies-Go/internal/controller/http/v1/apikeys"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *apikeys.Controller) {
+	keys := router.Group("/users/me/api-keys")
+
+	keys.Use(middleware.AuthMiddleware(), middleware.SessionOnly())
+	{
+		keys.GET("", controller.GetAll)
+		keys.POST("", controller.Create)
+		keys.DELETE("/:id", controller.Revoke)
+	}
+}
@@ -2,13 +2,53 @@ package auth
 
 import (
 	"Movies-Go/internal/controller/http/v1/auth"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/middleware"
+	"Movies-Go/internal/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
-func Router(router *gin.RouterGroup, controller *auth.Controller) {
+// Router registers the auth routes. limiter throttles the routes that
+// accept credentials per client address.
+func Router(router *gin.RouterGroup, controller *auth.Controller, limiter *ratelimit.Limiter) {
 	authGroup := router.Group("/auth")
 	{
-		authGroup.POST("/register", controller.Register)
-		authGroup.POST("/login", controller.Login)
+		throttled := authGroup.Group("")
+		throttled.Use(ratelimit.Middleware(limiter, ratelimit.ByIP))
+		{
+			throttled.POST("/register", controller.Register)
+			throttled.POST("/login", controller.Login)
+			throttled.POST("/refresh", controller.Refresh)
+			throttled.POST("/verify-email", controller.VerifyEmail)
+			throttled.POST("/verify-email/resend", controller.ResendVerification)
+			throttled.POST("/forgot-password", controller.ForgotPassword)
+			throttled.POST("/reset-password", controller.ResetPassword)
+			throttled.POST("/login/2fa", controller.LoginTwoFactor)
+			throttled.POST("/login/2fa/setup", controller.LoginTwoFactorSetup)
+			throttled.POST("/oidc/:provider/login", controller.StartOIDCLogin)
+			throttled.POST("/oidc/:provider/callback", controller.FinishOIDCLogin)
+		}
+
+		authGroup.GET("/oidc/providers", controller.OIDCProviders)
+
+		sessionGroup := authGroup.Group("")
+		sessionGroup.Use(middleware.AuthMiddleware(), middleware.SessionOnly())
+		{
+			sessionGroup.POST("/logout", controller.Logout)
+			sessionGroup.POST("/logout-all", controller.LogoutAll)
+
+			sessionGroup.GET("/2fa", controller.TwoFactorStatus)
+			sessionGroup.POST("/2fa/setup", controller.SetupTwoFactor)
+			sessionGroup.POST("/2fa/confirm", controller.ConfirmTwoFactor)
+			sessionGroup.POST("/2fa/recovery-codes", controller.RegenerateRecoveryCodes)
+			sessionGroup.POST("/2fa/disable", controller.DisableTwoFactor)
+
+			adminGroup := sessionGroup.Group("")
+			adminGroup.Use(middleware.RoleMiddleware(entity.RoleAdmin))
+			{
+				adminGroup.GET("/2fa/policy", controller.GetTwoFactorPolicy)
+				adminGroup.PUT("/2fa/policy", controller.UpdateTwoFactorPolicy)
+			}
+		}
 	}
 }
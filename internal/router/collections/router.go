@@ -0,0 +1,43 @@
+package collections
+
+import (
+	"Movies-Go/internal/controller/http/v1/collections"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *collections.Controller) {
+	collectionsGroup := router.Group("/collections")
+
+	collectionsGroup.Use(middleware.AuthMiddleware())
+	{
+		collectionsGroup.GET("", controller.GetPublic)
+		collectionsGroup.POST("", controller.Create)
+		collectionsGroup.GET("/:id", controller.GetByID)
+		collectionsGroup.PATCH("/:id", controller.Update)
+		collectionsGroup.DELETE("/:id", controller.Delete)
+		collectionsGroup.POST("/:id/copy", controller.Copy)
+
+		collectionsGroup.GET("/:id/items", controller.GetItems)
+		collectionsGroup.POST("/:id/items", controller.AddItem)
+		collectionsGroup.PUT("/:id/items", controller.Reorder)
+		collectionsGroup.PATCH("/:id/items/:movie_id", controller.UpdateItem)
+		collectionsGroup.DELETE("/:id/items/:movie_id", controller.RemoveItem)
+	}
+
+	userCollections := router.Group("/users")
+
+	userCollections.Use(middleware.AuthMiddleware())
+	{
+		userCollections.GET("/me/collections", controller.GetMine)
+		userCollections.GET("/:id/collections", controller.GetByUser)
+	}
+
+	movieCollections := router.Group("/movies/:id/collections")
+
+	movieCollections.Use(middleware.AuthMiddleware())
+	{
+		movieCollections.GET("", controller.GetByMovie)
+	}
+}
@@ -0,0 +1,31 @@
+package docs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"Movies-Go/internal/pkg/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// Router serves the OpenAPI document at /openapi.json and Swagger UI at
+// /docs. The document is encoded once, when the routes are registered.
+func Router(router *gin.RouterGroup, doc *openapi.Document) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		panic("docs: encoding the OpenAPI document: " + err.Error())
+	}
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+	})
+}
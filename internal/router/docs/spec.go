@@ -0,0 +1,617 @@
+package docs
+
+import (
+	"net/http"
+
+	"Movies-Go/internal/controller/http/v1/health"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/movieexport"
+	"Movies-Go/internal/pkg/openapi"
+	"Movies-Go/internal/pkg/version"
+	"Movies-Go/internal/pkg/xlsx"
+	"Movies-Go/internal/repository/postgres/apikeys"
+	"Movies-Go/internal/repository/postgres/collections"
+	"Movies-Go/internal/repository/postgres/genres"
+	"Movies-Go/internal/repository/postgres/identities"
+	"Movies-Go/internal/repository/postgres/movies"
+	"Movies-Go/internal/repository/postgres/people"
+	"Movies-Go/internal/repository/postgres/recommendations"
+	"Movies-Go/internal/repository/postgres/reviews"
+	"Movies-Go/internal/repository/postgres/sessions"
+	"Movies-Go/internal/repository/postgres/tokens"
+	"Movies-Go/internal/repository/postgres/twofactor"
+	"Movies-Go/internal/repository/postgres/users"
+	"Movies-Go/internal/repository/postgres/watchlists"
+)
+
+// BasePath is where the API routes are mounted.
+const BasePath = "/api/v1"
+
+// listMoviesQuery documents GET /movies, which reads page, limit, query and
+// cursor by hand before binding the filters.
+type listMoviesQuery struct {
+	Page   *int    `form:"page,default=1" binding:"min=1"`
+	Limit  *int    `form:"limit,default=10" binding:"min=1,max=100"`
+	Query  *string `form:"query"`
+	Cursor *string `form:"cursor"`
+	movies.MovieFilter
+}
+
+type exportMoviesQuery struct {
+	Format string  `form:"format,default=csv" binding:"oneof=csv ndjson xlsx"`
+	Query  *string `form:"query"`
+	movies.MovieFilter
+}
+
+// shareTokenQuery is accepted by the routes that show a collection.
+type shareTokenQuery struct {
+	Token *string `form:"token"`
+}
+
+type collectionItemsQuery struct {
+	collections.ItemFilter
+	shareTokenQuery
+}
+
+var editors = []string{entity.RoleAdmin, entity.RoleEditor}
+
+// Spec describes every route registered under BasePath.
+func Spec() *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title:   "Movies-Go API",
+		Version: version.Version,
+		Description: `
+A RESTful API for managing movie information. Authenticate with
+POST /auth/login and send the access token as "Authorization: Bearer <token>",
+sign in with an identity provider under /auth/oidc, or create a personal API
+key and send it as "X-API-Key: <key>".
+Errors are RFC 7807 problem documents.`,
+	}, BasePath)
+
+	cursors := map[string]*openapi.Schema{
+		"next_cursor": {Type: "string", Description: "Pass as cursor to fetch the next page"},
+		"prev_cursor": {Type: "string", Description: "Pass as cursor to fetch the previous page"},
+	}
+
+	d.AddTag("Health", "Service status and probes")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/health", Tag: "Health", Public: true,
+			Summary:  "Report that the service is up",
+			Response: d.Schema(health.HealthResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/livez", Tag: "Health", Public: true,
+			Summary:     "Liveness probe",
+			Description: "Succeeds while the process serves requests; checks no dependencies.",
+			Response:    d.Schema(health.LiveResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/readyz", Tag: "Health", Public: true,
+			Summary:     "Readiness probe",
+			Description: "Pings PostgreSQL and checks that the schema is at the newest migration. Responds 503 with the failing checks otherwise.",
+			Response:    d.Schema(health.ReadyResponse{}),
+			Responses:   map[int]*openapi.Schema{http.StatusServiceUnavailable: d.Schema(health.ReadyResponse{})},
+		},
+	)
+
+	d.AddTag("Auth", "Registration, login, sessions and account recovery")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/register", Tag: "Auth", Public: true,
+			Summary:     "Create an account",
+			Description: "Mails a link to verify the email address; logins are refused until it is followed. When the server allows unverified logins, the response is the one of /auth/login instead.",
+			Body:        users.RegisterRequest{}, Status: http.StatusCreated, Response: d.Data(users.UserResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/login", Tag: "Auth", Public: true,
+			Summary:     "Log in with email and password",
+			Description: "Logins are rate limited per client address and per account. Repeated failures lock the account for a growing period; logins to locked accounts get the same 401 as wrong passwords and unknown addresses. When the user has two-factor authentication enabled, or their role requires it, the response is 202 with a challenge token for POST /auth/login/2fa.",
+			Body:        users.LoginRequest{}, Response: d.Schema(users.AuthResponse{}),
+			Responses: map[int]*openapi.Schema{http.StatusAccepted: d.Schema(twofactor.ChallengeResponse{})},
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/refresh", Tag: "Auth", Public: true,
+			Summary:     "Exchange a refresh token for new tokens",
+			Description: "The refresh token is rotated: the one sent is no longer valid afterwards.",
+			Body:        sessions.RefreshRequest{}, Response: d.Schema(users.AuthResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/verify-email", Tag: "Auth", Public: true,
+			Summary: "Verify an email address with the token of a verification email",
+			Body:    tokens.VerifyEmailRequest{}, Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/verify-email/resend", Tag: "Auth", Public: true,
+			Summary:     "Send another verification email",
+			Description: "Responds the same whether or not the account exists or is already verified.",
+			Body:        tokens.ResendVerificationRequest{}, Status: http.StatusAccepted, Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/forgot-password", Tag: "Auth", Public: true,
+			Summary:     "Send a password reset email",
+			Description: "Responds the same whether or not the account exists.",
+			Body:        tokens.ForgotPasswordRequest{}, Status: http.StatusAccepted, Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/reset-password", Tag: "Auth", Public: true,
+			Summary:     "Set a new password with the token of a password reset email",
+			Description: "Revokes every session of the user.",
+			Body:        tokens.ResetPasswordRequest{}, Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/login/2fa", Tag: "Auth", Public: true,
+			Summary:     "Complete a login with a two-factor code",
+			Description: "Takes a code from the authenticator app or a recovery code. When enrolling during the login, the response also carries the recovery codes.",
+			Body:        twofactor.LoginRequest{}, Response: d.Schema(users.AuthResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/login/2fa/setup", Tag: "Auth", Public: true,
+			Summary:     "Start enrolling in two-factor authentication during a login",
+			Description: "For challenges with setup_required. Confirm with a code at POST /auth/login/2fa.",
+			Body:        twofactor.ChallengeRequest{}, Response: d.Data(twofactor.SetupResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/logout", Tag: "Auth", SessionOnly: true,
+			Summary:  "Revoke the current session",
+			Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/logout-all", Tag: "Auth", SessionOnly: true,
+			Summary: "Revoke every session of the current user",
+			Response: d.Data(struct {
+				Revoked int `json:"revoked"`
+			}{}),
+		},
+	)
+
+	d.AddTag("Single sign-on", "Login through OpenID Connect identity providers")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/auth/oidc/providers", Tag: "Single sign-on", Public: true,
+			Summary:  "List the identity providers users can sign in with",
+			Response: d.Data([]identities.ProviderResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/oidc/:provider/login", Tag: "Single sign-on", Public: true,
+			Summary:     "Start a sign-in at an identity provider",
+			Description: "Send the browser to authorization_url and keep flow_token for POST /auth/oidc/{provider}/callback.",
+			Response:    d.Data(identities.AuthorizationResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/oidc/:provider/callback", Tag: "Single sign-on", Public: true,
+			Summary:     "Finish a sign-in at an identity provider",
+			Description: "Submit the code and state the provider redirected back with. Responds like POST /auth/login, including the two-factor challenge.",
+			Body:        identities.CallbackRequest{}, Response: d.Schema(users.AuthResponse{}),
+			Responses: map[int]*openapi.Schema{http.StatusAccepted: d.Schema(twofactor.ChallengeResponse{})},
+		},
+	)
+
+	d.AddTag("Two-factor", "TOTP two-factor authentication")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/auth/2fa", Tag: "Two-factor", SessionOnly: true,
+			Summary:  "Show the two-factor status of the current user",
+			Response: d.Data(twofactor.StatusResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/2fa/setup", Tag: "Two-factor", SessionOnly: true,
+			Summary:     "Start enrolling in two-factor authentication",
+			Description: "Returns a new secret and its otpauth URI for an authenticator app. Confirm with POST /auth/2fa/confirm.",
+			Response:    d.Data(twofactor.SetupResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/2fa/confirm", Tag: "Two-factor", SessionOnly: true,
+			Summary:     "Enable two-factor authentication with a code from the authenticator",
+			Description: "Returns the recovery codes, which are shown only once.",
+			Body:        twofactor.CodeRequest{}, Response: d.Data(twofactor.RecoveryCodesResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/2fa/recovery-codes", Tag: "Two-factor", SessionOnly: true,
+			Summary: "Replace the recovery codes",
+			Body:    twofactor.CodeRequest{}, Response: d.Data(twofactor.RecoveryCodesResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/auth/2fa/disable", Tag: "Two-factor", SessionOnly: true,
+			Summary:     "Disable two-factor authentication",
+			Description: "Not allowed when the role of the user requires two-factor authentication.",
+			Body:        twofactor.DisableRequest{}, Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/auth/2fa/policy", Tag: "Two-factor", SessionOnly: true, Roles: []string{entity.RoleAdmin},
+			Summary:  "List the roles that require two-factor authentication",
+			Response: d.Data(twofactor.PolicyResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/auth/2fa/policy", Tag: "Two-factor", SessionOnly: true, Roles: []string{entity.RoleAdmin},
+			Summary:     "Set the roles that require two-factor authentication",
+			Description: "Members who are not enrolled must enroll at their next login; their sessions end at the next refresh.",
+			Body:        twofactor.PolicyRequest{}, Response: d.Data(twofactor.PolicyResponse{}),
+		},
+	)
+
+	d.AddTag("Users", "User accounts and roles")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users", Tag: "Users",
+			Summary: "List users",
+			Query:   users.Filter{}, Response: d.List(entity.User{}, cursors),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/:id", Tag: "Users",
+			Summary:  "Get a user",
+			Response: d.Data(entity.User{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/users/:id", Tag: "Users", SessionOnly: true,
+			Summary:     "Update a user",
+			Description: "Users can update themselves; admins can update anyone.",
+			Body:        users.UpdateUserRequest{}, Response: d.Data(entity.User{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/users/:id/role", Tag: "Users", SessionOnly: true, Roles: []string{entity.RoleAdmin},
+			Summary:     "Change a user's role",
+			Description: "Logs the user out of all sessions. The last remaining admin cannot be demoted.",
+			Body:        users.UpdateRoleRequest{}, Response: d.Data(entity.User{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/users/:id", Tag: "Users", SessionOnly: true, Roles: []string{entity.RoleAdmin},
+			Summary:  "Delete a user",
+			Response: openapi.Message(),
+		},
+	)
+
+	d.AddTag("API keys", "Personal API keys for scripts and integrations")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/me/api-keys", Tag: "API keys", SessionOnly: true,
+			Summary:  "List your API keys",
+			Response: d.List(apikeys.APIKeyResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/users/me/api-keys", Tag: "API keys", SessionOnly: true,
+			Summary:     "Create an API key",
+			Description: "The key is only returned in this response. The read scope allows GET requests, the write scope all others.",
+			Body:        apikeys.CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: d.Data(apikeys.CreatedAPIKeyResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/users/me/api-keys/:id", Tag: "API keys", SessionOnly: true,
+			Summary:  "Revoke an API key",
+			Response: openapi.Message(),
+		},
+	)
+
+	d.AddTag("Movies", "The movie catalog")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies", Tag: "Movies",
+			Summary:     "List movies",
+			Description: "Filters, sorting and pagination are described in the README.",
+			Query:       listMoviesQuery{}, Response: d.List(entity.Movie{}, cursors),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/search", Tag: "Movies",
+			Summary:     "Search movies",
+			Description: "Full-text search over title, director and plot with web search syntax.",
+			Query:       movies.SearchMovieRequest{},
+			Response: d.List(movies.MovieResponse{}, map[string]*openapi.Schema{
+				"page":        openapi.Integer(),
+				"limit":       openapi.Integer(),
+				"total_pages": openapi.Integer(),
+				"next_cursor": cursors["next_cursor"],
+				"prev_cursor": cursors["prev_cursor"],
+			}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/export", Tag: "Movies",
+			Summary:     "Download movies as CSV, NDJSON or xlsx",
+			Description: "Accepts the filters of GET /movies. The file is streamed as an attachment.",
+			Query:       exportMoviesQuery{},
+			Files: map[string]*openapi.Schema{
+				movieexport.FormatCSV.ContentType():    openapi.Binary(),
+				movieexport.FormatNDJSON.ContentType(): openapi.Binary(),
+				xlsx.ContentType:                       openapi.Binary(),
+			},
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/:id", Tag: "Movies",
+			Summary:  "Get a movie with its genres and credits",
+			Response: d.Data(entity.Movie{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/movies", Tag: "Movies", Roles: editors,
+			Summary: "Create a movie",
+			Body:    movies.CreateMovieRequest{}, Status: http.StatusCreated, Response: d.Data(entity.Movie{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/movies/import", Tag: "Movies", Roles: editors,
+			Summary: "Bulk import movies from CSV or NDJSON",
+			Description: "Send the file as the body or as the file field of a multipart form. " +
+				"Without batch_size nothing is written unless every row is valid, and the report " +
+				"is returned in a 400 problem under report.",
+			Query: movies.ImportRequest{},
+			Content: map[string]*openapi.Schema{
+				"text/csv":             openapi.Binary(),
+				"application/x-ndjson": openapi.Binary(),
+				"multipart/form-data":  openapi.Object(map[string]*openapi.Schema{"file": openapi.Binary()}, "file"),
+			},
+			Response: d.Data(movies.ImportReport{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/movies/:id", Tag: "Movies", Roles: editors,
+			Summary:     "Update a movie",
+			Description: "Omitted fields are kept. Omitting genre_ids keeps the genres, [] clears them.",
+			Body:        movies.UpdateMovieRequest{}, Response: d.Data(entity.Movie{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/movies/:id", Tag: "Movies", Roles: editors,
+			Summary:  "Delete a movie",
+			Response: openapi.Message(),
+		},
+	)
+
+	d.AddTag("Genres", "Genres movies are tagged with")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/genres", Tag: "Genres",
+			Summary:  "List genres",
+			Response: d.Data([]genres.GenreResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/genres/:id", Tag: "Genres",
+			Summary:  "Get a genre",
+			Response: d.Data(genres.GenreResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/genres/:id/movies", Tag: "Genres",
+			Summary: "List the movies of a genre",
+			Query:   movies.Filter{}, Response: d.List(entity.Movie{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/genres", Tag: "Genres", Roles: editors,
+			Summary: "Create a genre",
+			Body:    genres.CreateGenreRequest{}, Status: http.StatusCreated, Response: d.Data(genres.GenreResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/genres/:id", Tag: "Genres", Roles: editors,
+			Summary: "Update a genre",
+			Body:    genres.UpdateGenreRequest{}, Response: d.Data(genres.GenreResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/genres/:id", Tag: "Genres", Roles: editors,
+			Summary:  "Delete a genre and untag it from all movies",
+			Response: openapi.Message(),
+		},
+	)
+
+	d.AddTag("People", "Cast and crew, and their credits on movies")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/people", Tag: "People",
+			Summary: "List or search people",
+			Query:   people.Filter{}, Response: d.List(people.PersonResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/people/:id", Tag: "People",
+			Summary:  "Get a person",
+			Response: d.Data(people.PersonResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/people/:id/filmography", Tag: "People",
+			Summary:  "List a person's credits",
+			Response: d.Data(people.FilmographyResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/people", Tag: "People", Roles: editors,
+			Summary: "Create a person",
+			Body:    people.CreatePersonRequest{}, Status: http.StatusCreated, Response: d.Data(people.PersonResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/people/:id", Tag: "People", Roles: editors,
+			Summary: "Update a person",
+			Body:    people.UpdatePersonRequest{}, Response: d.Data(people.PersonResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/people/:id", Tag: "People", Roles: editors,
+			Summary:  "Delete a person",
+			Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/:id/credits", Tag: "People",
+			Summary:  "List the credits of a movie",
+			Response: d.Data([]people.CreditResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/movies/:id/credits", Tag: "People", Roles: editors,
+			Summary: "Credit a person on a movie",
+			Body:    people.CreateCreditRequest{}, Status: http.StatusCreated, Response: d.Data(people.CreditResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/movies/:id/credits/:credit_id", Tag: "People", Roles: editors,
+			Summary: "Update a credit",
+			Body:    people.UpdateCreditRequest{}, Response: d.Data(people.CreditResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/movies/:id/credits/:credit_id", Tag: "People", Roles: editors,
+			Summary:  "Delete a credit",
+			Response: openapi.Message(),
+		},
+	)
+
+	d.AddTag("Reviews", "Scores and reviews of movies")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/:id/reviews", Tag: "Reviews",
+			Summary: "List the reviews of a movie",
+			Query:   movies.Filter{}, Response: d.List(reviews.ReviewResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/movies/:id/reviews", Tag: "Reviews",
+			Summary: "Create or replace the current user's review of a movie",
+			Body:    reviews.UpsertReviewRequest{}, Response: d.Data(reviews.ReviewResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/movies/:id/reviews", Tag: "Reviews",
+			Summary:  "Delete the current user's review of a movie",
+			Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/:id/reviews", Tag: "Reviews",
+			Summary: "List the reviews written by a user",
+			Query:   movies.Filter{}, Response: d.List(reviews.ReviewResponse{}, nil),
+		},
+	)
+
+	d.AddTag("Watchlist", "The current user's watchlist and watch history")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/me/watchlist", Tag: "Watchlist",
+			Summary: "List the watchlist",
+			Query:   watchlists.WatchlistFilter{}, Response: d.List(watchlists.WatchlistItemResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/users/me/watchlist", Tag: "Watchlist",
+			Summary: "Add a movie to the watchlist",
+			Body:    watchlists.AddWatchlistRequest{}, Status: http.StatusCreated, Response: d.Data(watchlists.WatchlistItemResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPatch, Path: "/users/me/watchlist/:movie_id", Tag: "Watchlist",
+			Summary: "Move a watchlist entry or change its note",
+			Body:    watchlists.UpdateWatchlistRequest{}, Response: d.Data(watchlists.WatchlistItemResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/users/me/watchlist/:movie_id", Tag: "Watchlist",
+			Summary:  "Remove a movie from the watchlist",
+			Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/me/history", Tag: "Watchlist",
+			Summary: "List the watch history",
+			Query:   watchlists.HistoryFilter{}, Response: d.List(watchlists.HistoryEntryResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/users/me/history", Tag: "Watchlist",
+			Summary:     "Mark a movie as watched",
+			Description: "Marking a movie that is already in the history counts a rewatch.",
+			Body:        watchlists.AddHistoryRequest{}, Status: http.StatusCreated, Response: d.Data(watchlists.HistoryEntryResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/users/me/history/:movie_id", Tag: "Watchlist",
+			Summary: "Correct a history entry",
+			Body:    watchlists.UpdateHistoryRequest{}, Response: d.Data(watchlists.HistoryEntryResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/users/me/history/:movie_id", Tag: "Watchlist",
+			Summary:  "Remove a movie from the history",
+			Response: openapi.Message(),
+		},
+	)
+
+	d.AddTag("Collections", "User-curated, shareable lists of movies")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/collections", Tag: "Collections",
+			Summary: "List public collections",
+			Query:   collections.ListFilter{}, Response: d.List(collections.CollectionResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/collections", Tag: "Collections",
+			Summary: "Create a collection",
+			Body:    collections.CreateCollectionRequest{}, Status: http.StatusCreated, Response: d.Data(collections.CollectionResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/collections/:id", Tag: "Collections",
+			Summary:     "Get a collection",
+			Description: "Unlisted collections of other users need their share token.",
+			Query:       shareTokenQuery{}, Response: d.Data(collections.CollectionResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPatch, Path: "/collections/:id", Tag: "Collections",
+			Summary: "Update a collection you own",
+			Body:    collections.UpdateCollectionRequest{}, Response: d.Data(collections.CollectionResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/collections/:id", Tag: "Collections",
+			Summary:  "Delete a collection you own",
+			Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/collections/:id/copy", Tag: "Collections",
+			Summary:     "Copy a collection you can see",
+			Description: "The body is optional.",
+			Query:       shareTokenQuery{},
+			Body:        collections.CopyCollectionRequest{}, Status: http.StatusCreated, Response: d.Data(collections.CollectionResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/collections/:id/items", Tag: "Collections",
+			Summary: "List the movies of a collection",
+			Query:   collectionItemsQuery{}, Response: d.List(collections.ItemResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodPost, Path: "/collections/:id/items", Tag: "Collections",
+			Summary: "Add a movie to a collection",
+			Body:    collections.AddItemRequest{}, Status: http.StatusCreated, Response: d.Data(collections.ItemResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodPut, Path: "/collections/:id/items", Tag: "Collections",
+			Summary: "Reorder a collection",
+			Body:    collections.ReorderRequest{}, Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodPatch, Path: "/collections/:id/items/:movie_id", Tag: "Collections",
+			Summary: "Move a movie in a collection or change its note",
+			Body:    collections.UpdateItemRequest{}, Response: d.Data(collections.ItemResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodDelete, Path: "/collections/:id/items/:movie_id", Tag: "Collections",
+			Summary:  "Remove a movie from a collection",
+			Response: openapi.Message(),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/me/collections", Tag: "Collections",
+			Summary: "List the current user's collections",
+			Query:   collections.ListFilter{}, Response: d.List(collections.CollectionResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/:id/collections", Tag: "Collections",
+			Summary: "List a user's public collections",
+			Query:   collections.ListFilter{}, Response: d.List(collections.CollectionResponse{}, nil),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/:id/collections", Tag: "Collections",
+			Summary: "List the public collections containing a movie",
+			Query:   collections.ListFilter{}, Response: d.List(collections.CollectionResponse{}, nil),
+		},
+	)
+
+	d.AddTag("Recommendations", "Similar movies and personal recommendations")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/movies/:id/similar", Tag: "Recommendations",
+			Summary: "List movies similar to a movie",
+			Query:   recommendations.Filter{}, Response: d.Data(recommendations.SimilarListResponse{}),
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/users/me/recommendations", Tag: "Recommendations",
+			Summary:     "List recommendations for the current user",
+			Description: "Users without personal recommendations yet get the best rated movies they have not seen, with source popular.",
+			Query:       recommendations.UserFilter{}, Response: d.Data(recommendations.RecommendationListResponse{}),
+		},
+	)
+
+	d.AddTag("Documentation", "This document")
+	d.Add(
+		openapi.Route{
+			Method: http.MethodGet, Path: "/openapi.json", Tag: "Documentation", Public: true,
+			Summary:  "Get the OpenAPI document",
+			Response: &openapi.Schema{Type: "object"},
+		},
+		openapi.Route{
+			Method: http.MethodGet, Path: "/docs", Tag: "Documentation", Public: true,
+			Summary: "Browse the API with Swagger UI",
+			Files:   map[string]*openapi.Schema{"text/html": openapi.String()},
+		},
+	)
+
+	return d
+}
@@ -0,0 +1,28 @@
+package genres
+
+import (
+	"Movies-Go/internal/controller/http/v1/genres"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *genres.Controller) {
+	genresGroup := router.Group("/genres")
+
+	genresGroup.Use(middleware.AuthMiddleware())
+	{
+		genresGroup.GET("", controller.GetAll)
+		genresGroup.GET("/:id", controller.GetByID)
+		genresGroup.GET("/:id/movies", controller.GetMovies)
+
+		editorGroup := genresGroup.Group("")
+		editorGroup.Use(middleware.RoleMiddleware(entity.RoleAdmin, entity.RoleEditor))
+		{
+			editorGroup.POST("", controller.Create)
+			editorGroup.PUT("/:id", controller.Update)
+			editorGroup.DELETE("/:id", controller.Delete)
+		}
+	}
+}
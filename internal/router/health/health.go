@@ -0,0 +1,14 @@
+package health
+
+import (
+	"Movies-Go/internal/controller/http/v1/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Router registers the probes. They need no authentication.
+func Router(router *gin.RouterGroup, controller *health.Controller) {
+	router.GET("/health", controller.Health)
+	router.GET("/livez", controller.Live)
+	router.GET("/readyz", controller.Ready)
+}
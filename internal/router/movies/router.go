@@ -2,6 +2,7 @@ package movies
 
 import (
 	"Movies-Go/internal/controller/http/v1/movies"
+	"Movies-Go/internal/entity"
 	"Movies-Go/internal/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -15,8 +16,15 @@ func Router(router *gin.RouterGroup, controller *movies.Controller) {
 		moviesGroup.GET("", controller.GetAll)
 		moviesGroup.GET("/:id", controller.GetByID)
 		moviesGroup.GET("/search", controller.Search)
-		moviesGroup.POST("", controller.Create)
-		moviesGroup.PUT("/:id", controller.Update)
-		moviesGroup.DELETE("/:id", controller.Delete)
+		moviesGroup.GET("/export", controller.Export)
+
+		editorGroup := moviesGroup.Group("")
+		editorGroup.Use(middleware.RoleMiddleware(entity.RoleAdmin, entity.RoleEditor))
+		{
+			editorGroup.POST("", controller.Create)
+			editorGroup.POST("/import", controller.Import)
+			editorGroup.PUT("/:id", controller.Update)
+			editorGroup.DELETE("/:id", controller.Delete)
+		}
 	}
 }
@@ -0,0 +1,43 @@
+package people
+
+import (
+	"Movies-Go/internal/controller/http/v1/people"
+	"Movies-Go/internal/entity"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *people.Controller) {
+	peopleGroup := router.Group("/people")
+
+	peopleGroup.Use(middleware.AuthMiddleware())
+	{
+		peopleGroup.GET("", controller.GetAll)
+		peopleGroup.GET("/:id", controller.GetByID)
+		peopleGroup.GET("/:id/filmography", controller.Filmography)
+
+		editorGroup := peopleGroup.Group("")
+		editorGroup.Use(middleware.RoleMiddleware(entity.RoleAdmin, entity.RoleEditor))
+		{
+			editorGroup.POST("", controller.Create)
+			editorGroup.PUT("/:id", controller.Update)
+			editorGroup.DELETE("/:id", controller.Delete)
+		}
+	}
+
+	creditsGroup := router.Group("/movies/:id/credits")
+
+	creditsGroup.Use(middleware.AuthMiddleware())
+	{
+		creditsGroup.GET("", controller.GetCredits)
+
+		editorGroup := creditsGroup.Group("")
+		editorGroup.Use(middleware.RoleMiddleware(entity.RoleAdmin, entity.RoleEditor))
+		{
+			editorGroup.POST("", controller.CreateCredit)
+			editorGroup.PUT("/:credit_id", controller.UpdateCredit)
+			editorGroup.DELETE("/:credit_id", controller.DeleteCredit)
+		}
+	}
+}
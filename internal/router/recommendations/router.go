@@ -0,0 +1,24 @@
+package recommendations
+
+import (
+	"Movies-Go/internal/controller/http/v1/recommendations"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *recommendations.Controller) {
+	similar := router.Group("/movies/:id/similar")
+
+	similar.Use(middleware.AuthMiddleware())
+	{
+		similar.GET("", controller.Similar)
+	}
+
+	personal := router.Group("/users/me/recommendations")
+
+	personal.Use(middleware.AuthMiddleware())
+	{
+		personal.GET("", controller.ForCurrentUser)
+	}
+}
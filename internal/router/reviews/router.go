@@ -0,0 +1,26 @@
+package reviews
+
+import (
+	"Movies-Go/internal/controller/http/v1/reviews"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *reviews.Controller) {
+	movieReviews := router.Group("/movies/:id/reviews")
+
+	movieReviews.Use(middleware.AuthMiddleware())
+	{
+		movieReviews.GET("", controller.GetByMovie)
+		movieReviews.PUT("", controller.Upsert)
+		movieReviews.DELETE("", controller.Delete)
+	}
+
+	userReviews := router.Group("/users/:id/reviews")
+
+	userReviews.Use(middleware.AuthMiddleware())
+	{
+		userReviews.GET("", controller.GetByUser)
+	}
+}
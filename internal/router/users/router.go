@@ -2,6 +2,7 @@ package users
 
 import (
 	"Movies-Go/internal/controller/http/v1/users"
+	"Movies-Go/internal/entity"
 	"Movies-Go/internal/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -14,10 +15,15 @@ func Router(router *gin.RouterGroup, controller *users.Controller) {
 		{
 			usersGroup.GET("", controller.GetAll)
 			usersGroup.GET("/:id", controller.GetByID)
+			// Changing the email address or password takes a login.
+			usersGroup.PUT("/:id", middleware.SessionOnly(), controller.Update)
 
+			// Managing other accounts takes a login, so that a leaked API key
+			// of an admin cannot promote or delete users.
 			adminGroup := usersGroup.Group("")
+			adminGroup.Use(middleware.SessionOnly(), middleware.RoleMiddleware(entity.RoleAdmin))
 			{
-				adminGroup.PUT("/:id", controller.Update)
+				adminGroup.PUT("/:id/role", controller.UpdateRole)
 				adminGroup.DELETE("/:id", controller.Delete)
 			}
 		}
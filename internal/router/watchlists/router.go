@@ -0,0 +1,30 @@
+package watchlists
+
+import (
+	"Movies-Go/internal/controller/http/v1/watchlists"
+	"Movies-Go/internal/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Router(router *gin.RouterGroup, controller *watchlists.Controller) {
+	watchlist := router.Group("/users/me/watchlist")
+
+	watchlist.Use(middleware.AuthMiddleware())
+	{
+		watchlist.GET("", controller.GetWatchlist)
+		watchlist.POST("", controller.AddToWatchlist)
+		watchlist.PATCH("/:movie_id", controller.UpdateWatchlistItem)
+		watchlist.DELETE("/:movie_id", controller.RemoveFromWatchlist)
+	}
+
+	history := router.Group("/users/me/history")
+
+	history.Use(middleware.AuthMiddleware())
+	{
+		history.GET("", controller.GetHistory)
+		history.POST("", controller.MarkWatched)
+		history.PUT("/:movie_id", controller.UpdateHistoryEntry)
+		history.DELETE("/:movie_id", controller.RemoveFromHistory)
+	}
+}
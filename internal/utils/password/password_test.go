@@ -0,0 +1,18 @@
+package password
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestDummyHashCost keeps VerifyDummy as slow as Verify of a real hash.
+func TestDummyHashCost(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyHash))
+	if err != nil {
+		t.Fatalf("dummyHash is not a bcrypt hash: %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummyHash has cost %d, Hash uses %d", cost, bcrypt.DefaultCost)
+	}
+}